@@ -0,0 +1,352 @@
+package amqp
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+/*
+AmqpConnection connection to AMQP 0-9-1 broker like RabbitMQ shared by message queues.
+The connection implements IMessageQueueConnection to declare and delete queues.
+Every queue is declared durable with a dead letter exchange that routes
+rejected messages into a <name>.dead queue.
+
+AMQP 0-9-1 cannot list queues on a broker, so ReadQueueNames returns
+the queues created through this connection.
+
+Configuration parameters:
+
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  connection protocol: amqp or amqps (default: amqp)
+    - host:                      host name or IP address
+    - port:                      port number (default: 5672 or 5671 for amqps)
+    - vhost:                     virtual host (default: /)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - dead_letter_exchange:      name of the dead letter exchange (default: pip-services.dead-letter)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See IMessageQueueConnection
+See AmqpMessageQueue
+*/
+type AmqpConnection struct {
+	Logger             *clog.CompositeLogger
+	ConnectionResolver *cconn.ConnectionResolver
+	CredentialResolver *cauth.CredentialResolver
+	deadLetterExchange string
+	connection         *amqp091.Connection
+	queueNames         map[string]bool
+	lock               sync.Mutex
+}
+
+// NewAmqpConnection method are creates a new instance of the connection component.
+func NewAmqpConnection() *AmqpConnection {
+	c := AmqpConnection{
+		Logger:             clog.NewCompositeLogger(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		CredentialResolver: cauth.NewEmptyCredentialResolver(),
+		deadLetterExchange: "pip-services.dead-letter",
+		queueNames:         map[string]bool{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *AmqpConnection) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+	c.CredentialResolver.Configure(config)
+
+	c.deadLetterExchange = config.GetAsStringWithDefault("options.dead_letter_exchange", c.deadLetterExchange)
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *AmqpConnection) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+	c.CredentialResolver.SetReferences(references)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *AmqpConnection) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.connection != nil && !c.connection.IsClosed()
+}
+
+// Open method are resolves connection parameters and opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *AmqpConnection) Open(correlationId string) error {
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return err
+	}
+	if len(connections) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection parameters are not set")
+	}
+
+	credential, err := c.CredentialResolver.Lookup(correlationId)
+	if err != nil {
+		return err
+	}
+
+	return c.OpenWithParams(correlationId, connections, credential)
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *AmqpConnection) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.connection != nil {
+		return nil
+	}
+	if len(connections) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection parameters are not set")
+	}
+
+	uri, err := c.composeUri(correlationId, connections[0], credential)
+	if err != nil {
+		return err
+	}
+
+	connection, err := amqp091.Dial(uri)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to AMQP broker").
+			WithCause(err)
+	}
+
+	c.connection = connection
+	c.Logger.Debug(correlationId, "Connected to AMQP broker at %s", connection.RemoteAddr())
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *AmqpConnection) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.connection == nil {
+		return nil
+	}
+
+	err := c.connection.Close()
+	c.connection = nil
+	if err != nil && err != amqp091.ErrClosed {
+		return cerr.NewConnectionError(correlationId, "DISCONNECT_FAILED", "Failed to disconnect from AMQP broker").
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Disconnected from AMQP broker")
+	return nil
+}
+
+// GetConnection method are gets the opened broker connection or nil if the connection is closed.
+func (c *AmqpConnection) GetConnection() *amqp091.Connection {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.connection
+}
+
+// OpenChannel method are opens a new channel on the broker connection.
+// Returns: the opened channel or error.
+func (c *AmqpConnection) OpenChannel() (*amqp091.Channel, error) {
+	connection, err := c.checkOpen("")
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := connection.Channel()
+	return channel, c.wrapError("", err)
+}
+
+// GetDeadLetterExchange method are gets the name of the exchange that routes dead letters.
+func (c *AmqpConnection) GetDeadLetterExchange() string {
+	return c.deadLetterExchange
+}
+
+// ReadQueueNames method are reads names of queues created through this connection.
+// Returns: a list with queue names or error.
+func (c *AmqpConnection) ReadQueueNames() ([]string, error) {
+	if _, err := c.checkOpen(""); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queueNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateQueue method are declares a durable queue with its dead letter queue if they do not exist.
+//   - name    a name of the queue to be created.
+// Returns: error or nil for success.
+func (c *AmqpConnection) CreateQueue(name string) error {
+	if name == "" {
+		return cerr.NewBadRequestError("", "NO_QUEUE", "Queue name is not set")
+	}
+
+	channel, err := c.OpenChannel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	err = channel.ExchangeDeclare(c.deadLetterExchange, amqp091.ExchangeDirect, true, false, false, false, nil)
+	if err == nil {
+		_, err = channel.QueueDeclare(DeadLetterQueueName(name), true, false, false, false, nil)
+	}
+	if err == nil {
+		err = channel.QueueBind(DeadLetterQueueName(name), name, c.deadLetterExchange, false, nil)
+	}
+	if err == nil {
+		_, err = channel.QueueDeclare(name, true, false, false, false, amqp091.Table{
+			"x-dead-letter-exchange":    c.deadLetterExchange,
+			"x-dead-letter-routing-key": name,
+		})
+	}
+	if err != nil {
+		return c.wrapError("", err)
+	}
+
+	c.lock.Lock()
+	c.queueNames[name] = true
+	c.lock.Unlock()
+
+	return nil
+}
+
+// DeleteQueue method are deletes a queue with its dead letter queue and all their messages.
+//   - name    a name of the queue to be deleted.
+// Returns: error or nil for success.
+func (c *AmqpConnection) DeleteQueue(name string) error {
+	channel, err := c.OpenChannel()
+	if err != nil {
+		return err
+	}
+	defer channel.Close()
+
+	_, err = channel.QueueDelete(name, false, false, false)
+	if err == nil {
+		_, err = channel.QueueDelete(DeadLetterQueueName(name), false, false, false)
+	}
+	if err != nil {
+		return c.wrapError("", err)
+	}
+
+	c.lock.Lock()
+	delete(c.queueNames, name)
+	c.lock.Unlock()
+
+	return nil
+}
+
+// DeadLetterQueueName method are gets the name of the queue that keeps dead letters of the given queue.
+//   - name    a name of the queue.
+// Returns: the name of the dead letter queue.
+func DeadLetterQueueName(name string) string {
+	return name + ".dead"
+}
+
+func (c *AmqpConnection) checkOpen(correlationId string) (*amqp091.Connection, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.connection == nil || c.connection.IsClosed() {
+		return nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Connection to AMQP broker is not opened")
+	}
+	return c.connection, nil
+}
+
+func (c *AmqpConnection) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*cerr.ApplicationError); ok {
+		return err
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute AMQP operation").
+		WithCause(err)
+}
+
+// composeUri composes AMQP broker url from connection and credential parameters.
+func (c *AmqpConnection) composeUri(correlationId string, connection *cconn.ConnectionParams,
+	credential *cauth.CredentialParams) (string, error) {
+
+	uri := url.URL{}
+	if value := connection.Uri(); value != "" {
+		parsed, err := url.Parse(value)
+		if err != nil {
+			return "", cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid AMQP connection uri").
+				WithCause(err)
+		}
+		uri = *parsed
+	} else {
+		host := connection.Host()
+		if host == "" {
+			return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+		}
+
+		uri.Scheme = connection.Protocol()
+		if uri.Scheme == "" {
+			uri.Scheme = "amqp"
+		}
+		if uri.Scheme != "amqp" && uri.Scheme != "amqps" {
+			return "", cerr.NewConfigError(correlationId, "WRONG_PROTOCOL", "Unsupported AMQP protocol "+uri.Scheme).
+				WithDetails("protocol", uri.Scheme)
+		}
+
+		port := 5672
+		if uri.Scheme == "amqps" {
+			port = 5671
+		}
+		uri.Host = host + ":" + strconv.Itoa(connection.PortWithDefault(port))
+		if vhost := connection.GetAsString("vhost"); vhost != "" {
+			uri.Path = "/" + vhost
+		}
+	}
+
+	if credential != nil && credential.Username() != "" {
+		uri.User = url.UserPassword(credential.Username(), credential.Password())
+	}
+
+	return uri.String(), nil
+}
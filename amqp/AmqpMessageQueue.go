@@ -0,0 +1,579 @@
+package amqp
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+/*
+AmqpMessageQueue message queue that is implemented on top of AMQP 0-9-1 broker like RabbitMQ.
+
+Messages are published to the default exchange with publisher confirms,
+so Send returns only after the broker took responsibility for the message.
+Messages are received with manual acknowledgements: Complete acks a message,
+Abandon nacks it with requeue and MoveToDeadLetter nacks it without requeue,
+so the broker routes it through the dead letter exchange into <name>.dead queue.
+Unacknowledged messages return into the queue when the channel is closed.
+Peek receives messages and immediately returns them into the queue.
+
+Configuration parameters:
+
+  - name:                        name of the message queue
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  connection protocol: amqp or amqps (default: amqp)
+    - host:                      host name or IP address
+    - port:                      port number (default: 5672 or 5671 for amqps)
+    - vhost:                     virtual host (default: /)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - dead_letter_exchange:      name of the dead letter exchange (default: pip-services.dead-letter)
+    - timeout:                   timeout in milliseconds to wait for publisher confirms (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+- *:connection:amqp:*:1.0    (optional)  Shared AmqpConnection; when absent the queue opens its own connection
+
+See MessageQueue
+See AmqpConnection
+
+Example:
+
+    queue := NewAmqpMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 5672,
+        "credential.username", "guest",
+        "credential.password", "guest",
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type AmqpMessageQueue struct {
+	queues.MessageQueue
+	dependencyResolver *cref.DependencyResolver
+	config             *cconf.ConfigParams
+	references         cref.IReferences
+	localConnection    *AmqpConnection
+
+	// The connection to the broker
+	Connection *AmqpConnection
+
+	channel     *amqp091.Channel
+	channelLock sync.Mutex
+	timeout     time.Duration
+	opened      int32
+	cancel      int32
+}
+
+// amqpDelivery is a reference of received message in the channel it was received from.
+type amqpDelivery struct {
+	channel *amqp091.Channel
+	tag     uint64
+}
+
+// Names of message headers
+const (
+	headerSentTime = "pip-sent-time"
+	headerGroupId  = "pip-group-id"
+	// Headers with these prefixes are set by the queue or the broker and are not passed to messages
+	headerPrefix       = "pip-"
+	brokerHeaderPrefix = "x-"
+)
+
+// NewAmqpMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *AmqpMessageQueue
+// See MessagingCapabilities
+func NewAmqpMessageQueue(name string) *AmqpMessageQueue {
+	c := AmqpMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, false, true, true, true),
+	)
+
+	c.dependencyResolver = cref.NewDependencyResolver()
+	c.dependencyResolver.Put("connection", cref.NewDescriptor("pip-services", "connection", "amqp", "*", "1.0"))
+	c.config = cconf.NewEmptyConfigParams()
+	c.timeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *AmqpMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.config = config
+	c.dependencyResolver.Configure(config)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *AmqpMessageQueue) SetReferences(references cref.IReferences) {
+	c.MessageQueue.SetReferences(references)
+
+	c.references = references
+	c.dependencyResolver.SetReferences(references)
+	connection, ok := c.dependencyResolver.GetOneOptional("connection").(*AmqpConnection)
+	if ok {
+		c.Connection = connection
+	}
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *AmqpMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// Open method are opens the component.
+// When no shared connection is referenced, connection parameters are resolved
+// by the queue and a local connection is opened.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *AmqpMessageQueue) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	if c.Connection != nil && c.localConnection == nil {
+		return c.openQueue(correlationId)
+	}
+
+	return c.MessageQueue.Open(correlationId)
+}
+
+// OpenWithParams method are opens a local connection with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *AmqpMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.localConnection == nil {
+		c.localConnection = NewAmqpConnection()
+		c.localConnection.Configure(c.config)
+		if c.references != nil {
+			c.localConnection.SetReferences(c.references)
+		}
+		c.Connection = c.localConnection
+	}
+
+	if err := c.localConnection.OpenWithParams(correlationId, connections, credential); err != nil {
+		return err
+	}
+
+	return c.openQueue(correlationId)
+}
+
+func (c *AmqpMessageQueue) openQueue(correlationId string) error {
+	if !c.Connection.IsOpen() {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to AMQP broker is not opened")
+	}
+
+	if err := c.Connection.CreateQueue(c.Name()); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+	c.Logger.Debug(correlationId, "Opened queue %s", c.Name())
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Messages that were received but not completed return into the queue.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *AmqpMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+	atomic.StoreInt32(&c.opened, 0)
+
+	c.channelLock.Lock()
+	if c.channel != nil {
+		c.channel.Close()
+		c.channel = nil
+	}
+	c.channelLock.Unlock()
+
+	if c.localConnection != nil {
+		if err := c.localConnection.Close(correlationId); err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+	return nil
+}
+
+// Clear method are purges all messages and dead letters of the queue.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *AmqpMessageQueue) Clear(correlationId string) error {
+	channel, err := c.getChannel(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = channel.QueuePurge(c.Name(), false)
+	if err == nil {
+		_, err = channel.QueuePurge(DeadLetterQueueName(c.Name()), false)
+	}
+
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *AmqpMessageQueue) ReadMessageCount() (int64, error) {
+	channel, err := c.getChannel("")
+	if err != nil {
+		return 0, err
+	}
+
+	queue, err := channel.QueueDeclarePassive(c.Name(), true, false, false, false, nil)
+	if err != nil {
+		return 0, c.Connection.wrapError("", err)
+	}
+
+	return int64(queue.Messages), nil
+}
+
+// Send method are publishes a message into the queue and waits for the broker confirmation.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *AmqpMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	channel, err := c.getChannel(correlationId)
+	if err != nil {
+		return err
+	}
+
+	envelope.SentTime = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", c.Name(), false, false, fromMessage(envelope))
+	if err != nil {
+		return c.Connection.wrapError(correlationId, err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return c.Connection.wrapError(correlationId, err)
+	}
+	if !acked {
+		return cerr.NewConnectionError(correlationId, "NOT_CONFIRMED", "Broker rejected message sent to "+c.Name())
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *AmqpMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// Messages are received and returned into the queue with a single nack, so they keep their order.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *AmqpMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	messages, err := c.browse(correlationId, c.Name(), messageCount)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *AmqpMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		channel, err := c.getChannel(correlationId)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery, ok, err := channel.Get(c.Name(), false)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+
+		if ok {
+			message := toMessage(&delivery)
+			message.SetReference(&amqpDelivery{channel: channel, tag: delivery.DeliveryTag})
+
+			c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+			c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+			return message, nil
+		}
+
+		if !time.Now().Before(deadline) || atomic.LoadInt32(&c.cancel) != 0 {
+			return nil, nil
+		}
+		time.Sleep(time.Duration(100) * time.Millisecond)
+	}
+}
+
+// RenewLock method are not supported by AMQP. Received messages stay locked until they are completed,
+// abandoned or the channel is closed.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *AmqpMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	return nil
+}
+
+// Complete method are acknowledges a message and permanently removes it from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *AmqpMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	delivery, ok := message.GetReference().(*amqpDelivery)
+	if !ok {
+		return nil
+	}
+
+	err := delivery.channel.Ack(delivery.tag, false)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// The message is negatively acknowledged with requeue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *AmqpMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	delivery, ok := message.GetReference().(*amqpDelivery)
+	if !ok {
+		return nil
+	}
+
+	err := delivery.channel.Nack(delivery.tag, false, true)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue.
+// The message is negatively acknowledged without requeue and the broker routes it to the dead letter queue.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *AmqpMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	delivery, ok := message.GetReference().(*amqpDelivery)
+	if !ok {
+		return nil
+	}
+
+	err := delivery.channel.Nack(delivery.tag, false, false)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *AmqpMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *AmqpMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads messages from the dead letter queue without removing them.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *AmqpMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	return c.browse(correlationId, DeadLetterQueueName(c.Name()), -1)
+}
+
+// browse receives up to messageCount messages (or all messages if it is negative)
+// and returns them back into the queue. A separate channel is used,
+// so the nack does not return messages received by the queue channel.
+func (c *AmqpMessageQueue) browse(correlationId string, queue string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := c.Connection.OpenChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+
+	messages := []*queues.MessageEnvelope{}
+	lastTag := uint64(0)
+	for messageCount < 0 || int64(len(messages)) < messageCount {
+		delivery, ok, err := channel.Get(queue, false)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+		if !ok {
+			break
+		}
+		messages = append(messages, toMessage(&delivery))
+		lastTag = delivery.DeliveryTag
+	}
+
+	if lastTag > 0 {
+		err = channel.Nack(lastTag, true, true)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+	}
+
+	return messages, nil
+}
+
+// getChannel returns the queue channel and reopens it when it was closed by a channel error.
+func (c *AmqpMessageQueue) getChannel(correlationId string) (*amqp091.Channel, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.channelLock.Lock()
+	defer c.channelLock.Unlock()
+
+	if c.channel != nil && !c.channel.IsClosed() {
+		return c.channel, nil
+	}
+
+	channel, err := c.Connection.OpenChannel()
+	if err != nil {
+		return nil, err
+	}
+	err = channel.Confirm(false)
+	if err != nil {
+		channel.Close()
+		return nil, c.Connection.wrapError(correlationId, err)
+	}
+
+	c.channel = channel
+	return channel, nil
+}
+
+func fromMessage(message *queues.MessageEnvelope) amqp091.Publishing {
+	headers := amqp091.Table{headerSentTime: message.SentTime.UnixMilli()}
+	if message.GroupId != "" {
+		headers[headerGroupId] = message.GroupId
+	}
+	for name, value := range message.Headers {
+		headers[name] = value
+	}
+
+	return amqp091.Publishing{
+		MessageId:     message.MessageId,
+		CorrelationId: message.CorrelationId,
+		Type:          message.MessageType,
+		Timestamp:     message.SentTime,
+		Headers:       headers,
+		DeliveryMode:  amqp091.Persistent,
+		Body:          message.Message,
+	}
+}
+
+func toMessage(delivery *amqp091.Delivery) *queues.MessageEnvelope {
+	message := queues.NewEmptyMessageEnvelope()
+	message.MessageId = delivery.MessageId
+	message.CorrelationId = delivery.CorrelationId
+	message.MessageType = delivery.Type
+	message.SentTime = delivery.Timestamp
+	if millis, ok := delivery.Headers[headerSentTime].(int64); ok {
+		message.SentTime = time.UnixMilli(millis)
+	}
+	message.GroupId, _ = delivery.Headers[headerGroupId].(string)
+	message.Message = delivery.Body
+	for name, value := range delivery.Headers {
+		if strings.HasPrefix(name, headerPrefix) || strings.HasPrefix(name, brokerHeaderPrefix) {
+			continue
+		}
+		if value, ok := value.(string); ok {
+			message.SetHeader(name, value)
+		}
+	}
+	return message
+}
@@ -0,0 +1,56 @@
+package amqp
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// AmqpMessageQueueFactory are creates AmqpMessageQueue and AmqpConnection components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See AmqpMessageQueue
+// See AmqpConnection
+type AmqpMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewAmqpMessageQueueFactory method are create a new instance of the factory.
+func NewAmqpMessageQueueFactory() *AmqpMessageQueueFactory {
+	c := AmqpMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	amqpQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "amqp", "*", "1.0")
+	amqpConnectionDescriptor := cref.NewDescriptor("pip-services", "connection", "amqp", "*", "1.0")
+
+	c.Register(amqpQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(amqpConnectionDescriptor, NewAmqpConnection)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *AmqpMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewAmqpMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
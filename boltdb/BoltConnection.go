@@ -0,0 +1,239 @@
+package boltdb
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	bbolt "go.etcd.io/bbolt"
+)
+
+var (
+	queuesBucket      = []byte("queues")
+	messagesBucket    = []byte("messages")
+	locksBucket       = []byte("locks")
+	deadBucket        = []byte("dead")
+	availableBucket   = []byte("available")
+	expirationsBucket = []byte("expirations")
+)
+
+/*
+BoltConnection connection to an embedded bbolt database that stores message queues.
+All queues share a single database file, and each of them is kept in its own bucket.
+The connection implements IMessageQueueConnection to list, create and delete queues in the file.
+
+Configuration parameters:
+
+  - path:                        path to the database file
+  - options:
+    - timeout:                   timeout in milliseconds to obtain the file lock (default: 5000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+
+See IMessageQueueConnection
+See BoltMessageQueue
+*/
+type BoltConnection struct {
+	Logger  *clog.CompositeLogger
+	path    string
+	timeout time.Duration
+	db      *bbolt.DB
+	lock    sync.Mutex
+}
+
+// NewBoltConnection method are creates a new instance of the connection component.
+func NewBoltConnection() *BoltConnection {
+	c := BoltConnection{
+		Logger:  clog.NewCompositeLogger(),
+		timeout: 5000 * time.Millisecond,
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *BoltConnection) Configure(config *cconf.ConfigParams) {
+	c.path = config.GetAsStringWithDefault("path", c.path)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *BoltConnection) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *BoltConnection) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.db != nil
+}
+
+// Open method are opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BoltConnection) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.db != nil {
+		return nil
+	}
+
+	if c.path == "" {
+		return cerr.NewConfigError(correlationId, "NO_PATH", "Path to the database file is not set")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_CREATE_DIR", "Failed to create directory for database "+c.path).
+			WithCause(err)
+	}
+
+	db, err := bbolt.Open(c.path, 0644, &bbolt.Options{Timeout: c.timeout})
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT", "Failed to open database "+c.path).
+			WithCause(err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(queuesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT", "Failed to initialize database "+c.path).
+			WithCause(err)
+	}
+
+	c.db = db
+	c.Logger.Debug(correlationId, "Opened database %s", c.path)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BoltConnection) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.db == nil {
+		return nil
+	}
+
+	err := c.db.Close()
+	c.db = nil
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_DISCONNECT", "Failed to close database "+c.path).
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Closed database %s", c.path)
+	return nil
+}
+
+// GetDB method are gets the opened database or nil if the connection is closed.
+func (c *BoltConnection) GetDB() *bbolt.DB {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.db
+}
+
+// ReadQueueNames method are reads names of all queues stored in the database.
+// Returns: a list with queue names or error.
+func (c *BoltConnection) ReadQueueNames() ([]string, error) {
+	db, err := c.checkOpen()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	err = db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(queuesBucket).ForEachBucket(func(name []byte) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+	sort.Strings(names)
+
+	return names, err
+}
+
+// CreateQueue method are creates a queue in the database if it does not exist.
+//   - name    a name of the queue to be created.
+// Returns: error or nil for success.
+func (c *BoltConnection) CreateQueue(name string) error {
+	db, err := c.checkOpen()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		_, err := createQueueBucket(tx, name)
+		return err
+	})
+}
+
+// DeleteQueue method are deletes a queue with all its messages from the database.
+//   - name    a name of the queue to be deleted.
+// Returns: error or nil for success.
+func (c *BoltConnection) DeleteQueue(name string) error {
+	db, err := c.checkOpen()
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		err := tx.Bucket(queuesBucket).DeleteBucket([]byte(name))
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+func (c *BoltConnection) checkOpen() (*bbolt.DB, error) {
+	db := c.GetDB()
+	if db == nil {
+		return nil, cerr.NewInvalidStateError("", "NOT_OPENED", "Connection to database "+c.path+" is not opened")
+	}
+	return db, nil
+}
+
+// createQueueBucket creates a queue bucket with its nested buckets.
+// Indexes of queues created by previous versions are built from their messages.
+func createQueueBucket(tx *bbolt.Tx, name string) (*bbolt.Bucket, error) {
+	if name == "" {
+		return nil, cerr.NewBadRequestError("", "NO_QUEUE", "Queue name is not set")
+	}
+
+	bucket, err := tx.Bucket(queuesBucket).CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	indexed := bucket.Bucket(availableBucket) != nil
+	for _, nested := range [][]byte{messagesBucket, locksBucket, deadBucket, availableBucket, expirationsBucket} {
+		if _, err = bucket.CreateBucketIfNotExists(nested); err != nil {
+			return nil, err
+		}
+	}
+	if !indexed {
+		if err = indexMessages(bucket); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
@@ -0,0 +1,716 @@
+package boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	bbolt "go.etcd.io/bbolt"
+)
+
+/*
+BoltMessageQueue message queue that stores messages in an embedded bbolt database.
+Every operation runs in a database transaction, so receiving and locking a message is atomic.
+Locks are stored together with messages and expire by time, so messages locked by a crashed
+process become available again after restart once their locks expire.
+Available messages and lock expirations are kept in separate index buckets,
+so receiving a message does not scan the whole queue.
+
+Configuration parameters:
+
+  - name:                        name of the message queue
+  - path:                        path to the database file (used when no connection is referenced)
+  - options:
+    - lock_timeout:              timeout in milliseconds to lock received messages (default: 30000)
+    - timeout:                   timeout in milliseconds to obtain the database file lock (default: 5000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:connection:bolt:*:1.0    (optional)  Shared BoltConnection; when absent the queue opens its own connection
+
+See MessageQueue
+See BoltConnection
+
+Example:
+
+    queue := NewBoltMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "path", "./data/queues.db",
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type BoltMessageQueue struct {
+	queues.MessageQueue
+	dependencyResolver *cref.DependencyResolver
+	config             *cconf.ConfigParams
+	localConnection    *BoltConnection
+
+	// The connection to the database
+	Connection *BoltConnection
+
+	lockTimeout time.Duration
+	opened      int32
+	cancel      int32
+}
+
+// boltRecord is a message stored in the database together with its lock.
+type boltRecord struct {
+	Message    *queues.MessageEnvelope `json:"msg"`
+	Token      uint64                  `json:"token,omitempty"`
+	Expiration int64                   `json:"exp,omitempty"`
+}
+
+// NewBoltMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *BoltMessageQueue
+// See MessagingCapabilities
+func NewBoltMessageQueue(name string) *BoltMessageQueue {
+	c := BoltMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.dependencyResolver = cref.NewDependencyResolver()
+	c.dependencyResolver.Put("connection", cref.NewDescriptor("pip-services", "connection", "bolt", "*", "1.0"))
+	c.config = cconf.NewEmptyConfigParams()
+	c.lockTimeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *BoltMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.config = config
+	c.dependencyResolver.Configure(config)
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *BoltMessageQueue) SetReferences(references cref.IReferences) {
+	c.MessageQueue.SetReferences(references)
+
+	c.dependencyResolver.SetReferences(references)
+	connection, ok := c.dependencyResolver.GetOneOptional("connection").(*BoltConnection)
+	if ok {
+		c.Connection = connection
+	}
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *BoltMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// Open method are opens the component and creates the queue bucket if it does not exist.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BoltMessageQueue) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	if c.Connection == nil {
+		c.localConnection = NewBoltConnection()
+		c.localConnection.Configure(c.config)
+		c.localConnection.Logger = c.Logger
+		c.Connection = c.localConnection
+	}
+
+	if c.localConnection != nil {
+		if err := c.localConnection.Open(correlationId); err != nil {
+			return err
+		}
+	}
+
+	if !c.Connection.IsOpen() {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to database is not opened")
+	}
+
+	if err := c.Connection.CreateQueue(c.Name()); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+	c.Logger.Debug(correlationId, "Opened queue %s", c.Name())
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BoltMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+	atomic.StoreInt32(&c.opened, 0)
+
+	if c.localConnection != nil {
+		if err := c.localConnection.Close(correlationId); err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+	return nil
+}
+
+// Clear method are removes all messages and locks from the queue.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BoltMessageQueue) Clear(correlationId string) error {
+	return c.update(correlationId, func(bucket *bbolt.Bucket) error {
+		for _, nested := range [][]byte{messagesBucket, locksBucket, deadBucket, availableBucket, expirationsBucket} {
+			if err := bucket.DeleteBucket(nested); err != nil && err != bbolt.ErrBucketNotFound {
+				return err
+			}
+			if _, err := bucket.CreateBucket(nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *BoltMessageQueue) ReadMessageCount() (count int64, err error) {
+	now := time.Now().UnixNano()
+	err = c.view("", func(bucket *bbolt.Bucket) error {
+		return bucket.Bucket(messagesBucket).ForEach(func(key []byte, value []byte) error {
+			record, err := decodeRecord(value)
+			if err != nil {
+				return err
+			}
+			if record.isAvailable(now) {
+				count++
+			}
+			return nil
+		})
+	})
+	return count, err
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *BoltMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	envelope.SentTime = time.Now()
+
+	err := c.update(correlationId, func(bucket *bbolt.Bucket) error {
+		return putAvailable(bucket, &boltRecord{Message: envelope})
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *BoltMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *BoltMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	messages := []*queues.MessageEnvelope{}
+	now := time.Now().UnixNano()
+
+	err := c.view(correlationId, func(bucket *bbolt.Bucket) error {
+		cursor := bucket.Bucket(messagesBucket).Cursor()
+		for key, value := cursor.First(); key != nil && int64(len(messages)) < messageCount; key, value = cursor.Next() {
+			record, err := decodeRecord(value)
+			if err != nil {
+				return err
+			}
+			if record.isAvailable(now) {
+				messages = append(messages, record.Message)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and locks it in the same transaction.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *BoltMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	var message *queues.MessageEnvelope
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		err := c.update(correlationId, func(bucket *bbolt.Bucket) error {
+			messages := bucket.Bucket(messagesBucket)
+			locks := bucket.Bucket(locksBucket)
+			available := bucket.Bucket(availableBucket)
+			now := time.Now()
+
+			if err := releaseExpired(bucket, now.UnixNano()); err != nil {
+				return err
+			}
+
+			for {
+				key, _ := available.Cursor().First()
+				if key == nil {
+					return nil
+				}
+				key = append([]byte{}, key...)
+				if err := available.Delete(key); err != nil {
+					return err
+				}
+
+				value := messages.Get(key)
+				if value == nil {
+					continue
+				}
+				record, err := decodeRecord(value)
+				if err != nil {
+					return err
+				}
+
+				token, err := locks.NextSequence()
+				if err != nil {
+					return err
+				}
+				record.Token = token
+				record.Expiration = now.Add(c.lockTimeout).UnixNano()
+
+				if err = locks.Put(encodeKey(token), key); err != nil {
+					return err
+				}
+				if err = bucket.Bucket(expirationsBucket).Put(expirationKey(record.Expiration, token), key); err != nil {
+					return err
+				}
+				if err = messages.Put(key, encodeRecord(record)); err != nil {
+					return err
+				}
+
+				message = record.Message
+				message.SetReference(token)
+				return nil
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if message != nil || !time.Now().Before(deadline) || atomic.LoadInt32(&c.cancel) != 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if message != nil {
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	}
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// This method is usually used to extend the message processing time.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *BoltMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	token, ok := message.GetReference().(uint64)
+	if !ok {
+		return nil
+	}
+	if lockTimeout <= 0 {
+		lockTimeout = c.lockTimeout
+	}
+
+	err := c.update(message.CorrelationId, func(bucket *bbolt.Bucket) error {
+		key, record, err := findLocked(bucket, token)
+		if err != nil || record == nil {
+			return err
+		}
+
+		expirations := bucket.Bucket(expirationsBucket)
+		if err = expirations.Delete(expirationKey(record.Expiration, token)); err != nil {
+			return err
+		}
+		record.Expiration = time.Now().Add(lockTimeout).UnixNano()
+		if err = expirations.Put(expirationKey(record.Expiration, token), key); err != nil {
+			return err
+		}
+		return bucket.Bucket(messagesBucket).Put(key, encodeRecord(record))
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *BoltMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(uint64)
+	if !ok {
+		return nil
+	}
+
+	err := c.update(message.CorrelationId, func(bucket *bbolt.Bucket) error {
+		key, record, err := findLocked(bucket, token)
+		if err != nil || record == nil {
+			return err
+		}
+
+		if err = releaseLock(bucket, record); err != nil {
+			return err
+		}
+		return bucket.Bucket(messagesBucket).Delete(key)
+	})
+	if err != nil {
+		return err
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *BoltMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(uint64)
+	if !ok {
+		return nil
+	}
+
+	abandoned := false
+	err := c.update(message.CorrelationId, func(bucket *bbolt.Bucket) error {
+		key, record, err := findLocked(bucket, token)
+		if err != nil || record == nil {
+			return err
+		}
+
+		// Move the message to the end of the queue
+		if err = releaseLock(bucket, record); err != nil {
+			return err
+		}
+		if err = bucket.Bucket(messagesBucket).Delete(key); err != nil {
+			return err
+		}
+		abandoned = true
+		return putAvailable(bucket, &boltRecord{Message: record.Message})
+	})
+	if err != nil || !abandoned {
+		return err
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and stores it in the dead letter bucket.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *BoltMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(uint64)
+	if !ok {
+		return nil
+	}
+
+	err := c.update(message.CorrelationId, func(bucket *bbolt.Bucket) error {
+		key, record, err := findLocked(bucket, token)
+		if err != nil || record == nil {
+			return err
+		}
+
+		if err = releaseLock(bucket, record); err != nil {
+			return err
+		}
+		if err = bucket.Bucket(messagesBucket).Delete(key); err != nil {
+			return err
+		}
+		return putRecord(bucket.Bucket(deadBucket), &boltRecord{Message: record.Message})
+	})
+	if err != nil {
+		return err
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *BoltMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *BoltMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads messages that were moved to the dead letter bucket of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *BoltMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	messages := []*queues.MessageEnvelope{}
+	err := c.view(correlationId, func(bucket *bbolt.Bucket) error {
+		return bucket.Bucket(deadBucket).ForEach(func(key []byte, value []byte) error {
+			record, err := decodeRecord(value)
+			if err == nil {
+				messages = append(messages, record.Message)
+			}
+			return err
+		})
+	})
+	return messages, err
+}
+
+// view runs a read-only transaction on the queue bucket.
+// If the queue bucket does not exist the function is not called.
+func (c *BoltMessageQueue) view(correlationId string, fn func(bucket *bbolt.Bucket) error) error {
+	if err := c.CheckOpen(correlationId); err != nil {
+		return err
+	}
+	db, err := c.Connection.checkOpen()
+	if err != nil {
+		return err
+	}
+
+	err = db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(queuesBucket).Bucket([]byte(c.Name()))
+		if bucket == nil {
+			return nil
+		}
+		return fn(bucket)
+	})
+	return c.wrapError(correlationId, err)
+}
+
+// update runs a read-write transaction on the queue bucket and creates the bucket when it is missing.
+func (c *BoltMessageQueue) update(correlationId string, fn func(bucket *bbolt.Bucket) error) error {
+	if err := c.CheckOpen(correlationId); err != nil {
+		return err
+	}
+	db, err := c.Connection.checkOpen()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := createQueueBucket(tx, c.Name())
+		if err != nil {
+			return err
+		}
+		return fn(bucket)
+	})
+	return c.wrapError(correlationId, err)
+}
+
+func (c *BoltMessageQueue) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*cerr.ApplicationError); ok {
+		return err
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to access queue "+c.Name()).
+		WithCause(err)
+}
+
+// findLocked finds a message locked with the given token.
+// It returns nil record when the lock does not exist anymore.
+func findLocked(bucket *bbolt.Bucket, token uint64) ([]byte, *boltRecord, error) {
+	key := bucket.Bucket(locksBucket).Get(encodeKey(token))
+	if key == nil {
+		return nil, nil, nil
+	}
+	key = append([]byte{}, key...)
+
+	value := bucket.Bucket(messagesBucket).Get(key)
+	if value == nil {
+		return nil, nil, nil
+	}
+	record, err := decodeRecord(value)
+	if err != nil || record.Token != token {
+		return nil, nil, err
+	}
+	return key, record, nil
+}
+
+// putRecord stores a record under the next sequential key of the bucket.
+func putRecord(bucket *bbolt.Bucket, record *boltRecord) error {
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(encodeKey(seq), encodeRecord(record))
+}
+
+// putAvailable stores a record as the last message of the queue and adds it to the index of available messages.
+func putAvailable(bucket *bbolt.Bucket, record *boltRecord) error {
+	messages := bucket.Bucket(messagesBucket)
+	seq, err := messages.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := encodeKey(seq)
+	if err = messages.Put(key, encodeRecord(record)); err != nil {
+		return err
+	}
+	return bucket.Bucket(availableBucket).Put(key, []byte{})
+}
+
+// releaseLock removes the lock of a record and its expiration from the indexes.
+func releaseLock(bucket *bbolt.Bucket, record *boltRecord) error {
+	if err := bucket.Bucket(locksBucket).Delete(encodeKey(record.Token)); err != nil {
+		return err
+	}
+	return bucket.Bucket(expirationsBucket).Delete(expirationKey(record.Expiration, record.Token))
+}
+
+// releaseExpired returns messages with expired locks into the index of available messages.
+// Expirations are ordered by time, so only expired locks are visited.
+func releaseExpired(bucket *bbolt.Bucket, now int64) error {
+	expirations := bucket.Bucket(expirationsBucket)
+	for {
+		key, value := expirations.Cursor().First()
+		if key == nil || int64(binary.BigEndian.Uint64(key)) > now {
+			return nil
+		}
+		key = append([]byte{}, key...)
+		value = append([]byte{}, value...)
+
+		if err := bucket.Bucket(locksBucket).Delete(key[8:]); err != nil {
+			return err
+		}
+		if err := expirations.Delete(key); err != nil {
+			return err
+		}
+		if err := bucket.Bucket(availableBucket).Put(value, []byte{}); err != nil {
+			return err
+		}
+	}
+}
+
+// indexMessages builds indexes of available messages and lock expirations from stored messages.
+func indexMessages(bucket *bbolt.Bucket) error {
+	return bucket.Bucket(messagesBucket).ForEach(func(key []byte, value []byte) error {
+		record, err := decodeRecord(value)
+		if err != nil {
+			return err
+		}
+		if record.Token == 0 {
+			return bucket.Bucket(availableBucket).Put(key, []byte{})
+		}
+		if err = bucket.Bucket(locksBucket).Put(encodeKey(record.Token), key); err != nil {
+			return err
+		}
+		return bucket.Bucket(expirationsBucket).Put(expirationKey(record.Expiration, record.Token), key)
+	})
+}
+
+func (c *boltRecord) isAvailable(now int64) bool {
+	return c.Token == 0 || c.Expiration <= now
+}
+
+func encodeKey(value uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, value)
+	return key
+}
+
+// expirationKey composes a key of the lock expiration index, so locks are ordered by their expiration time.
+func expirationKey(expiration int64, token uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(expiration))
+	binary.BigEndian.PutUint64(key[8:], token)
+	return key
+}
+
+func encodeRecord(record *boltRecord) []byte {
+	message := *record.Message
+	message.SetReference(nil)
+	value, _ := json.Marshal(&boltRecord{Message: &message, Token: record.Token, Expiration: record.Expiration})
+	return value
+}
+
+func decodeRecord(value []byte) (*boltRecord, error) {
+	record := boltRecord{}
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+	if record.Message == nil {
+		record.Message = queues.NewEmptyMessageEnvelope()
+	}
+	return &record, nil
+}
@@ -0,0 +1,56 @@
+package boltdb
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// BoltMessageQueueFactory are creates BoltMessageQueue and BoltConnection components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See BoltMessageQueue
+// See BoltConnection
+type BoltMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewBoltMessageQueueFactory method are create a new instance of the factory.
+func NewBoltMessageQueueFactory() *BoltMessageQueueFactory {
+	c := BoltMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	boltQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "bolt", "*", "1.0")
+	boltConnectionDescriptor := cref.NewDescriptor("pip-services", "connection", "bolt", "*", "1.0")
+
+	c.Register(boltQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(boltConnectionDescriptor, NewBoltConnection)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *BoltMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewBoltMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
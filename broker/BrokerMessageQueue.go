@@ -0,0 +1,496 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+BrokerMessageQueue message queue client that works with a queue hosted by BrokerServer.
+It allows several processes to share memory queues without installing a real message broker.
+
+Requests are multiplexed over a single TCP connection, so the queue can be used
+from multiple goroutines. When the connection is lost, pending requests fail
+and the connection is restored on the next request.
+Messages received by the queue stay locked on the server until they are completed
+or abandoned, or until the connection is closed.
+
+Configuration parameters:
+
+  - name:                        name of the message queue on the broker
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - host:                      host name or IP address of the broker
+    - port:                      port number (default: 7070)
+    - uri:                       resource URI or connection string with all parameters in it
+  - options:
+    - timeout:                   timeout in milliseconds of broker requests (default: 30000)
+    - lock_timeout:              timeout in milliseconds to lock received messages, 0 to use the server lock timeout (default: 0)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+
+See MessageQueue
+See BrokerServer
+
+Example:
+
+    queue := NewBrokerMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 7070,
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type BrokerMessageQueue struct {
+	queues.MessageQueue
+	address     string
+	timeout     time.Duration
+	lockTimeout time.Duration
+	conn        net.Conn
+	encoder     *json.Encoder
+	writeLock   sync.Mutex
+	pending     map[int64]chan *brokerResponse
+	sequence    int64
+	opened      int32
+	cancel      int32
+}
+
+// NewBrokerMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *BrokerMessageQueue
+// See MessagingCapabilities
+func NewBrokerMessageQueue(name string) *BrokerMessageQueue {
+	c := BrokerMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, false, true),
+	)
+
+	c.timeout = 30000 * time.Millisecond
+	c.pending = map[int64]chan *brokerResponse{}
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *BrokerMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *BrokerMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *BrokerMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	address, err := c.composeAddress(correlationId, connections[0])
+	if err != nil {
+		return err
+	}
+	c.address = address
+
+	c.Lock.Lock()
+	_, err = c.connect(correlationId)
+	c.Lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), address)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Messages that were received and not completed return into the queue on the broker.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BrokerMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+	atomic.StoreInt32(&c.opened, 0)
+
+	c.Lock.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.Lock.Unlock()
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are clears component state.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BrokerMessageQueue) Clear(correlationId string) error {
+	_, err := c.invoke(correlationId, &brokerRequest{Operation: operationClear}, 0)
+	return err
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *BrokerMessageQueue) ReadMessageCount() (int64, error) {
+	response, err := c.invoke("", &brokerRequest{Operation: operationCount}, 0)
+	if err != nil {
+		return 0, err
+	}
+	return response.Count, nil
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *BrokerMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	envelope.SentTime = time.Now()
+
+	_, err := c.invoke(correlationId, &brokerRequest{Operation: operationSend, Envelope: envelope}, 0)
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *BrokerMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	response, err := c.invoke(correlationId, &brokerRequest{Operation: operationPeek}, 0)
+	if err != nil || response.Envelope == nil {
+		return nil, err
+	}
+
+	message := response.Envelope
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *BrokerMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	response, err := c.invoke(correlationId, &brokerRequest{Operation: operationPeekBatch, Count: messageCount}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := response.Envelopes
+	if messages == nil {
+		messages = []*queues.MessageEnvelope{}
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *BrokerMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	request := &brokerRequest{
+		Operation:   operationReceive,
+		Timeout:     waitTimeout.Milliseconds(),
+		LockTimeout: c.lockTimeout.Milliseconds(),
+	}
+	response, err := c.invoke(correlationId, request, waitTimeout)
+	if err != nil || response.Envelope == nil {
+		return nil, err
+	}
+
+	message := response.Envelope
+	message.SetReference(response.Token)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+	c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// This method is usually used to extend the message processing time.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *BrokerMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	token, ok := message.GetReference().(string)
+	if !ok || token == "" {
+		return nil
+	}
+
+	request := &brokerRequest{Operation: operationRenewLock, Token: token, Timeout: lockTimeout.Milliseconds()}
+	_, err := c.invoke(message.CorrelationId, request, 0)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *BrokerMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	err := c.release(message, operationComplete)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *BrokerMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	err := c.release(message, operationAbandon)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *BrokerMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	err := c.release(message, operationDead)
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *BrokerMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *BrokerMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// release sends the operation that releases the lock of a received message.
+func (c *BrokerMessageQueue) release(message *queues.MessageEnvelope, operation string) error {
+	token, ok := message.GetReference().(string)
+	if !ok || token == "" {
+		return nil
+	}
+
+	_, err := c.invoke(message.CorrelationId, &brokerRequest{Operation: operation, Token: token}, 0)
+	if err != nil {
+		return err
+	}
+	message.SetReference(nil)
+
+	return nil
+}
+
+// invoke sends a request to the broker and waits for its response.
+// The wait time is added to the request timeout for requests that block on the broker.
+func (c *BrokerMessageQueue) invoke(correlationId string, request *brokerRequest,
+	wait time.Duration) (*brokerResponse, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(chan *brokerResponse, 1)
+
+	c.Lock.Lock()
+	encoder, err := c.connect(correlationId)
+	if err != nil {
+		c.Lock.Unlock()
+		return nil, err
+	}
+	c.sequence++
+	request.Id = c.sequence
+	request.Queue = c.Name()
+	request.CorrelationId = correlationId
+	c.pending[request.Id] = result
+	c.Lock.Unlock()
+
+	c.writeLock.Lock()
+	err = encoder.Encode(request)
+	c.writeLock.Unlock()
+
+	if err == nil {
+		timer := time.NewTimer(c.timeout + wait)
+		defer timer.Stop()
+
+		select {
+		case response := <-result:
+			if response == nil {
+				err = fmt.Errorf("connection to broker was lost")
+			} else if response.Error != nil {
+				return nil, cerr.ApplicationErrorFactory.Create(response.Error)
+			} else {
+				return response, nil
+			}
+		case <-timer.C:
+			err = fmt.Errorf("request timed out")
+		}
+	}
+
+	c.Lock.Lock()
+	delete(c.pending, request.Id)
+	c.Lock.Unlock()
+
+	return nil, cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute broker operation").
+		WithCause(err)
+}
+
+// connect establishes a connection to the broker if it is not connected.
+// It must be called under the queue lock.
+func (c *BrokerMessageQueue) connect(correlationId string) (*json.Encoder, error) {
+	if c.conn != nil {
+		return c.encoder, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to broker at "+c.address).
+			WithCause(err)
+	}
+
+	c.conn = conn
+	c.encoder = json.NewEncoder(conn)
+	go c.read(conn)
+
+	return c.encoder, nil
+}
+
+// read dispatches responses to waiting requests until the connection is closed.
+func (c *BrokerMessageQueue) read(conn net.Conn) {
+	decoder := json.NewDecoder(conn)
+	for {
+		response := &brokerResponse{}
+		if err := decoder.Decode(response); err != nil {
+			break
+		}
+
+		c.Lock.Lock()
+		result, ok := c.pending[response.Id]
+		delete(c.pending, response.Id)
+		c.Lock.Unlock()
+
+		if ok {
+			result <- response
+		}
+	}
+
+	conn.Close()
+
+	// Fail pending requests of the lost connection
+	c.Lock.Lock()
+	if c.conn == conn {
+		c.conn = nil
+	}
+	for id, result := range c.pending {
+		delete(c.pending, id)
+		close(result)
+	}
+	c.Lock.Unlock()
+}
+
+// composeAddress composes broker address from connection parameters.
+func (c *BrokerMessageQueue) composeAddress(correlationId string, connection *cconn.ConnectionParams) (string, error) {
+	host := connection.Host()
+	port := connection.PortWithDefault(7070)
+
+	if uri := connection.Uri(); uri != "" {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Hostname() == "" {
+			return "", cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid broker connection uri").
+				WithCause(err)
+		}
+		host = parsed.Hostname()
+		if parsed.Port() != "" {
+			port, _ = strconv.Atoi(parsed.Port())
+		}
+	}
+
+	if host == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
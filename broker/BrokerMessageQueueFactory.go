@@ -0,0 +1,56 @@
+package broker
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// BrokerMessageQueueFactory are creates BrokerMessageQueue and BrokerServer components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See BrokerMessageQueue
+// See BrokerServer
+type BrokerMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewBrokerMessageQueueFactory method are create a new instance of the factory.
+func NewBrokerMessageQueueFactory() *BrokerMessageQueueFactory {
+	c := BrokerMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	brokerQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "broker", "*", "1.0")
+	brokerServerDescriptor := cref.NewDescriptor("pip-services", "message-broker", "memory", "*", "1.0")
+
+	c.Register(brokerQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(brokerServerDescriptor, NewBrokerServer)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *BrokerMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewBrokerMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
@@ -0,0 +1,47 @@
+package broker
+
+import (
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// Operations of the broker protocol.
+//
+// The protocol is a sequence of JSON objects separated by new lines in both directions.
+// Every request carries an id that is returned in its response, so a client can
+// send multiple requests over one connection without waiting for previous responses.
+const (
+	operationSend      = "send"
+	operationReceive   = "receive"
+	operationPeek      = "peek"
+	operationPeekBatch = "peek_batch"
+	operationCount     = "count"
+	operationRenewLock = "renew_lock"
+	operationComplete  = "complete"
+	operationAbandon   = "abandon"
+	operationDead      = "dead"
+	operationClear     = "clear"
+)
+
+// brokerRequest is a request sent by a client to the broker.
+type brokerRequest struct {
+	Id            int64                   `json:"id"`
+	Operation     string                  `json:"op"`
+	Queue         string                  `json:"queue"`
+	CorrelationId string                  `json:"correlation_id,omitempty"`
+	Envelope      *queues.MessageEnvelope `json:"envelope,omitempty"`
+	Token         string                  `json:"token,omitempty"`
+	Timeout       int64                   `json:"timeout,omitempty"`
+	LockTimeout   int64                   `json:"lock_timeout,omitempty"`
+	Count         int64                   `json:"count,omitempty"`
+}
+
+// brokerResponse is a response sent by the broker to a client.
+type brokerResponse struct {
+	Id        int64                     `json:"id"`
+	Envelope  *queues.MessageEnvelope   `json:"envelope,omitempty"`
+	Envelopes []*queues.MessageEnvelope `json:"envelopes,omitempty"`
+	Token     string                    `json:"token,omitempty"`
+	Count     int64                     `json:"count,omitempty"`
+	Error     *cerr.ErrorDescription    `json:"error,omitempty"`
+}
@@ -0,0 +1,411 @@
+package broker
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+BrokerServer standalone message broker that hosts named MemoryMessageQueue instances
+and exposes them over TCP to BrokerMessageQueue clients in other processes.
+
+Queues are created on the first request to them. Messages received by a client
+are locked with tokens that belong to the client connection:
+when the connection is closed all its locked messages are abandoned and delivered again.
+Messages are locked for the lock timeout requested by the client or for the server lock timeout,
+the time a client waits for messages does not affect their locks.
+
+Configuration parameters:
+
+  - connection(s):
+    - host:                      host name or IP address to listen on (default: 0.0.0.0)
+    - port:                      port number to listen on, 0 to pick a free port (default: 7070)
+  - options:
+    - lock_timeout:              timeout in milliseconds to lock received messages when clients do not set it (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+
+See BrokerMessageQueue
+See MemoryMessageQueue
+
+Example:
+
+    server := NewBrokerServer()
+    server.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.port", 7070,
+    ))
+    server.Open("123")
+    ...
+    server.Close("123")
+*/
+type BrokerServer struct {
+	Logger             *clog.CompositeLogger
+	Counters           *ccount.CompositeCounters
+	ConnectionResolver *cconn.ConnectionResolver
+	references         cref.IReferences
+	lockTimeout        time.Duration
+	listener           net.Listener
+	queues             map[string]*queues.MemoryMessageQueue
+	connections        map[*brokerConnection]bool
+	wait               sync.WaitGroup
+	lock               sync.Mutex
+}
+
+// brokerConnection is a client connection served by the broker.
+type brokerConnection struct {
+	server     *BrokerServer
+	conn       net.Conn
+	encoder    *json.Encoder
+	writeLock  sync.Mutex
+	locks      map[string]*brokerLock
+	lock       sync.Mutex
+	closed     bool
+	operations sync.WaitGroup
+}
+
+// brokerLock is a message received by a client and locked with a token.
+type brokerLock struct {
+	queue   *queues.MemoryMessageQueue
+	message *queues.MessageEnvelope
+}
+
+// NewBrokerServer method are creates a new instance of the broker server.
+func NewBrokerServer() *BrokerServer {
+	c := BrokerServer{
+		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		lockTimeout:        30000 * time.Millisecond,
+		queues:             map[string]*queues.MemoryMessageQueue{},
+		connections:        map[*brokerConnection]bool{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *BrokerServer) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+// The references are also passed to created queues.
+//   - references 	references to locate the component dependencies.
+func (c *BrokerServer) SetReferences(references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *BrokerServer) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.listener != nil
+}
+
+// Open method are starts listening for client connections.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BrokerServer) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	connection, err := c.ConnectionResolver.Resolve(correlationId)
+	if err != nil {
+		return err
+	}
+
+	host := "0.0.0.0"
+	port := 7070
+	if connection != nil {
+		if connection.Host() != "" {
+			host = connection.Host()
+		}
+		port = connection.PortWithDefault(port)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to listen on "+host+":"+strconv.Itoa(port)).
+			WithCause(err)
+	}
+
+	c.lock.Lock()
+	c.listener = listener
+	c.lock.Unlock()
+
+	c.wait.Add(1)
+	go c.accept(listener)
+
+	c.Logger.Info(correlationId, "Opened message broker at %s", listener.Addr().String())
+
+	return nil
+}
+
+// Close method are stops the server, closes client connections and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *BrokerServer) Close(correlationId string) error {
+	c.lock.Lock()
+	listener := c.listener
+	c.listener = nil
+	connections := []*brokerConnection{}
+	for connection := range c.connections {
+		connections = append(connections, connection)
+	}
+	c.lock.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	listener.Close()
+	for _, connection := range connections {
+		connection.conn.Close()
+	}
+	c.wait.Wait()
+
+	c.Logger.Info(correlationId, "Closed message broker")
+
+	return nil
+}
+
+// GetAddress method are gets the address the server listens on.
+// Returns: the host:port address or empty string if the server is not opened.
+func (c *BrokerServer) GetAddress() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.listener == nil {
+		return ""
+	}
+	return c.listener.Addr().String()
+}
+
+// GetQueue method are gets a hosted queue by its name and creates it if it does not exist.
+//   - name    a name of the queue.
+// Returns: the memory message queue.
+func (c *BrokerServer) GetQueue(name string) *queues.MemoryMessageQueue {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	queue, ok := c.queues[name]
+	if !ok {
+		queue = queues.NewMemoryMessageQueue(name)
+		if c.references != nil {
+			queue.SetReferences(c.references)
+		}
+		queue.Open("")
+		c.queues[name] = queue
+	}
+	return queue
+}
+
+// GetQueueNames method are gets names of the hosted queues.
+// Returns: a sorted list with queue names.
+func (c *BrokerServer) GetQueueNames() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *BrokerServer) accept(listener net.Listener) {
+	defer c.wait.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		connection := &brokerConnection{
+			server:  c,
+			conn:    conn,
+			encoder: json.NewEncoder(conn),
+			locks:   map[string]*brokerLock{},
+		}
+
+		c.lock.Lock()
+		if c.listener == nil {
+			c.lock.Unlock()
+			conn.Close()
+			return
+		}
+		c.connections[connection] = true
+		c.lock.Unlock()
+
+		c.Counters.IncrementOne("broker.connections")
+		c.wait.Add(1)
+		go connection.serve()
+	}
+}
+
+// serve reads requests from the client until the connection is closed.
+// Every request is processed in its own goroutine, because receive requests block.
+func (c *brokerConnection) serve() {
+	defer c.server.wait.Done()
+
+	decoder := json.NewDecoder(c.conn)
+	for {
+		request := &brokerRequest{}
+		if err := decoder.Decode(request); err != nil {
+			break
+		}
+
+		c.operations.Add(1)
+		go func() {
+			defer c.operations.Done()
+			c.write(c.handle(request))
+		}()
+	}
+
+	c.conn.Close()
+	c.operations.Wait()
+
+	// Return messages locked by the client into their queues
+	c.lock.Lock()
+	c.closed = true
+	locks := c.locks
+	c.locks = map[string]*brokerLock{}
+	c.lock.Unlock()
+
+	for _, lock := range locks {
+		lock.queue.Abandon(lock.message)
+	}
+
+	c.server.lock.Lock()
+	delete(c.server.connections, c)
+	c.server.lock.Unlock()
+}
+
+func (c *brokerConnection) write(response *brokerResponse) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.encoder.Encode(response)
+}
+
+// handle executes a request against the requested queue.
+func (c *brokerConnection) handle(request *brokerRequest) *brokerResponse {
+	response := &brokerResponse{Id: request.Id}
+
+	if request.Queue == "" {
+		response.Error = cerr.ErrorDescriptionFactory.Create(
+			cerr.NewBadRequestError(request.CorrelationId, "NO_QUEUE", "Queue name is not set"))
+		return response
+	}
+	queue := c.server.GetQueue(request.Queue)
+
+	var err error
+	switch request.Operation {
+	case operationSend:
+		if request.Envelope == nil {
+			err = cerr.NewBadRequestError(request.CorrelationId, "NO_MESSAGE", "Message is not set")
+			break
+		}
+		err = queue.Send(request.CorrelationId, request.Envelope)
+	case operationReceive:
+		lockTimeout := time.Duration(request.LockTimeout) * time.Millisecond
+		if lockTimeout <= 0 {
+			lockTimeout = c.server.lockTimeout
+		}
+		var messages []*queues.MessageEnvelope
+		messages, err = queue.ReceiveBatchWithLock(request.CorrelationId, 1,
+			time.Duration(request.Timeout)*time.Millisecond, lockTimeout)
+		if len(messages) > 0 {
+			response.Envelope = messages[0]
+			response.Token = c.lockMessage(queue, messages[0])
+		}
+	case operationPeek:
+		response.Envelope, err = queue.Peek(request.CorrelationId)
+	case operationPeekBatch:
+		response.Envelopes, err = queue.PeekBatch(request.CorrelationId, request.Count)
+	case operationCount:
+		response.Count, err = queue.ReadMessageCount()
+	case operationRenewLock:
+		if lock := c.findLock(request.Token, false); lock != nil {
+			err = queue.RenewLock(lock.message, time.Duration(request.Timeout)*time.Millisecond)
+		}
+	case operationComplete:
+		if lock := c.findLock(request.Token, true); lock != nil {
+			err = queue.Complete(lock.message)
+		}
+	case operationAbandon:
+		if lock := c.findLock(request.Token, true); lock != nil {
+			err = queue.Abandon(lock.message)
+		}
+	case operationDead:
+		if lock := c.findLock(request.Token, true); lock != nil {
+			err = queue.MoveToDeadLetter(lock.message)
+		}
+	case operationClear:
+		err = queue.Clear(request.CorrelationId)
+	default:
+		err = cerr.NewBadRequestError(request.CorrelationId, "UNKNOWN_OPERATION", "Unknown operation "+request.Operation).
+			WithDetails("operation", request.Operation)
+	}
+
+	if err != nil {
+		response.Error = cerr.ErrorDescriptionFactory.Create(err)
+	}
+	return response
+}
+
+// lockMessage keeps a received message until the client completes or abandons it.
+// If the connection is already closed the message is abandoned immediately.
+func (c *brokerConnection) lockMessage(queue *queues.MemoryMessageQueue, message *queues.MessageEnvelope) string {
+	token := cdata.IdGenerator.NextLong()
+
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		queue.Abandon(message)
+		return ""
+	}
+	c.locks[token] = &brokerLock{queue: queue, message: message}
+	c.lock.Unlock()
+
+	return token
+}
+
+// findLock finds a locked message by its token and optionally releases it.
+func (c *brokerConnection) findLock(token string, release bool) *brokerLock {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	lock, ok := c.locks[token]
+	if ok && release {
+		delete(c.locks, token)
+	}
+	return lock
+}
@@ -3,25 +3,36 @@ package build
 import (
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
 	cbuild "github.com/pip-services3-go/pip-services3-components-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
 	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
 )
 
-// DefaultMessagingFactory Creates MemoryMessageQueue components by their descriptors.
+// DefaultMessagingFactory Creates MemoryMessageQueue and FileMessageQueue components by their descriptors.
 // Name of created message queue is taken from its descriptor.
+// Memory queues are created by MemoryMessageQueueFactory, so named memory queues are shared
+// through its connection, which is also created by the factory.
 //
 // See Factory
 // See MemoryMessageQueue
+// See MemoryMessageQueueFactory
+// See FileMessageQueue
 type DefaultMessagingFactory struct {
 	cbuild.Factory
+	memoryFactory *MemoryMessageQueueFactory
 }
 
 // NewDefaultMessagingFactory are create a new instance of the factory.
 func NewDefaultMessagingFactory() *DefaultMessagingFactory {
 	c := DefaultMessagingFactory{}
 	c.Factory = *cbuild.NewFactory()
+	c.memoryFactory = NewMemoryMessageQueueFactory()
 
 	memoryQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "memory", "*", "1.0")
 	memoryQueueFactoryDescriptor := cref.NewDescriptor("pip-services", "queue-factory", "memory", "*", "1.0")
+	memoryConnectionDescriptor := cref.NewDescriptor("pip-services", "queue-connection", "memory", "*", "1.0")
+	memoryAdminDescriptor := cref.NewDescriptor("pip-services", "queue-admin", "memory", "*", "1.0")
+	fileQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "file", "*", "1.0")
+	fileQueueFactoryDescriptor := cref.NewDescriptor("pip-services", "queue-factory", "file", "*", "1.0")
 
 	c.Register(memoryQueueDescriptor, func(locator interface{}) interface{} {
 		name := ""
@@ -30,9 +41,28 @@ func NewDefaultMessagingFactory() *DefaultMessagingFactory {
 			name = descriptor.Name()
 		}
 
-		return queues.NewMemoryMessageQueue(name)
+		return c.memoryFactory.CreateQueue(name)
 	})
-	c.RegisterType(memoryQueueFactoryDescriptor, NewMemoryMessageQueueFactory)
+	c.Register(memoryQueueFactoryDescriptor, func(locator interface{}) interface{} {
+		return c.memoryFactory
+	})
+	c.Register(memoryConnectionDescriptor, func(locator interface{}) interface{} {
+		return c.memoryFactory.Connection
+	})
+	c.Register(memoryAdminDescriptor, func(locator interface{}) interface{} {
+		return connect.NewMemoryMessageQueueAdmin(c.memoryFactory.Connection)
+	})
+
+	c.Register(fileQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+
+		return queues.NewFileMessageQueue(name)
+	})
+	c.RegisterType(fileQueueFactoryDescriptor, NewFileMessageQueueFactory)
 
 	return &c
 }
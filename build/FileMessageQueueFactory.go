@@ -0,0 +1,52 @@
+package build
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// FileMessageQueueFactory are creates FileMessageQueue components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See FileMessageQueue
+type FileMessageQueueFactory struct {
+	MessageQueueFactory
+}
+
+// NewFileMessageQueueFactory method are create a new instance of the factory.
+func NewFileMessageQueueFactory() *FileMessageQueueFactory {
+	c := FileMessageQueueFactory{
+		MessageQueueFactory: *InheritMessageQueueFactory(),
+	}
+
+	fileQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "file", "*", "1.0")
+
+	c.Register(fileQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *FileMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := queues.NewFileMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
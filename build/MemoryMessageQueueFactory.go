@@ -1,26 +1,38 @@
 package build
 
 import (
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
 	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
 )
 
 // MemoryMessageQueueFactory are creates MemoryMemoryMessageQueue components by their descriptors.
 // Name of created message queue is taken from its descriptor.
+// Named queues are taken from the factory connection, so components
+// that get queues with the same name from the factory share them.
+// Every component gets its own SharedMemoryMessageQueue handle, so closing one component
+// does not stop other components that share the queue.
 //
 // See Factory
 // See MemoryMemoryMessageQueue
+// See MemoryMessageQueueConnection
+// See SharedMemoryMessageQueue
 type MemoryMessageQueueFactory struct {
 	MessageQueueFactory
+	Connection *connect.MemoryMessageQueueConnection
 }
 
 // NewMemoryMessageQueueFactory method are create a new instance of the factory.
 func NewMemoryMessageQueueFactory() *MemoryMessageQueueFactory {
 	c := MemoryMessageQueueFactory{
 		MessageQueueFactory: *InheritMessageQueueFactory(),
+		Connection:          connect.NewMemoryMessageQueueConnection(),
 	}
 
 	memoryQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "memory", "*", "1.0")
+	memoryConnectionDescriptor := cref.NewDescriptor("pip-services", "queue-connection", "memory", "*", "1.0")
+	memoryAdminDescriptor := cref.NewDescriptor("pip-services", "queue-admin", "memory", "*", "1.0")
 
 	c.Register(memoryQueueDescriptor, func(locator interface{}) interface{} {
 		name := ""
@@ -30,15 +42,41 @@ func NewMemoryMessageQueueFactory() *MemoryMessageQueueFactory {
 		}
 		return c.CreateQueue(name)
 	})
+	c.Register(memoryConnectionDescriptor, func(locator interface{}) interface{} {
+		return c.Connection
+	})
+	c.Register(memoryAdminDescriptor, func(locator interface{}) interface{} {
+		return connect.NewMemoryMessageQueueAdmin(c.Connection)
+	})
 
 	return &c
 }
 
+// Configure method are configures the factory and its connection.
+//   - config    configuration parameters to be set.
+func (c *MemoryMessageQueueFactory) Configure(config *cconf.ConfigParams) {
+	c.MessageQueueFactory.Configure(config)
+	c.Connection.Configure(config)
+}
+
+// SetReferences method are sets references to the factory and its connection.
+//   - references 	references to locate the component dependencies.
+func (c *MemoryMessageQueueFactory) SetReferences(references cref.IReferences) {
+	c.MessageQueueFactory.SetReferences(references)
+	c.Connection.SetReferences(references)
+}
+
 // Creates a message queue component and assigns its name.
+// Named queues are shared through the factory connection and returned as separate handles,
+// queues without a name are created as separate instances.
 //
 // Parameters:
 //   - name: a name of the created message queue.
 func (c *MemoryMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	if name != "" && name != "*" {
+		return connect.NewSharedMemoryMessageQueue(c.Connection.GetQueue(name))
+	}
+
 	queue := queues.NewMemoryMessageQueue(name)
 
 	if c.Config != nil {
@@ -0,0 +1,50 @@
+// Command pipbroker runs a standalone message broker that hosts memory message queues
+// and serves them over TCP to BrokerMessageQueue clients.
+//
+// Usage:
+//
+//	pipbroker [-host 0.0.0.0] [-port 7070] [-level info]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/broker"
+)
+
+func main() {
+	host := flag.String("host", "0.0.0.0", "host name or IP address to listen on")
+	port := flag.Int("port", 7070, "port number to listen on")
+	level := flag.String("level", "info", "log level: none, fatal, error, warn, info, debug or trace")
+	flag.Parse()
+
+	logger := clog.NewConsoleLogger()
+	logger.SetLevel(clog.LogLevelConverter.ToLogLevel(*level))
+
+	server := broker.NewBrokerServer()
+	server.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.host", *host,
+		"connection.port", *port,
+	))
+	server.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "logger", "console", "default", "1.0"), logger,
+	))
+
+	if err := server.Open("pipbroker"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	server.Close("pipbroker")
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// headerValues collects repeated "-header name=value" options.
+type headerValues map[string]string
+
+func (c headerValues) String() string {
+	pairs := []string{}
+	for name, value := range c {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (c headerValues) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return cerr.NewBadRequestError(correlationId, "WRONG_HEADER", "Header must be set as name=value")
+	}
+	c[name] = headerValue
+	return nil
+}
+
+// messageFilter defines options that select messages by MessageFilter parameters.
+type messageFilter struct {
+	messageType *string
+	minAge      *int64
+	maxAge      *int64
+	headers     headerValues
+}
+
+func newMessageFilter(flags *flag.FlagSet) *messageFilter {
+	c := &messageFilter{
+		messageType: flags.String("type", "", "message type or comma separated list of types"),
+		minAge:      flags.Int64("min-age", 0, "minimum age of messages in milliseconds"),
+		maxAge:      flags.Int64("max-age", 0, "maximum age of messages in milliseconds"),
+		headers:     headerValues{},
+	}
+	flags.Var(c.headers, "header", "header value as name=value, can be repeated")
+	return c
+}
+
+func (c *messageFilter) toFilterParams(deadLetter bool) *cdata.FilterParams {
+	filter := cdata.NewFilterParamsFromTuples(
+		"message_type", *c.messageType,
+		"dead_letter", deadLetter,
+	)
+	if *c.minAge > 0 {
+		filter.Put("min_age", *c.minAge)
+	}
+	if *c.maxAge > 0 {
+		filter.Put("max_age", *c.maxAge)
+	}
+	for name, value := range c.headers {
+		filter.Put("header."+name, value)
+	}
+	return filter
+}
+
+// sendCommand sends a message read from stdin or a file.
+func sendCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("send", flag.ContinueOnError)
+	messageType := flags.String("type", "", "message type")
+	messageId := flags.String("id", "", "message id (default: generated)")
+	correlation := flags.String("correlation-id", "", "correlation id")
+	group := flags.String("group", "", "key of the message group")
+	file := flags.String("file", "", "file with the message body (default: stdin)")
+	headers := headerValues{}
+	flags.Var(headers, "header", "header value as name=value, can be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var body []byte
+	var err error
+	if *file != "" {
+		body, err = os.ReadFile(*file)
+	} else {
+		body, err = io.ReadAll(cli.input)
+	}
+	if err != nil {
+		return err
+	}
+
+	envelope := queues.NewMessageEnvelope(*correlation, *messageType, body)
+	if *messageId != "" {
+		envelope.MessageId = *messageId
+	}
+	envelope.GroupId = *group
+	for name, value := range headers {
+		envelope.SetHeader(name, value)
+	}
+
+	err = cli.queue.Send(correlationId, envelope)
+	if err != nil {
+		return err
+	}
+
+	if cli.json {
+		return cli.printJson(map[string]string{"message_id": envelope.MessageId})
+	}
+	_, err = cli.output.Write([]byte(envelope.MessageId + "\n"))
+	return err
+}
+
+// receiveCommand receives messages and completes, abandons or moves them to dead letter.
+func receiveCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("receive", flag.ContinueOnError)
+	count := flags.Int("count", 1, "maximum number of messages to receive")
+	wait := flags.Int64("wait", 1000, "time in milliseconds to wait for each message")
+	ack := flags.String("ack", "complete", "what to do with received messages: complete, abandon or dead")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *ack != "complete" && *ack != "abandon" && *ack != "dead" {
+		return cerr.NewBadRequestError(correlationId, "WRONG_ACK", "Unknown acknowledgement "+*ack)
+	}
+
+	for i := 0; i < *count; i++ {
+		message, err := cli.queue.Receive(correlationId, time.Duration(*wait)*time.Millisecond)
+		if err != nil {
+			return err
+		}
+		if message == nil {
+			break
+		}
+
+		err = cli.printMessage(message)
+		if err != nil {
+			cli.queue.Abandon(message)
+			return err
+		}
+
+		switch *ack {
+		case "abandon":
+			err = cli.queue.Abandon(message)
+		case "dead":
+			err = cli.queue.MoveToDeadLetter(message)
+		default:
+			err = cli.queue.Complete(message)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// peekCommand peeks messages without removing them.
+func peekCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("peek", flag.ContinueOnError)
+	count := flags.Int64("count", 1, "maximum number of messages to peek")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	messages, err := cli.queue.PeekBatch(correlationId, *count)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if err := cli.printMessage(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countCommand prints the number of messages in the queue.
+func countCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("count", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	count, err := cli.queue.ReadMessageCount()
+	if err != nil {
+		return err
+	}
+	return cli.printResult("count", count)
+}
+
+// purgeCommand removes messages from the queue.
+func purgeCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ContinueOnError)
+	filter := newMessageFilter(flags)
+	deadLetter := flags.Bool("dead-letter", false, "purge the dead letter queue")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inspector, err := cli.inspector("purge")
+	if err != nil {
+		return err
+	}
+
+	messages, err := inspector.RemoveMessages(correlationId, filter.toFilterParams(*deadLetter))
+	if err != nil {
+		return err
+	}
+	return cli.printResult("purged", int64(len(messages)))
+}
+
+// deadLetterCommand browses messages in the dead letter queue.
+func deadLetterCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("dead-letter", flag.ContinueOnError)
+	filter := newMessageFilter(flags)
+	skip := flags.Int64("skip", 0, "number of messages to skip")
+	take := flags.Int64("take", 100, "maximum number of messages to print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inspector, err := cli.inspector("dead-letter")
+	if err != nil {
+		return err
+	}
+
+	page, err := inspector.BrowseMessages(correlationId, filter.toFilterParams(true), cdata.NewPagingParams(*skip, *take, false))
+	if err != nil {
+		return err
+	}
+	for _, message := range page.Data {
+		if err := cli.printMessage(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redriveCommand moves messages from the dead letter queue back into the queue.
+func redriveCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("redrive", flag.ContinueOnError)
+	filter := newMessageFilter(flags)
+	rate := flags.Float64("rate", 0, "maximum number of messages per second, 0 for no limit")
+	max := flags.Int64("max", 0, "maximum number of messages to redrive, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if _, err := cli.inspector("redrive"); err != nil {
+		return err
+	}
+
+	redriver := queues.NewDeadLetterRedriver()
+	redriver.Configure(cconf.NewConfigParamsFromTuples(
+		"options.messages_per_second", *rate,
+		"options.max_messages", *max,
+	))
+
+	result, err := redriver.Redrive(correlationId, cli.queue, cli.queue, filter.toFilterParams(true))
+	if err != nil {
+		return err
+	}
+	return cli.printResult("redriven", result.Redriven)
+}
+
+// tailCommand prints new messages as they arrive without removing them until it is interrupted.
+func tailCommand(cli *pipq, args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	interval := flags.Int64("interval", 1000, "polling interval in milliseconds")
+	all := flags.Bool("all", false, "print messages that are already in the queue")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var seen map[string]bool
+	for {
+		messages, err := cli.queue.PeekBatch(correlationId, 1000)
+		if err != nil {
+			return err
+		}
+
+		// Only ids of messages that are still in the queue are remembered
+		current := map[string]bool{}
+		for _, message := range messages {
+			current[message.MessageId] = true
+			if seen == nil && !*all || seen[message.MessageId] {
+				continue
+			}
+			if err := cli.printMessage(message); err != nil {
+				return err
+			}
+		}
+		seen = current
+
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(time.Duration(*interval) * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Command pipq works with message queues defined in a pip-services configuration file.
+// Queues are created from components with "pip-services:message-queue:<kind>:<name>:1.0" descriptors
+// by DefaultMessagingFactory and factories of all other backends in the module.
+// Purge, dead-letter and redrive commands work only with queues that can be inspected,
+// like memory and file queues.
+//
+// Usage:
+//
+//	pipq [-config config.yml] [-queue name] [-json] <command> [options]
+//
+// Commands:
+//
+//	send          sends a message read from stdin or a file
+//	receive       receives messages and acknowledges them
+//	peek          peeks messages without removing them
+//	count         prints the number of messages in the queue
+//	purge         removes all messages from the queue
+//	dead-letter   browses messages in the dead letter queue
+//	redrive       moves messages from the dead letter queue back into the queue
+//	tail          prints new messages as they arrive without removing them
+//
+// With -json flag messages and results are printed as JSON objects, one per line.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usage = `Usage: pipq [-config config.yml] [-queue name] [-json] <command> [options]
+
+Commands:
+  send          sends a message read from stdin or a file
+  receive       receives messages and acknowledges them
+  peek          peeks messages without removing them
+  count         prints the number of messages in the queue
+  purge         removes all messages from the queue
+  dead-letter   browses messages in the dead letter queue
+  redrive       moves messages from the dead letter queue back into the queue
+  tail          prints new messages as they arrive without removing them
+
+Run "pipq <command> -h" to see options of a command.
+
+Global options:
+`
+
+// commands maps command names to their implementations.
+var commands = map[string]func(cli *pipq, args []string) error{
+	"send":        sendCommand,
+	"receive":     receiveCommand,
+	"peek":        peekCommand,
+	"count":       countCommand,
+	"purge":       purgeCommand,
+	"dead-letter": deadLetterCommand,
+	"redrive":     redriveCommand,
+	"tail":        tailCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run parses arguments and executes the command.
+// Returns: exit code, 2 for wrong arguments and 1 for failed commands.
+func run(args []string, input io.Reader, output io.Writer, errors io.Writer) int {
+	flags := flag.NewFlagSet("pipq", flag.ContinueOnError)
+	flags.SetOutput(errors)
+	configPath := flags.String("config", defaultConfigPath(), "configuration file with message queue components (.yml, .yaml or .json)")
+	queueName := flags.String("queue", "", "name of the queue, required when the configuration has several queues")
+	jsonOutput := flags.Bool("json", false, "print results as JSON objects, one per line")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	command, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(errors, "Unknown command %s\n\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	cli := newPipq(*jsonOutput, input, output)
+	err := cli.open(*configPath, *queueName)
+	if err == nil {
+		err = command(cli, flags.Args()[1:])
+		cli.close()
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(errors, strings.TrimSpace(err.Error()))
+		return 1
+	}
+	return 0
+}
+
+// defaultConfigPath gets the configuration path from PIPQ_CONFIG environment variable.
+func defaultConfigPath() string {
+	if path := os.Getenv("PIPQ_CONFIG"); path != "" {
+		return path
+	}
+	return "./config/config.yml"
+}
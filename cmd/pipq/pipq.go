@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	crun "github.com/pip-services3-go/pip-services3-commons-go/run"
+	cbuild "github.com/pip-services3-go/pip-services3-components-go/build"
+	cconfig "github.com/pip-services3-go/pip-services3-components-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/amqp"
+	"github.com/pip-services3-go/pip-services3-messaging-go/boltdb"
+	"github.com/pip-services3-go/pip-services3-messaging-go/broker"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/grpc"
+	"github.com/pip-services3-go/pip-services3-messaging-go/kafka"
+	"github.com/pip-services3-go/pip-services3-messaging-go/mqtt"
+	"github.com/pip-services3-go/pip-services3-messaging-go/nats"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/redis"
+	"github.com/pip-services3-go/pip-services3-messaging-go/sqldb"
+	"github.com/pip-services3-go/pip-services3-messaging-go/stomp"
+)
+
+// correlationId is used in all calls made by the tool
+const correlationId = "pipq"
+
+// pipq holds components created from the configuration and writes results.
+type pipq struct {
+	queue      queues.IMessageQueue
+	components []interface{}
+	json       bool
+	input      io.Reader
+	output     io.Writer
+}
+
+func newPipq(jsonOutput bool, input io.Reader, output io.Writer) *pipq {
+	return &pipq{
+		json:   jsonOutput,
+		input:  input,
+		output: output,
+	}
+}
+
+// newFactory creates a factory of message queues and their connections.
+// Besides queues of DefaultMessagingFactory it creates queues of all backends in the module.
+func newFactory() cbuild.IFactory {
+	return cbuild.NewCompositeFactoryFromFactories(
+		build.NewDefaultMessagingFactory(),
+		amqp.NewAmqpMessageQueueFactory(),
+		boltdb.NewBoltMessageQueueFactory(),
+		broker.NewBrokerMessageQueueFactory(),
+		grpc.NewGrpcMessageQueueFactory(),
+		kafka.NewKafkaMessageQueueFactory(),
+		mqtt.NewMqttMessageQueueFactory(),
+		nats.NewNatsMessageQueueFactory(),
+		redis.NewRedisMessageQueueFactory(),
+		sqldb.NewSqlMessageQueueFactory(),
+		stomp.NewStompMessageQueueFactory(),
+	)
+}
+
+// open reads the configuration file, creates queues and connections defined there
+// and opens the selected queue together with the connections.
+func (c *pipq) open(configPath string, queueName string) error {
+	var config *cconf.ConfigParams
+	var err error
+	if strings.ToLower(filepath.Ext(configPath)) == ".json" {
+		config, err = cconfig.ReadJsonConfig(correlationId, configPath, nil)
+	} else {
+		config, err = cconfig.ReadYamlConfig(correlationId, configPath, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Components are listed in the configuration as sections with descriptors.
+	// Other components like loggers or servers are skipped.
+	factory := newFactory()
+	references := cref.NewEmptyReferences()
+	queueComponents := map[string]queues.IMessageQueue{}
+	names := []string{}
+	for _, section := range config.GetSectionNames() {
+		componentConfig := config.GetSection(section)
+		descriptor, err := cref.ParseDescriptorFromString(componentConfig.GetAsString("descriptor"))
+		if err != nil || descriptor == nil {
+			continue
+		}
+		if descriptor.Type() != "message-queue" && descriptor.Type() != "connection" {
+			continue
+		}
+
+		component, err := factory.Create(descriptor)
+		if err != nil {
+			return err
+		}
+		if configurable, ok := component.(cconf.IConfigurable); ok {
+			configurable.Configure(componentConfig)
+		}
+		references.Put(descriptor, component)
+
+		if queue, ok := component.(queues.IMessageQueue); ok {
+			queueComponents[descriptor.Name()] = queue
+			names = append(names, descriptor.Name())
+		} else {
+			c.components = append(c.components, component)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_QUEUES", "Configuration "+configPath+" has no message queues")
+	}
+	if queueName == "" {
+		if len(names) > 1 {
+			return cerr.NewConfigError(correlationId, "NO_QUEUE", "Queue is not set, use -queue option to select one of "+
+				strings.Join(names, ", "))
+		}
+		queueName = names[0]
+	}
+	queue, ok := queueComponents[queueName]
+	if !ok {
+		return cerr.NewConfigError(correlationId, "QUEUE_NOT_FOUND", "Queue "+queueName+" is not found in "+configPath)
+	}
+
+	// Connections are opened before the queue that uses them
+	c.components = append(c.components, queue)
+	cref.Referencer.SetReferences(references, references.GetAll())
+	if err = crun.Opener.Open(correlationId, c.components); err != nil {
+		c.close()
+		return err
+	}
+
+	c.queue = queue
+	return nil
+}
+
+// close closes the queue and connections.
+func (c *pipq) close() {
+	crun.Closer.Close(correlationId, c.components)
+}
+
+// inspector gets the queue inspector or error when the queue does not support inspection.
+//   - command   a name of the command that needs the inspector.
+func (c *pipq) inspector(command string) (queues.IMessageQueueInspector, error) {
+	inspector, ok := c.queue.(queues.IMessageQueueInspector)
+	if !ok {
+		return nil, cerr.NewUnsupportedError(correlationId, "NOT_SUPPORTED", "Command "+command+" is not supported by queue "+
+			c.queue.Name()+": it works only with queues that can be inspected, like memory and file queues").
+			WithDetails("queue", c.queue.Name())
+	}
+	return inspector, nil
+}
+
+// printMessage writes a message as JSON object or a text line.
+func (c *pipq) printMessage(message *queues.MessageEnvelope) error {
+	if c.json {
+		return c.printJson(message)
+	}
+
+	headers := []string{}
+	for name, value := range message.Headers {
+		headers = append(headers, name+"="+value)
+	}
+	sort.Strings(headers)
+
+	line := fmt.Sprintf("%s %s %s %s", message.MessageId, orDash(message.MessageType),
+		orDash(message.CorrelationId), message.SentTime.Format("2006-01-02T15:04:05.000Z07:00"))
+	if len(headers) > 0 {
+		line += " " + strings.Join(headers, ",")
+	}
+	_, err := fmt.Fprintf(c.output, "%s\n%s\n", line, message.GetMessageAsString())
+	return err
+}
+
+// printResult writes a named numeric result as JSON object or a text line.
+func (c *pipq) printResult(name string, value int64) error {
+	if c.json {
+		return c.printJson(map[string]int64{name: value})
+	}
+	_, err := fmt.Fprintf(c.output, "%d\n", value)
+	return err
+}
+
+func (c *pipq) printJson(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.output, "%s\n", data)
+	return err
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
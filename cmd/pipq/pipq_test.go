@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfig creates a configuration with a file queue that can be inspected
+// and a bolt queue that cannot.
+func writeConfig(t *testing.T) string {
+	dir := t.TempDir()
+	config := `
+- descriptor: "pip-services:message-queue:file:orders:1.0"
+  path: "` + filepath.ToSlash(filepath.Join(dir, "orders.log")) + `"
+
+- descriptor: "pip-services:message-queue:bolt:events:1.0"
+  path: "` + filepath.ToSlash(filepath.Join(dir, "events.db")) + `"
+`
+	path := filepath.Join(dir, "config.yml")
+	assert.Nil(t, os.WriteFile(path, []byte(config), 0644))
+	return path
+}
+
+// runPipq runs the tool and returns its exit code, output and errors.
+func runPipq(t *testing.T, input string, args ...string) (int, string, string) {
+	output := &bytes.Buffer{}
+	errors := &bytes.Buffer{}
+	code := run(args, strings.NewReader(input), output, errors)
+	return code, output.String(), errors.String()
+}
+
+func TestPipqArguments(t *testing.T) {
+	config := writeConfig(t)
+
+	code, _, errors := runPipq(t, "")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errors, "Usage: pipq")
+
+	code, _, errors = runPipq(t, "", "-config", config, "unknown")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errors, "Unknown command unknown")
+
+	code, _, _ = runPipq(t, "", "-wrong")
+	assert.Equal(t, 2, code)
+
+	// The queue must be selected when the configuration has several queues
+	code, _, errors = runPipq(t, "", "-config", config, "count")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errors, "events, orders")
+
+	code, _, errors = runPipq(t, "", "-config", config, "-queue", "unknown", "count")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errors, "Queue unknown is not found")
+
+	code, _, errors = runPipq(t, "", "-config", config, "-queue", "orders", "receive", "-ack", "wrong")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errors, "Unknown acknowledgement wrong")
+}
+
+func TestPipqCommands(t *testing.T) {
+	config := writeConfig(t)
+	pipq := func(input string, args ...string) string {
+		code, output, errors := runPipq(t, input, append([]string{"-config", config, "-queue", "orders"}, args...)...)
+		assert.Equal(t, 0, code, errors)
+		return output
+	}
+
+	// Send from stdin and a file
+	id1 := strings.TrimSpace(pipq("ABC", "send", "-type", "order", "-header", "tenant=tenant1", "-header", "trace_id=abc"))
+	assert.NotEqual(t, "", id1)
+
+	file := filepath.Join(t.TempDir(), "message.txt")
+	assert.Nil(t, os.WriteFile(file, []byte("DEF"), 0644))
+	pipq("", "send", "-type", "invoice", "-id", "2", "-file", file)
+	assert.Equal(t, "2\n", pipq("", "count"))
+
+	// Peek prints messages with headers
+	output := pipq("", "peek", "-count", "5")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.True(t, strings.HasPrefix(lines[0], id1+" order "))
+		assert.True(t, strings.HasSuffix(lines[0], " tenant=tenant1,trace_id=abc"))
+		assert.Equal(t, "ABC", lines[1])
+		assert.True(t, strings.HasPrefix(lines[2], "2 invoice "))
+		assert.Equal(t, "DEF", lines[3])
+	}
+
+	// Receive as JSON and move to dead letters
+	var message map[string]interface{}
+	output = pipq("", "-json", "receive", "-ack", "dead")
+	assert.Nil(t, json.Unmarshal([]byte(output), &message))
+	assert.Equal(t, id1, message["message_id"])
+	assert.Equal(t, "order", message["message_type"])
+	assert.Equal(t, "1\n", pipq("", "count"))
+
+	output = pipq("", "dead-letter", "-header", "tenant=tenant1")
+	assert.Contains(t, output, id1+" order ")
+	output = pipq("", "dead-letter", "-type", "invoice")
+	assert.Equal(t, "", output)
+
+	// Redrive returns dead letters into the queue
+	output = pipq("", "-json", "redrive", "-type", "order")
+	assert.Nil(t, json.Unmarshal([]byte(output), &message))
+	assert.Equal(t, float64(1), message["redriven"])
+	assert.Equal(t, "2\n", pipq("", "count"))
+
+	// Receive completes received messages
+	output = pipq("", "receive", "-count", "5", "-wait", "100")
+	assert.Contains(t, output, "DEF\n")
+	assert.Contains(t, output, "ABC\n")
+	assert.Equal(t, "0\n", pipq("", "count"))
+
+	// Purge removes selected messages
+	pipq("A", "send", "-type", "order")
+	pipq("B", "send", "-type", "invoice")
+	assert.Equal(t, "1\n", pipq("", "purge", "-type", "order"))
+	assert.Equal(t, "1\n", pipq("", "count"))
+}
+
+func TestPipqNotInspectedQueue(t *testing.T) {
+	config := writeConfig(t)
+
+	for _, command := range []string{"purge", "dead-letter", "redrive"} {
+		code, _, errors := runPipq(t, "", "-config", config, "-queue", "events", command)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, errors, "Command "+command+" is not supported by queue events")
+	}
+}
@@ -0,0 +1,63 @@
+package connect
+
+import (
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// IMessageQueueAdmin Interface for administration of message queues by operators.
+// Messages are selected by filters described in MessageFilter.
+// When a filter has "dead_letter" set to true, messages are taken from the dead letter queue.
+//
+// Backends implement it on top of IMessageQueueInspector implemented by their queues.
+//
+// See MemoryMessageQueueAdmin
+// See IMessageQueueInspector
+// See MessageFilter
+type IMessageQueueAdmin interface {
+
+	// ReadQueueStats method are reads the state of all queues.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	// Returns: a list with queue statistics sorted by queue names or error.
+	ReadQueueStats(correlationId string) ([]*queues.MessageQueueStats, error)
+
+	// GetQueueStats method are reads the state of a queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - name              a name of the queue.
+	// Returns: the queue statistics or error.
+	GetQueueStats(correlationId string, name string) (*queues.MessageQueueStats, error)
+
+	// PurgeQueue method are removes messages that match the filter from a queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - name              a name of the queue.
+	//   - filter            (optional) a filter to select messages, all messages are removed when it is not set.
+	// Returns: the number of removed messages or error.
+	PurgeQueue(correlationId string, name string, filter *cdata.FilterParams) (int64, error)
+
+	// BrowseMessages method are gets a page of messages from a queue without removing them.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - name              a name of the queue.
+	//   - filter            (optional) a filter to select messages.
+	//   - paging            (optional) paging parameters.
+	// Returns: a page with messages or error.
+	BrowseMessages(correlationId string, name string, filter *cdata.FilterParams,
+		paging *cdata.PagingParams) (*queues.MessageEnvelopePage, error)
+
+	// MoveMessages method are removes messages that match the filter from the source queue
+	// and sends them to the target queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - source            a name of the source queue.
+	//   - target            a name of the target queue.
+	//   - filter            (optional) a filter to select messages.
+	// Returns: the number of moved messages or error.
+	MoveMessages(correlationId string, source string, target string, filter *cdata.FilterParams) (int64, error)
+
+	// CopyMessages method are sends copies of messages that match the filter from the source queue
+	// to the target queue. Messages in the source queue are left as they are.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - source            a name of the source queue.
+	//   - target            a name of the target queue.
+	//   - filter            (optional) a filter to select messages.
+	// Returns: the number of copied messages or error.
+	CopyMessages(correlationId string, source string, target string, filter *cdata.FilterParams) (int64, error)
+}
@@ -0,0 +1,215 @@
+package connect
+
+import (
+	"math"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+MemoryMessageQueueAdmin Administration of memory queues registered in MemoryMessageQueueConnection.
+Operations on queues that are not registered fail with QUEUE_NOT_FOUND error,
+target queues of move and copy operations are created when they do not exist.
+
+See IMessageQueueAdmin
+See MemoryMessageQueueConnection
+
+Example:
+
+    connection := NewMemoryMessageQueueConnection()
+    admin := NewMemoryMessageQueueAdmin(connection)
+
+    stats, err := admin.ReadQueueStats("123")
+    ...
+    count, err := admin.MoveMessages("123", "orders", "orders.retry", cdata.NewFilterParamsFromTuples(
+        "dead_letter", true,
+        "message_type", "order",
+    ))
+*/
+type MemoryMessageQueueAdmin struct {
+	connection *MemoryMessageQueueConnection
+}
+
+// NewMemoryMessageQueueAdmin method are creates a new instance of the admin.
+//   - connection    a connection with registered queues.
+// Returns: *MemoryMessageQueueAdmin
+func NewMemoryMessageQueueAdmin(connection *MemoryMessageQueueConnection) *MemoryMessageQueueAdmin {
+	c := MemoryMessageQueueAdmin{
+		connection: connection,
+	}
+	return &c
+}
+
+func (c *MemoryMessageQueueAdmin) findQueue(correlationId string, name string) (*queues.MemoryMessageQueue, error) {
+	queue := c.connection.FindQueue(name)
+	if queue == nil {
+		return nil, cerr.NewNotFoundError(correlationId, "QUEUE_NOT_FOUND", "Queue "+name+" is not found").
+			WithDetails("queue", name)
+	}
+	return queue, nil
+}
+
+// ReadQueueStats method are reads the state of all queues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with queue statistics sorted by queue names or error.
+func (c *MemoryMessageQueueAdmin) ReadQueueStats(correlationId string) ([]*queues.MessageQueueStats, error) {
+	names, err := c.connection.ReadQueueNames()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*queues.MessageQueueStats{}
+	for _, name := range names {
+		queue := c.connection.FindQueue(name)
+		if queue == nil {
+			continue
+		}
+		stats, err := queue.ReadStats(correlationId)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// GetQueueStats method are reads the state of a queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a name of the queue.
+// Returns: the queue statistics or error.
+func (c *MemoryMessageQueueAdmin) GetQueueStats(correlationId string, name string) (*queues.MessageQueueStats, error) {
+	queue, err := c.findQueue(correlationId, name)
+	if err != nil {
+		return nil, err
+	}
+	return queue.ReadStats(correlationId)
+}
+
+// PurgeQueue method are removes messages that match the filter from a queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a name of the queue.
+//   - filter            (optional) a filter to select messages, all messages are removed when it is not set.
+// Returns: the number of removed messages or error.
+func (c *MemoryMessageQueueAdmin) PurgeQueue(correlationId string, name string, filter *cdata.FilterParams) (int64, error) {
+	queue, err := c.findQueue(correlationId, name)
+	if err != nil {
+		return 0, err
+	}
+	messages, err := queue.RemoveMessages(correlationId, filter)
+	return int64(len(messages)), err
+}
+
+// BrowseMessages method are gets a page of messages from a queue without removing them.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - name              a name of the queue.
+//   - filter            (optional) a filter to select messages.
+//   - paging            (optional) paging parameters.
+// Returns: a page with messages or error.
+func (c *MemoryMessageQueueAdmin) BrowseMessages(correlationId string, name string, filter *cdata.FilterParams,
+	paging *cdata.PagingParams) (*queues.MessageEnvelopePage, error) {
+	queue, err := c.findQueue(correlationId, name)
+	if err != nil {
+		return nil, err
+	}
+	return queue.BrowseMessages(correlationId, filter, paging)
+}
+
+// MoveMessages method are removes messages that match the filter from the source queue
+// and sends them to the target queue.
+// Every message is sent to the target queue before it is removed from the source,
+// so a failure in the middle of a move does not lose messages.
+// Moved messages keep their sent time. When a message is taken from the source queue
+// by a consumer during the move, its copy is removed from the target queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - source            a name of the source queue.
+//   - target            a name of the target queue.
+//   - filter            (optional) a filter to select messages.
+// Returns: the number of moved messages or error.
+func (c *MemoryMessageQueueAdmin) MoveMessages(correlationId string, source string, target string,
+	filter *cdata.FilterParams) (int64, error) {
+	sourceQueue, err := c.findQueue(correlationId, source)
+	if err != nil {
+		return 0, err
+	}
+	if source == target && !queues.NewMessageFilter(filter).DeadLetter {
+		return 0, cerr.NewBadRequestError(correlationId, "SAME_QUEUE", "Messages cannot be moved into the same queue").
+			WithDetails("queue", source)
+	}
+	targetQueue := c.connection.GetQueue(target)
+	deadLetter := queues.NewMessageFilter(filter).DeadLetter
+
+	page, err := sourceQueue.BrowseMessages(correlationId, filter, cdata.NewPagingParams(0, math.MaxInt64, false))
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	for _, message := range page.Data {
+		// The browsed message is a copy, so it is kept to remove the source entry
+		envelope := *message
+		err = targetQueue.Resend(correlationId, &envelope)
+		if err != nil {
+			return count, err
+		}
+		removed, err := sourceQueue.RemoveMessage(correlationId, message, deadLetter)
+		if err != nil {
+			return count, err
+		}
+		if !removed {
+			// The message was taken from the source meanwhile, so it is not moved
+			withdrawn, err := targetQueue.RemoveMessage(correlationId, &envelope, false)
+			if err != nil {
+				return count, err
+			}
+			if !withdrawn {
+				return count, cerr.NewConflictError(correlationId, "MESSAGE_TAKEN",
+					"Message "+message.MessageId+" was received from queue "+source+" and "+target+" while it was moved").
+					WithDetails("message_id", message.MessageId)
+			}
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// CopyMessages method are sends copies of messages that match the filter from the source queue
+// to the target queue. Messages in the source queue are left as they are.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - source            a name of the source queue.
+//   - target            a name of the target queue.
+//   - filter            (optional) a filter to select messages.
+// Returns: the number of copied messages or error.
+func (c *MemoryMessageQueueAdmin) CopyMessages(correlationId string, source string, target string,
+	filter *cdata.FilterParams) (int64, error) {
+	sourceQueue, err := c.findQueue(correlationId, source)
+	if err != nil {
+		return 0, err
+	}
+	if source == target && !queues.NewMessageFilter(filter).DeadLetter {
+		return 0, cerr.NewBadRequestError(correlationId, "SAME_QUEUE", "Messages cannot be copied into the same queue").
+			WithDetails("queue", source)
+	}
+	targetQueue := c.connection.GetQueue(target)
+
+	page, err := sourceQueue.BrowseMessages(correlationId, filter, cdata.NewPagingParams(0, math.MaxInt64, false))
+	if err != nil {
+		return 0, err
+	}
+	return c.sendMessages(correlationId, targetQueue, page.Data)
+}
+
+func (c *MemoryMessageQueueAdmin) sendMessages(correlationId string, queue *queues.MemoryMessageQueue,
+	messages []*queues.MessageEnvelope) (int64, error) {
+	count := int64(0)
+	for _, message := range messages {
+		err := queue.Resend(correlationId, message)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
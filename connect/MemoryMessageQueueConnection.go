@@ -0,0 +1,182 @@
+package connect
+
+import (
+	"sort"
+	"sync"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+MemoryMessageQueueConnection Connection that owns a registry of named MemoryMessageQueue instances.
+Components that get queues from the same connection share them by name,
+so messages sent by one component can be received by another one in the same process.
+
+Queues are created on the first request and configured with parameters
+and references of the connection. Deleted queues are closed and removed from the registry.
+
+Configuration parameters are passed to created queues.
+
+References are passed to created queues:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+
+See IMessageQueueConnection
+See MemoryMessageQueue
+
+Example:
+
+    connection := NewMemoryMessageQueueConnection()
+
+    queue1 := connection.GetQueue("myqueue")
+    queue1.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+
+    queue2 := connection.GetQueue("myqueue")
+    message, err := queue2.Receive("123", 10000*time.Millisecond)
+    ...
+*/
+type MemoryMessageQueueConnection struct {
+	config     *cconf.ConfigParams
+	references cref.IReferences
+	queues     map[string]*queues.MemoryMessageQueue
+	opened     bool
+	lock       sync.Mutex
+}
+
+// NewMemoryMessageQueueConnection method are creates a new instance of the connection.
+// Returns: *MemoryMessageQueueConnection
+func NewMemoryMessageQueueConnection() *MemoryMessageQueueConnection {
+	c := MemoryMessageQueueConnection{
+		queues: map[string]*queues.MemoryMessageQueue{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+// The parameters are passed to queues created after the call.
+//   - config    configuration parameters to be set.
+func (c *MemoryMessageQueueConnection) Configure(config *cconf.ConfigParams) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.config = config
+}
+
+// SetReferences method are sets references to dependent components.
+// The references are passed to queues created after the call.
+//   - references 	references to locate the component dependencies.
+func (c *MemoryMessageQueueConnection) SetReferences(references cref.IReferences) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.references = references
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *MemoryMessageQueueConnection) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.opened
+}
+
+// Open method are opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *MemoryMessageQueueConnection) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.opened = true
+	return nil
+}
+
+// Close method are closes all registered queues and removes them from the registry.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *MemoryMessageQueueConnection) Close(correlationId string) error {
+	c.lock.Lock()
+	registered := c.queues
+	c.queues = map[string]*queues.MemoryMessageQueue{}
+	c.opened = false
+	c.lock.Unlock()
+
+	for _, queue := range registered {
+		queue.Close(correlationId)
+	}
+	return nil
+}
+
+// GetQueue method are gets a registered queue by its name and creates it if it does not exist.
+//   - name    a name of the queue.
+// Returns: the shared memory message queue.
+func (c *MemoryMessageQueueConnection) GetQueue(name string) *queues.MemoryMessageQueue {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	queue, ok := c.queues[name]
+	if !ok {
+		queue = queues.NewMemoryMessageQueue(name)
+		if c.config != nil {
+			queue.Configure(c.config)
+		}
+		if c.references != nil {
+			queue.SetReferences(c.references)
+		}
+		queue.Open("")
+		c.queues[name] = queue
+	}
+	return queue
+}
+
+// FindQueue method are gets a registered queue by its name.
+//   - name    a name of the queue.
+// Returns: the shared memory message queue or nil if it is not registered.
+func (c *MemoryMessageQueueConnection) FindQueue(name string) *queues.MemoryMessageQueue {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.queues[name]
+}
+
+// ReadQueueNames method are reads names of the registered queues.
+// Returns: a sorted list with queue names or error.
+func (c *MemoryMessageQueueConnection) ReadQueueNames() ([]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateQueue method are creates a queue with the given name if it is not registered yet.
+//   - name    a name of the queue.
+// Returns: error or nil for success.
+func (c *MemoryMessageQueueConnection) CreateQueue(name string) error {
+	c.GetQueue(name)
+	return nil
+}
+
+// DeleteQueue method are closes a queue and removes it from the registry.
+// Messages that remain in the queue are lost. Deleting an absent queue does nothing.
+//   - name    a name of the queue.
+// Returns: error or nil for success.
+func (c *MemoryMessageQueueConnection) DeleteQueue(name string) error {
+	c.lock.Lock()
+	queue, ok := c.queues[name]
+	delete(c.queues, name)
+	c.lock.Unlock()
+
+	if ok {
+		queue.Close("")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package connect
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+SharedMemoryMessageQueue Handle of a MemoryMessageQueue that is shared through MemoryMessageQueueConnection.
+Every component gets its own handle, so opening and closing a handle or listening through it
+does not affect other components that share the queue. Messages are sent to and received from
+the shared queue, which stays open until it is deleted or the connection is closed.
+
+See MemoryMessageQueue
+See MemoryMessageQueueConnection
+
+Example:
+
+    connection := NewMemoryMessageQueueConnection()
+
+    queue1 := NewSharedMemoryMessageQueue(connection.GetQueue("myqueue"))
+    queue1.BeginListen("123", receiver)
+
+    queue2 := NewSharedMemoryMessageQueue(connection.GetQueue("myqueue"))
+    queue2.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    queue2.Close("123") // queue1 keeps listening
+*/
+type SharedMemoryMessageQueue struct {
+	*queues.MemoryMessageQueue
+	opened int32
+	cancel int32
+}
+
+// sharedQueueCheckInterval is the interval to check if the shared queue of a listening handle is closed.
+const sharedQueueCheckInterval = 100 * time.Millisecond
+
+// NewSharedMemoryMessageQueue method are creates a new handle of the shared queue.
+//   - queue     a shared memory message queue.
+// Returns: *SharedMemoryMessageQueue
+func NewSharedMemoryMessageQueue(queue *queues.MemoryMessageQueue) *SharedMemoryMessageQueue {
+	c := SharedMemoryMessageQueue{
+		MemoryMessageQueue: queue,
+	}
+	return &c
+}
+
+// Queue method are gets the shared queue of the handle.
+// Returns: the shared memory message queue.
+func (c *SharedMemoryMessageQueue) Queue() *queues.MemoryMessageQueue {
+	return c.MemoryMessageQueue
+}
+
+// IsOpen method are checks if the handle and the shared queue are opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SharedMemoryMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0 && c.MemoryMessageQueue.IsOpen()
+}
+
+// Open method are opens the handle. The shared queue is opened by the connection.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SharedMemoryMessageQueue) Open(correlationId string) error {
+	atomic.StoreInt32(&c.opened, 1)
+	return nil
+}
+
+// Close method are closes the handle and ends listening through it.
+// The shared queue and listening through other handles are not affected.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SharedMemoryMessageQueue) Close(correlationId string) error {
+	atomic.StoreInt32(&c.opened, 0)
+	c.EndListen(correlationId)
+	return nil
+}
+
+// Listen method are listens for incoming messages of the shared queue and blocks the current thread
+// until listening through the handle is ended or the shared queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+func (c *SharedMemoryMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	// Shared queues are closed by the connection, which does not know the handles
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(sharedQueueCheckInterval):
+				if !c.MemoryMessageQueue.IsOpen() {
+					c.EndListen(correlationId)
+					return
+				}
+			}
+		}
+	}()
+
+	return c.MemoryMessageQueue.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// BeginListen method are listens for incoming messages without blocking the current thread.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See Listen
+func (c *SharedMemoryMessageQueue) BeginListen(correlationId string, receiver queues.IMessageReceiver) {
+	go func() {
+		err := c.Listen(correlationId, receiver)
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to listed the message queue "+c.Name())
+		}
+	}()
+}
+
+// EndListen method are ends listening through the handle.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *SharedMemoryMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
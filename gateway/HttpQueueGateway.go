@@ -0,0 +1,631 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+HttpQueueGateway exposes message queues over HTTP, so they can be used from scripts and non-Go clients.
+
+Exposed queues are taken from references by "*:message-queue:*:*:1.0" descriptor
+and addressed by their names. Received messages are locked with tokens kept by the gateway.
+Locks that are not completed, abandoned or renewed within the lock timeout
+are abandoned by the gateway.
+
+Routes (relative to the base route):
+
+  - GET    /                                 names of exposed queues
+  - GET    /{queue}/count                    number of messages in the queue
+  - GET    /{queue}/peek?count=N             peeks up to N messages (default: 1)
+  - POST   /{queue}/messages                 sends a message
+  - GET    /{queue}/messages?wait=MS         receives a message waiting up to MS milliseconds, returns 204 when there are none
+  - DELETE /{queue}/messages/{token}         completes a received message
+  - PUT    /{queue}/messages/{token}/abandon abandons a received message
+  - PUT    /{queue}/messages/{token}/renew   renews the lock of a received message
+  - PUT    /{queue}/messages/{token}/dead    moves a received message to dead letter
+  - GET    /{queue}/stream?mode=M&types=T&prefetch=N
+                                             streams messages over WebSocket (see below)
+
+Messages are sent and returned as JSON objects with message_id, correlation_id,
+message_type, sent_time, headers, group_id and message fields, where the message is base64 encoded.
+Received messages also have lock_token field. Errors are returned as ErrorDescription objects.
+
+The stream route upgrades the connection to WebSocket and sends {"type":"message","message":{...}}
+JSON frames. In "ack" mode (default) messages are consumed from the queue: the client acknowledges
+them by sending {"type":"complete|abandon|dead|renew","lock_token":"...","timeout":MS} frames,
+and no more than prefetch (default: 10) messages are sent until earlier ones are acknowledged.
+Unacknowledged messages are abandoned when the socket is closed. In "tap" mode messages are
+observed by peeking the queue without removing them; when the client cannot keep up with prefetch
+buffered messages, the rest are dropped and reported by {"type":"overflow","dropped":N} frame.
+The types parameter limits the stream to comma-separated message types; in "ack" mode messages
+of other types are abandoned for other consumers.
+
+Configuration parameters:
+
+  - connection(s):
+    - host:                      host name or IP address to listen on (default: 0.0.0.0)
+    - port:                      port number to listen on, 0 to pick a free port (default: 8080)
+  - options:
+    - base_route:                base route of the gateway (default: /queues)
+    - queues:                    comma-separated names of queues to expose (default: all referenced queues)
+    - lock_timeout:              timeout in milliseconds of message locks held by the gateway (default: 30000)
+    - max_wait:                  maximum time in milliseconds to wait for a message in long polling (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:message-queue:*:*:1.0    (optional)  IMessageQueue components to expose
+
+See IMessageQueue
+
+Example:
+
+    queue := queues.NewMemoryMessageQueue("orders")
+    queue.Open("123")
+
+    gateway := NewHttpQueueGateway()
+    gateway.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.port", 8080,
+    ))
+    gateway.SetReferences(cref.NewReferencesFromTuples(
+        cref.NewDescriptor("pip-services", "message-queue", "memory", "orders", "1.0"), queue,
+    ))
+    gateway.Open("123")
+
+    // curl -X POST http://localhost:8080/queues/orders/messages -d '{"message_type":"order","message":"QUJD"}'
+    // curl http://localhost:8080/queues/orders/messages?wait=10000
+*/
+type HttpQueueGateway struct {
+	Logger             *clog.CompositeLogger
+	Counters           *ccount.CompositeCounters
+	ConnectionResolver *cconn.ConnectionResolver
+	baseRoute          string
+	queueNames         []string
+	lockTimeout        time.Duration
+	maxWait            time.Duration
+	queues             map[string]queues.IMessageQueue
+	locks              map[string]*gatewayLock
+	streams            map[*queueStream]bool
+	streamsWait        sync.WaitGroup
+	server             *http.Server
+	listener           net.Listener
+	lock               sync.Mutex
+}
+
+// gatewayLock is a message received through the gateway and locked with a token.
+type gatewayLock struct {
+	queue          queues.IMessageQueue
+	message        *queues.MessageEnvelope
+	expirationTime time.Time
+}
+
+// gatewayMessage is a message as it is sent and returned by the gateway.
+type gatewayMessage struct {
+	MessageId     string            `json:"message_id,omitempty"`
+	CorrelationId string            `json:"correlation_id,omitempty"`
+	MessageType   string            `json:"message_type,omitempty"`
+	SentTime      *time.Time        `json:"sent_time,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	GroupId       string            `json:"group_id,omitempty"`
+	Message       []byte            `json:"message"`
+	LockToken     string            `json:"lock_token,omitempty"`
+}
+
+// NewHttpQueueGateway method are creates a new instance of the gateway.
+func NewHttpQueueGateway() *HttpQueueGateway {
+	c := HttpQueueGateway{
+		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		baseRoute:          "/queues",
+		lockTimeout:        30000 * time.Millisecond,
+		maxWait:            30000 * time.Millisecond,
+		queues:             map[string]queues.IMessageQueue{},
+		locks:              map[string]*gatewayLock{},
+		streams:            map[*queueStream]bool{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *HttpQueueGateway) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+
+	c.baseRoute = strings.TrimSuffix(config.GetAsStringWithDefault("options.base_route", c.baseRoute), "/")
+	if c.baseRoute != "" && !strings.HasPrefix(c.baseRoute, "/") {
+		c.baseRoute = "/" + c.baseRoute
+	}
+	if names := config.GetAsString("options.queues"); names != "" {
+		c.queueNames = []string{}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.queueNames = append(c.queueNames, name)
+			}
+		}
+	}
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+	c.maxWait = time.Duration(config.GetAsLongWithDefault("options.max_wait", int64(c.maxWait/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components and collects queues to expose.
+//   - references 	references to locate the component dependencies.
+func (c *HttpQueueGateway) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+
+	components := references.GetOptional(cref.NewDescriptor("*", "message-queue", "*", "*", "1.0"))
+	for _, component := range components {
+		if queue, ok := component.(queues.IMessageQueue); ok {
+			c.AddQueue(queue)
+		}
+	}
+}
+
+// AddQueue method are exposes a queue through the gateway.
+// When options.queues is configured, queues with other names are ignored.
+//   - queue    a queue to expose.
+func (c *HttpQueueGateway) AddQueue(queue queues.IMessageQueue) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.queueNames != nil {
+		found := false
+		for _, name := range c.queueNames {
+			found = found || name == queue.Name()
+		}
+		if !found {
+			return
+		}
+	}
+	c.queues[queue.Name()] = queue
+}
+
+// GetQueueNames method are gets names of exposed queues.
+// Returns: a sorted list with queue names.
+func (c *HttpQueueGateway) GetQueueNames() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *HttpQueueGateway) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.server != nil
+}
+
+// Open method are starts the HTTP server.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *HttpQueueGateway) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	connection, err := c.ConnectionResolver.Resolve(correlationId)
+	if err != nil {
+		return err
+	}
+
+	host := "0.0.0.0"
+	port := 8080
+	if connection != nil {
+		if connection.Host() != "" {
+			host = connection.Host()
+		}
+		port = connection.PortWithDefault(port)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to listen on "+host+":"+strconv.Itoa(port)).
+			WithCause(err)
+	}
+
+	server := &http.Server{Handler: c.createHandler()}
+
+	c.lock.Lock()
+	c.server = server
+	c.listener = listener
+	c.lock.Unlock()
+
+	go server.Serve(listener)
+
+	c.Logger.Info(correlationId, "Opened HTTP queue gateway at %s%s", listener.Addr().String(), c.baseRoute)
+
+	return nil
+}
+
+// Close method are stops the HTTP server and abandons messages locked by the gateway.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *HttpQueueGateway) Close(correlationId string) error {
+	c.lock.Lock()
+	server := c.server
+	c.server = nil
+	c.listener = nil
+	locks := c.locks
+	c.locks = map[string]*gatewayLock{}
+	streams := []*queueStream{}
+	for stream := range c.streams {
+		streams = append(streams, stream)
+	}
+	c.lock.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	// Streams are hijacked connections that are not closed by the server shutdown
+	for _, stream := range streams {
+		stream.socket.Close()
+	}
+	c.streamsWait.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5000*time.Millisecond)
+	defer cancel()
+	server.Shutdown(ctx)
+
+	for _, lock := range locks {
+		lock.queue.Abandon(lock.message)
+	}
+
+	c.Logger.Info(correlationId, "Closed HTTP queue gateway")
+
+	return nil
+}
+
+// GetAddress method are gets the address the gateway listens on.
+// Returns: the host:port address or empty string if the gateway is not opened.
+func (c *HttpQueueGateway) GetAddress() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.listener == nil {
+		return ""
+	}
+	return c.listener.Addr().String()
+}
+
+func (c *HttpQueueGateway) createHandler() http.Handler {
+	mux := http.NewServeMux()
+	route := c.baseRoute
+
+	if route == "" {
+		mux.HandleFunc("GET /{$}", c.getQueueNames)
+	} else {
+		mux.HandleFunc("GET "+route, c.getQueueNames)
+	}
+	mux.HandleFunc("GET "+route+"/{queue}/count", c.readMessageCount)
+	mux.HandleFunc("GET "+route+"/{queue}/peek", c.peekMessages)
+	mux.HandleFunc("POST "+route+"/{queue}/messages", c.sendMessage)
+	mux.HandleFunc("GET "+route+"/{queue}/messages", c.receiveMessage)
+	mux.HandleFunc("DELETE "+route+"/{queue}/messages/{token}", c.completeMessage)
+	mux.HandleFunc("PUT "+route+"/{queue}/messages/{token}/abandon", c.abandonMessage)
+	mux.HandleFunc("PUT "+route+"/{queue}/messages/{token}/renew", c.renewLock)
+	mux.HandleFunc("PUT "+route+"/{queue}/messages/{token}/dead", c.moveToDeadLetter)
+	mux.HandleFunc("GET "+route+"/{queue}/stream", c.streamMessages)
+
+	return mux
+}
+
+func (c *HttpQueueGateway) getQueueNames(res http.ResponseWriter, req *http.Request) {
+	c.sendResult(res, c.GetQueueNames())
+}
+
+func (c *HttpQueueGateway) readMessageCount(res http.ResponseWriter, req *http.Request) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	count, err := queue.ReadMessageCount()
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	c.sendResult(res, map[string]int64{"count": count})
+}
+
+func (c *HttpQueueGateway) peekMessages(res http.ResponseWriter, req *http.Request) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	count := int64(1)
+	if value := req.URL.Query().Get("count"); value != "" {
+		count, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || count < 1 {
+			c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_COUNT", "Invalid message count "+value))
+			return
+		}
+	}
+
+	envelopes, err := queue.PeekBatch(getCorrelationId(req), count)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	messages := []*gatewayMessage{}
+	for _, envelope := range envelopes {
+		messages = append(messages, fromMessage(envelope, ""))
+	}
+	c.sendResult(res, messages)
+}
+
+func (c *HttpQueueGateway) sendMessage(res http.ResponseWriter, req *http.Request) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	message := &gatewayMessage{}
+	err = json.NewDecoder(req.Body).Decode(message)
+	if err != nil {
+		c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_MESSAGE", "Invalid message").WithCause(err))
+		return
+	}
+
+	envelope := toMessage(message)
+	if envelope.CorrelationId == "" {
+		envelope.CorrelationId = getCorrelationId(req)
+	}
+	err = queue.Send(envelope.CorrelationId, envelope)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusCreated)
+	json.NewEncoder(res).Encode(fromMessage(envelope, ""))
+}
+
+func (c *HttpQueueGateway) receiveMessage(res http.ResponseWriter, req *http.Request) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	wait := time.Duration(0)
+	if value := req.URL.Query().Get("wait"); value != "" {
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || millis < 0 {
+			c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_WAIT", "Invalid wait timeout "+value))
+			return
+		}
+		wait = time.Duration(millis) * time.Millisecond
+	}
+	if wait > c.maxWait {
+		wait = c.maxWait
+	}
+
+	envelope, err := queue.Receive(getCorrelationId(req), wait)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+	if envelope == nil {
+		res.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// The client went away while waiting
+	if req.Context().Err() != nil {
+		queue.Abandon(envelope)
+		return
+	}
+
+	token := cdata.IdGenerator.NextLong()
+	c.lock.Lock()
+	c.locks[token] = &gatewayLock{
+		queue:          queue,
+		message:        envelope,
+		expirationTime: time.Now().Add(c.lockTimeout),
+	}
+	c.lock.Unlock()
+
+	c.Counters.IncrementOne("gateway." + queue.Name() + ".received_messages")
+	c.sendResult(res, fromMessage(envelope, token))
+}
+
+func (c *HttpQueueGateway) completeMessage(res http.ResponseWriter, req *http.Request) {
+	lock, err := c.getLock(req, true)
+	if err == nil {
+		err = lock.queue.Complete(lock.message)
+	}
+	c.sendEmptyResult(res, err)
+}
+
+func (c *HttpQueueGateway) abandonMessage(res http.ResponseWriter, req *http.Request) {
+	lock, err := c.getLock(req, true)
+	if err == nil {
+		err = lock.queue.Abandon(lock.message)
+	}
+	c.sendEmptyResult(res, err)
+}
+
+func (c *HttpQueueGateway) moveToDeadLetter(res http.ResponseWriter, req *http.Request) {
+	lock, err := c.getLock(req, true)
+	if err == nil {
+		err = lock.queue.MoveToDeadLetter(lock.message)
+	}
+	c.sendEmptyResult(res, err)
+}
+
+func (c *HttpQueueGateway) renewLock(res http.ResponseWriter, req *http.Request) {
+	timeout := c.lockTimeout
+	if value := req.URL.Query().Get("timeout"); value != "" {
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || millis <= 0 {
+			c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_TIMEOUT", "Invalid lock timeout "+value))
+			return
+		}
+		timeout = time.Duration(millis) * time.Millisecond
+	}
+
+	lock, err := c.getLock(req, false)
+	if err == nil {
+		err = lock.queue.RenewLock(lock.message, timeout)
+	}
+	if err == nil {
+		c.lock.Lock()
+		lock.expirationTime = time.Now().Add(timeout)
+		c.lock.Unlock()
+	}
+	c.sendEmptyResult(res, err)
+}
+
+// getQueue finds an exposed queue by the name in the request path.
+func (c *HttpQueueGateway) getQueue(req *http.Request) (queues.IMessageQueue, error) {
+	name := req.PathValue("queue")
+
+	c.lock.Lock()
+	queue, ok := c.queues[name]
+	c.lock.Unlock()
+
+	if !ok {
+		return nil, cerr.NewNotFoundError(getCorrelationId(req), "QUEUE_NOT_FOUND", "Queue "+name+" is not found").
+			WithDetails("queue", name)
+	}
+	return queue, nil
+}
+
+// getLock finds a locked message by the token in the request path and optionally releases it.
+// Expired locks are abandoned before the search.
+func (c *HttpQueueGateway) getLock(req *http.Request, release bool) (*gatewayLock, error) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.abandonExpired()
+
+	token := req.PathValue("token")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	lock, ok := c.locks[token]
+	if !ok || lock.queue != queue {
+		return nil, cerr.NewNotFoundError(getCorrelationId(req), "LOCK_NOT_FOUND", "Lock "+token+" is not found or expired").
+			WithDetails("token", token)
+	}
+	if release {
+		delete(c.locks, token)
+	}
+	return lock, nil
+}
+
+// abandonExpired returns messages with expired locks into their queues.
+func (c *HttpQueueGateway) abandonExpired() {
+	now := time.Now()
+	expired := []*gatewayLock{}
+
+	c.lock.Lock()
+	for token, lock := range c.locks {
+		if lock.expirationTime.Before(now) {
+			expired = append(expired, lock)
+			delete(c.locks, token)
+		}
+	}
+	c.lock.Unlock()
+
+	for _, lock := range expired {
+		lock.queue.Abandon(lock.message)
+	}
+}
+
+func (c *HttpQueueGateway) sendResult(res http.ResponseWriter, result interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	json.NewEncoder(res).Encode(result)
+}
+
+func (c *HttpQueueGateway) sendEmptyResult(res http.ResponseWriter, err error) {
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (c *HttpQueueGateway) sendError(res http.ResponseWriter, err error) {
+	description := cerr.ErrorDescriptionFactory.Create(err)
+	status := description.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	json.NewEncoder(res).Encode(description)
+}
+
+func getCorrelationId(req *http.Request) string {
+	return req.URL.Query().Get("correlation_id")
+}
+
+func fromMessage(envelope *queues.MessageEnvelope, token string) *gatewayMessage {
+	message := &gatewayMessage{
+		MessageId:     envelope.MessageId,
+		CorrelationId: envelope.CorrelationId,
+		MessageType:   envelope.MessageType,
+		Headers:       envelope.Headers,
+		GroupId:       envelope.GroupId,
+		Message:       envelope.Message,
+		LockToken:     token,
+	}
+	if !envelope.SentTime.IsZero() {
+		sentTime := envelope.SentTime
+		message.SentTime = &sentTime
+	}
+	return message
+}
+
+func toMessage(message *gatewayMessage) *queues.MessageEnvelope {
+	envelope := queues.NewMessageEnvelope(message.CorrelationId, message.MessageType, message.Message)
+	if message.MessageId != "" {
+		envelope.MessageId = message.MessageId
+	}
+	envelope.Headers = message.Headers
+	envelope.GroupId = message.GroupId
+	return envelope
+}
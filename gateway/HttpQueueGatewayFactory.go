@@ -0,0 +1,26 @@
+package gateway
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cbuild "github.com/pip-services3-go/pip-services3-components-go/build"
+)
+
+// HttpQueueGatewayFactory are creates HttpQueueGateway components by their descriptors.
+//
+// See Factory
+// See HttpQueueGateway
+type HttpQueueGatewayFactory struct {
+	cbuild.Factory
+}
+
+// NewHttpQueueGatewayFactory method are create a new instance of the factory.
+func NewHttpQueueGatewayFactory() *HttpQueueGatewayFactory {
+	c := HttpQueueGatewayFactory{}
+	c.Factory = *cbuild.NewFactory()
+
+	httpGatewayDescriptor := cref.NewDescriptor("pip-services", "queue-gateway", "http", "*", "1.0")
+
+	c.RegisterType(httpGatewayDescriptor, NewHttpQueueGateway)
+
+	return &c
+}
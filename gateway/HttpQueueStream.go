@@ -0,0 +1,340 @@
+package gateway
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// Stream modes
+const (
+	// StreamModeAck consumes messages from the queue. Every message must be
+	// completed, abandoned or moved to dead letter by the client over the socket.
+	StreamModeAck = "ack"
+	// StreamModeTap observes messages in the queue without removing them.
+	StreamModeTap = "tap"
+)
+
+// Types of stream frames
+const (
+	frameMessage   = "message"
+	frameComplete  = "complete"
+	frameAbandon   = "abandon"
+	frameDead      = "dead"
+	frameRenew     = "renew"
+	frameOverflow  = "overflow"
+	frameError     = "error"
+	defaultCredits = 10
+)
+
+// streamFrame is a JSON frame sent over the socket in both directions.
+type streamFrame struct {
+	Type      string                 `json:"type"`
+	Message   *gatewayMessage        `json:"message,omitempty"`
+	LockToken string                 `json:"lock_token,omitempty"`
+	Timeout   int64                  `json:"timeout,omitempty"`
+	Dropped   int64                  `json:"dropped,omitempty"`
+	Error     *cerr.ErrorDescription `json:"error,omitempty"`
+}
+
+// queueStream is a socket that streams messages of a queue to a client.
+type queueStream struct {
+	gateway  *HttpQueueGateway
+	queue    queues.IMessageQueue
+	socket   *websocket.Conn
+	types    map[string]bool
+	prefetch int
+	writes   chan *streamFrame
+	done     chan struct{}
+	locks    map[string]*queues.MessageEnvelope
+	credits  chan struct{}
+	dropped  int64
+	closed   bool
+	lock     sync.Mutex
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamMessages upgrades the request to a WebSocket and streams messages of the queue.
+//
+// Query parameters:
+//   - mode        ack to consume messages or tap to observe them (default: ack)
+//   - types       comma-separated message types to stream (default: all)
+//   - prefetch    maximum number of messages sent and not acknowledged in ack mode,
+//                 or buffered for a slow client in tap mode (default: 10)
+func (c *HttpQueueGateway) streamMessages(res http.ResponseWriter, req *http.Request) {
+	queue, err := c.getQueue(req)
+	if err != nil {
+		c.sendError(res, err)
+		return
+	}
+
+	query := req.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = StreamModeAck
+	}
+	if mode != StreamModeAck && mode != StreamModeTap {
+		c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_MODE", "Invalid stream mode "+mode))
+		return
+	}
+	prefetch := defaultCredits
+	if value := query.Get("prefetch"); value != "" {
+		prefetch, err = strconv.Atoi(value)
+		if err != nil || prefetch < 1 {
+			c.sendError(res, cerr.NewBadRequestError(getCorrelationId(req), "WRONG_PREFETCH", "Invalid prefetch "+value))
+			return
+		}
+	}
+	var types map[string]bool
+	if value := query.Get("types"); value != "" {
+		types = map[string]bool{}
+		for _, messageType := range strings.Split(value, ",") {
+			types[strings.TrimSpace(messageType)] = true
+		}
+	}
+
+	socket, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		return
+	}
+
+	stream := &queueStream{
+		gateway:  c,
+		queue:    queue,
+		socket:   socket,
+		types:    types,
+		prefetch: prefetch,
+		writes:   make(chan *streamFrame, prefetch),
+		done:     make(chan struct{}),
+		locks:    map[string]*queues.MessageEnvelope{},
+		credits:  make(chan struct{}, prefetch),
+	}
+	for i := 0; i < prefetch; i++ {
+		stream.credits <- struct{}{}
+	}
+
+	c.Counters.IncrementOne("gateway." + queue.Name() + ".streams")
+	c.Logger.Debug(getCorrelationId(req), "Started %s stream of queue %s", mode, queue.Name())
+
+	c.lock.Lock()
+	if c.server == nil {
+		c.lock.Unlock()
+		socket.Close()
+		return
+	}
+	c.streams[stream] = true
+	c.streamsWait.Add(1)
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.streams, stream)
+		c.lock.Unlock()
+		c.streamsWait.Done()
+	}()
+
+	go stream.write()
+	if mode == StreamModeAck {
+		go stream.consume()
+	} else {
+		go stream.tap()
+	}
+	stream.read()
+}
+
+// read processes frames from the client until the socket is closed.
+func (c *queueStream) read() {
+	defer c.close()
+
+	for {
+		frame := &streamFrame{}
+		if err := c.socket.ReadJSON(frame); err != nil {
+			return
+		}
+
+		err := c.handle(frame)
+		if err != nil {
+			c.send(&streamFrame{Type: frameError, LockToken: frame.LockToken, Error: cerr.ErrorDescriptionFactory.Create(err)})
+		}
+	}
+}
+
+// handle executes an acknowledgement sent by the client.
+func (c *queueStream) handle(frame *streamFrame) error {
+	if frame.Type != frameComplete && frame.Type != frameAbandon && frame.Type != frameDead && frame.Type != frameRenew {
+		return cerr.NewBadRequestError("", "UNKNOWN_FRAME", "Unknown frame type "+frame.Type).
+			WithDetails("type", frame.Type)
+	}
+
+	c.lock.Lock()
+	message, ok := c.locks[frame.LockToken]
+	if ok && frame.Type != frameRenew {
+		delete(c.locks, frame.LockToken)
+	}
+	c.lock.Unlock()
+
+	if !ok {
+		return cerr.NewNotFoundError("", "LOCK_NOT_FOUND", "Lock "+frame.LockToken+" is not found").
+			WithDetails("token", frame.LockToken)
+	}
+
+	if frame.Type == frameRenew {
+		return c.queue.RenewLock(message, time.Duration(frame.Timeout)*time.Millisecond)
+	}
+
+	var err error
+	switch frame.Type {
+	case frameComplete:
+		err = c.queue.Complete(message)
+	case frameAbandon:
+		err = c.queue.Abandon(message)
+	case frameDead:
+		err = c.queue.MoveToDeadLetter(message)
+	}
+
+	// Released message gives a credit to send the next one
+	c.credits <- struct{}{}
+	return err
+}
+
+// consume receives messages from the queue while the client has credits.
+// Messages of filtered out types are abandoned for other consumers.
+func (c *queueStream) consume() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.credits:
+		}
+
+		var message *queues.MessageEnvelope
+		for message == nil {
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+
+			var err error
+			message, err = c.queue.Receive("", time.Duration(1000)*time.Millisecond)
+			if err != nil {
+				c.gateway.Logger.Error("", err, "Failed to receive the message")
+				time.Sleep(time.Duration(1000) * time.Millisecond)
+				continue
+			}
+			if message != nil && !c.accepts(message) {
+				c.queue.Abandon(message)
+				message = nil
+				time.Sleep(time.Duration(100) * time.Millisecond)
+			}
+		}
+
+		token := cdata.IdGenerator.NextLong()
+		c.lock.Lock()
+		if c.closed {
+			c.lock.Unlock()
+			c.queue.Abandon(message)
+			return
+		}
+		c.locks[token] = message
+		c.lock.Unlock()
+
+		c.gateway.Counters.IncrementOne("gateway." + c.queue.Name() + ".received_messages")
+		c.send(&streamFrame{Type: frameMessage, Message: fromMessage(message, token)})
+	}
+}
+
+// tap periodically peeks messages in the queue and sends the ones that were not seen before.
+// When the client does not keep up, messages are dropped and an overflow frame is sent.
+func (c *queueStream) tap() {
+	seen := map[string]bool{}
+	for {
+		messages, err := c.queue.PeekBatch("", 100)
+		if err != nil {
+			c.gateway.Logger.Error("", err, "Failed to peek messages")
+		}
+
+		current := map[string]bool{}
+		for _, message := range messages {
+			current[message.MessageId] = true
+			if seen[message.MessageId] || !c.accepts(message) {
+				continue
+			}
+
+			select {
+			case c.writes <- &streamFrame{Type: frameMessage, Message: fromMessage(message, "")}:
+			default:
+				c.lock.Lock()
+				c.dropped++
+				c.lock.Unlock()
+			}
+		}
+		seen = current
+
+		c.lock.Lock()
+		dropped := c.dropped
+		c.dropped = 0
+		c.lock.Unlock()
+		if dropped > 0 {
+			c.send(&streamFrame{Type: frameOverflow, Dropped: dropped})
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(time.Duration(500) * time.Millisecond):
+		}
+	}
+}
+
+func (c *queueStream) accepts(message *queues.MessageEnvelope) bool {
+	return c.types == nil || c.types[message.MessageType]
+}
+
+// send queues a frame to be written to the socket.
+func (c *queueStream) send(frame *streamFrame) {
+	select {
+	case c.writes <- frame:
+	case <-c.done:
+	}
+}
+
+// write sends queued frames to the socket one by one.
+func (c *queueStream) write() {
+	for {
+		select {
+		case frame := <-c.writes:
+			if err := c.socket.WriteJSON(frame); err != nil {
+				c.socket.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// close stops the stream and abandons messages that were not acknowledged.
+func (c *queueStream) close() {
+	close(c.done)
+	c.socket.Close()
+
+	c.lock.Lock()
+	c.closed = true
+	locks := c.locks
+	c.locks = map[string]*queues.MessageEnvelope{}
+	c.lock.Unlock()
+
+	for _, message := range locks {
+		c.queue.Abandon(message)
+	}
+}
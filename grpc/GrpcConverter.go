@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"fmt"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/grpc/protos"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fromEnvelope converts a message envelope into its protobuf representation.
+func fromEnvelope(envelope *queues.MessageEnvelope) *protos.MessageEnvelope {
+	if envelope == nil {
+		return nil
+	}
+
+	message := &protos.MessageEnvelope{
+		MessageId:     envelope.MessageId,
+		CorrelationId: envelope.CorrelationId,
+		MessageType:   envelope.MessageType,
+		Headers:       envelope.Headers,
+		Message:       envelope.Message,
+		GroupId:       envelope.GroupId,
+	}
+	if !envelope.SentTime.IsZero() {
+		message.SentTime = timestamppb.New(envelope.SentTime)
+	}
+	return message
+}
+
+// toEnvelope converts a protobuf message into a message envelope.
+func toEnvelope(message *protos.MessageEnvelope) *queues.MessageEnvelope {
+	if message == nil {
+		return nil
+	}
+
+	envelope := queues.NewMessageEnvelope(message.CorrelationId, message.MessageType, message.Message)
+	if message.MessageId != "" {
+		envelope.MessageId = message.MessageId
+	}
+	if len(message.Headers) > 0 {
+		envelope.Headers = message.Headers
+	}
+	envelope.GroupId = message.GroupId
+	if message.SentTime != nil {
+		envelope.SentTime = message.SentTime.AsTime()
+	}
+	return envelope
+}
+
+// fromError converts an application error into a gRPC status error.
+// The error description is passed in the status details to restore the error on the client.
+func fromError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	description := cerr.ErrorDescriptionFactory.Create(err)
+
+	code := codes.Unknown
+	switch description.Category {
+	case cerr.BadRequest:
+		code = codes.InvalidArgument
+	case cerr.Unauthorized:
+		code = codes.PermissionDenied
+	case cerr.NotFound:
+		code = codes.NotFound
+	case cerr.Conflict:
+		code = codes.Aborted
+	case cerr.Unsupported:
+		code = codes.Unimplemented
+	case cerr.InvalidState:
+		code = codes.FailedPrecondition
+	case cerr.NoResponse:
+		code = codes.DeadlineExceeded
+	case cerr.FailedInvocation:
+		code = codes.Unavailable
+	case cerr.Internal, cerr.Misconfiguration, cerr.FileError:
+		code = codes.Internal
+	}
+
+	details := map[string]string{}
+	for key, value := range description.Details {
+		details[key] = fmt.Sprint(value)
+	}
+
+	result := status.New(code, description.Message)
+	withDetails, detailsErr := result.WithDetails(&protos.ErrorDescription{
+		Type:          description.Type,
+		Category:      description.Category,
+		Code:          description.Code,
+		CorrelationId: description.CorrelationId,
+		Status:        int32(description.Status),
+		Message:       description.Message,
+		Cause:         description.Cause,
+		StackTrace:    description.StackTrace,
+		Details:       details,
+	})
+	if detailsErr == nil {
+		result = withDetails
+	}
+	return result.Err()
+}
+
+// toError restores an application error from a gRPC status error.
+// Errors without error description are treated as connection errors.
+func toError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if result, ok := status.FromError(err); ok {
+		for _, detail := range result.Details() {
+			description, ok := detail.(*protos.ErrorDescription)
+			if !ok {
+				continue
+			}
+
+			details := map[string]interface{}{}
+			for key, value := range description.Details {
+				details[key] = value
+			}
+
+			return cerr.ApplicationErrorFactory.Create(&cerr.ErrorDescription{
+				Type:          description.Type,
+				Category:      description.Category,
+				Code:          description.Code,
+				CorrelationId: description.CorrelationId,
+				Status:        int(description.Status),
+				Message:       description.Message,
+				Cause:         description.Cause,
+				StackTrace:    description.StackTrace,
+				Details:       details,
+			})
+		}
+	}
+
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute gRPC operation").
+		WithCause(err)
+}
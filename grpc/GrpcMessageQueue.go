@@ -0,0 +1,555 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/grpc/protos"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+/*
+GrpcMessageQueue message queue client that works with a queue exposed by GrpcMessageQueueServer.
+
+Received messages stay locked on the server until they are completed or abandoned,
+or until the lock timeout of the server expires.
+Listen opens a server stream that pushes messages as they come,
+instead of polling the queue with Receive calls.
+
+Configuration parameters:
+
+  - name:                        name of the message queue on the server
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - host:                      host name or IP address of the server
+    - port:                      port number (default: 8090)
+    - uri:                       resource URI or connection string with all parameters in it
+  - options:
+    - timeout:                   timeout in milliseconds of gRPC calls (default: 30000)
+    - prefetch:                  maximum number of unacknowledged messages streamed to a listener (default: 1)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+
+See MessageQueue
+See GrpcMessageQueueServer
+
+Example:
+
+    queue := NewGrpcMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 8090,
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type GrpcMessageQueue struct {
+	queues.MessageQueue
+	timeout  time.Duration
+	prefetch int32
+	conn     *grpc.ClientConn
+	client   protos.MessageQueueClient
+	listen   context.CancelFunc
+	opened   int32
+	cancel   int32
+}
+
+// NewGrpcMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *GrpcMessageQueue
+// See MessagingCapabilities
+func NewGrpcMessageQueue(name string) *GrpcMessageQueue {
+	c := GrpcMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.timeout = 30000 * time.Millisecond
+	c.prefetch = 1
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *GrpcMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+	c.prefetch = int32(config.GetAsIntegerWithDefault("options.prefetch", int(c.prefetch)))
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *GrpcMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *GrpcMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	address, err := c.composeAddress(correlationId, connections[0])
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to gRPC server at "+address).
+			WithCause(err)
+	}
+
+	c.Lock.Lock()
+	c.conn = conn
+	c.client = protos.NewMessageQueueClient(conn)
+	c.Lock.Unlock()
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), address)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *GrpcMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	c.EndListen(correlationId)
+	atomic.StoreInt32(&c.opened, 0)
+
+	c.Lock.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.client = nil
+	c.Lock.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are clears component state.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *GrpcMessageQueue) Clear(correlationId string) error {
+	client, ctx, cancel, err := c.getClient(correlationId, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = client.Clear(ctx, &protos.QueueRequest{CorrelationId: correlationId, QueueName: c.Name()})
+	return toError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *GrpcMessageQueue) ReadMessageCount() (int64, error) {
+	client, ctx, cancel, err := c.getClient("", 0)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	reply, err := client.ReadMessageCount(ctx, &protos.QueueRequest{QueueName: c.Name()})
+	if err != nil {
+		return 0, toError("", err)
+	}
+	return reply.Count, nil
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *GrpcMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	client, ctx, cancel, err := c.getClient(correlationId, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	envelope.SentTime = time.Now()
+
+	_, err = client.Send(ctx, &protos.SendRequest{
+		CorrelationId: correlationId,
+		QueueName:     c.Name(),
+		Message:       fromEnvelope(envelope),
+	})
+	if err != nil {
+		return toError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *GrpcMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	client, ctx, cancel, err := c.getClient(correlationId, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	reply, err := client.Peek(ctx, &protos.QueueRequest{CorrelationId: correlationId, QueueName: c.Name()})
+	if err != nil {
+		return nil, toError(correlationId, err)
+	}
+	if reply.Message == nil {
+		return nil, nil
+	}
+
+	message := toEnvelope(reply.Message)
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *GrpcMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	client, ctx, cancel, err := c.getClient(correlationId, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	reply, err := client.PeekBatch(ctx, &protos.PeekBatchRequest{
+		CorrelationId: correlationId,
+		QueueName:     c.Name(),
+		MessageCount:  messageCount,
+	})
+	if err != nil {
+		return nil, toError(correlationId, err)
+	}
+
+	messages := []*queues.MessageEnvelope{}
+	for _, message := range reply.Messages {
+		messages = append(messages, toEnvelope(message))
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *GrpcMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	client, ctx, cancel, err := c.getClient(correlationId, waitTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	reply, err := client.Receive(ctx, &protos.ReceiveRequest{
+		CorrelationId: correlationId,
+		QueueName:     c.Name(),
+		WaitTimeout:   waitTimeout.Milliseconds(),
+	})
+	if err != nil {
+		return nil, toError(correlationId, err)
+	}
+	if reply.Message == nil {
+		return nil, nil
+	}
+
+	message := toEnvelope(reply.Message)
+	message.SetReference(reply.LockToken)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+	c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// This method is usually used to extend the message processing time.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *GrpcMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	token, ok := message.GetReference().(string)
+	if !ok || token == "" {
+		return nil
+	}
+
+	client, ctx, cancel, err := c.getClient(message.CorrelationId, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = client.RenewLock(ctx, &protos.LockRequest{
+		CorrelationId: message.CorrelationId,
+		QueueName:     c.Name(),
+		LockToken:     token,
+		LockTimeout:   lockTimeout.Milliseconds(),
+	})
+	if err != nil {
+		return toError(message.CorrelationId, err)
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *GrpcMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	err := c.release(message, protos.MessageQueueClient.Complete)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *GrpcMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	err := c.release(message, protos.MessageQueueClient.Abandon)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *GrpcMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	err := c.release(message, protos.MessageQueueClient.MoveToDeadLetter)
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+// Messages are pushed by the server through a stream that is restored when it fails.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *GrpcMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	c.Logger.Trace("", "Started listening messages at %s", c.String())
+
+	// Unset cancellation token
+	atomic.StoreInt32(&c.cancel, 0)
+
+	for atomic.LoadInt32(&c.cancel) == 0 {
+		err := c.listenStream(correlationId, receiver)
+		if err != nil && atomic.LoadInt32(&c.cancel) == 0 {
+			c.Logger.Error(correlationId, err, "Failed to receive the message")
+			time.Sleep(time.Duration(1000) * time.Millisecond)
+		}
+	}
+
+	return nil
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *GrpcMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+
+	c.Lock.Lock()
+	if c.listen != nil {
+		c.listen()
+		c.listen = nil
+	}
+	c.Lock.Unlock()
+}
+
+// listenStream passes messages from a single Listen stream to the receiver until the stream ends.
+func (c *GrpcMessageQueue) listenStream(correlationId string, receiver queues.IMessageReceiver) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	c.Lock.Lock()
+	client := c.client
+	ctx, cancel := context.WithCancel(context.Background())
+	c.listen = cancel
+	c.Lock.Unlock()
+	defer cancel()
+
+	if client == nil || atomic.LoadInt32(&c.cancel) != 0 {
+		return nil
+	}
+
+	stream, err := client.Listen(ctx, &protos.ListenRequest{
+		CorrelationId: correlationId,
+		QueueName:     c.Name(),
+		Prefetch:      c.prefetch,
+	})
+	if err != nil {
+		return toError(correlationId, err)
+	}
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return toError(correlationId, err)
+		}
+
+		message := toEnvelope(reply.Message)
+		message.SetReference(reply.LockToken)
+
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+		// Streamed messages are already received, so they are throttled before processing
+		if !c.ThrottleListen(correlationId, &c.cancel) {
+			c.Abandon(message)
+			return nil
+		}
+
+		c.DeliverMessage(correlationId, receiver, message)
+	}
+}
+
+// release calls the operation that releases the lock of a received message.
+func (c *GrpcMessageQueue) release(message *queues.MessageEnvelope,
+	operation func(protos.MessageQueueClient, context.Context, *protos.LockRequest, ...grpc.CallOption) (*protos.EmptyReply, error)) error {
+	token, ok := message.GetReference().(string)
+	if !ok || token == "" {
+		return nil
+	}
+
+	client, ctx, cancel, err := c.getClient(message.CorrelationId, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	_, err = operation(client, ctx, &protos.LockRequest{
+		CorrelationId: message.CorrelationId,
+		QueueName:     c.Name(),
+		LockToken:     token,
+	})
+	if err != nil {
+		return toError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	return nil
+}
+
+// getClient gets the gRPC client and a context with the call timeout.
+// The wait time is added to the timeout for calls that block on the server.
+func (c *GrpcMessageQueue) getClient(correlationId string,
+	wait time.Duration) (protos.MessageQueueClient, context.Context, context.CancelFunc, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	c.Lock.Lock()
+	client := c.client
+	c.Lock.Unlock()
+
+	if client == nil {
+		return nil, nil, nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The queue is not opened")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout+wait)
+	return client, ctx, cancel, nil
+}
+
+// composeAddress composes server address from connection parameters.
+func (c *GrpcMessageQueue) composeAddress(correlationId string, connection *cconn.ConnectionParams) (string, error) {
+	host := connection.Host()
+	port := connection.PortWithDefault(8090)
+
+	if uri := connection.Uri(); uri != "" {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Hostname() == "" {
+			return "", cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid gRPC connection uri").
+				WithCause(err)
+		}
+		host = parsed.Hostname()
+		if parsed.Port() != "" {
+			port, _ = strconv.Atoi(parsed.Port())
+		}
+	}
+
+	if host == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
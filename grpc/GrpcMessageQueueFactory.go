@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// GrpcMessageQueueFactory are creates GrpcMessageQueue and GrpcMessageQueueServer components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See GrpcMessageQueue
+// See GrpcMessageQueueServer
+type GrpcMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewGrpcMessageQueueFactory method are create a new instance of the factory.
+func NewGrpcMessageQueueFactory() *GrpcMessageQueueFactory {
+	c := GrpcMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	grpcQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "grpc", "*", "1.0")
+	grpcServerDescriptor := cref.NewDescriptor("pip-services", "queue-server", "grpc", "*", "1.0")
+
+	c.Register(grpcQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(grpcServerDescriptor, NewGrpcMessageQueueServer)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *GrpcMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewGrpcMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
@@ -0,0 +1,608 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	crun "github.com/pip-services3-go/pip-services3-commons-go/run"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/grpc/protos"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"google.golang.org/grpc"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative protos/messagequeue.proto
+
+/*
+GrpcMessageQueueServer exposes message queues over gRPC using the MessageQueue service
+defined in protos/messagequeue.proto, so they can be used by GrpcMessageQueue clients
+and by clients generated for other languages.
+
+Exposed queues are taken from references by "*:message-queue:*:*:1.0" descriptor
+and addressed by their names. Received messages are locked with tokens kept by the server.
+Locks that are not completed, abandoned or renewed within the lock timeout
+are abandoned by the server. Messages streamed by Listen calls are also abandoned
+when their stream is closed.
+
+Configuration parameters:
+
+  - connection(s):
+    - host:                      host name or IP address to listen on (default: 0.0.0.0)
+    - port:                      port number to listen on, 0 to pick a free port (default: 8090)
+  - options:
+    - queues:                    comma-separated names of queues to expose (default: all referenced queues)
+    - lock_timeout:              timeout in milliseconds of message locks held by the server (default: 30000)
+    - max_wait:                  maximum time in milliseconds to wait for a message in Receive calls (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:message-queue:*:*:1.0    (optional)  IMessageQueue components to expose
+
+See GrpcMessageQueue
+See IMessageQueue
+
+Example:
+
+    queue := queues.NewMemoryMessageQueue("orders")
+    queue.Open("123")
+
+    server := NewGrpcMessageQueueServer()
+    server.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.port", 8090,
+    ))
+    server.SetReferences(cref.NewReferencesFromTuples(
+        cref.NewDescriptor("pip-services", "message-queue", "memory", "orders", "1.0"), queue,
+    ))
+    server.Open("123")
+*/
+type GrpcMessageQueueServer struct {
+	protos.UnimplementedMessageQueueServer
+	Logger             *clog.CompositeLogger
+	Counters           *ccount.CompositeCounters
+	ConnectionResolver *cconn.ConnectionResolver
+	queueNames         []string
+	lockTimeout        time.Duration
+	maxWait            time.Duration
+	queues             map[string]queues.IMessageQueue
+	locks              map[string]*serverLock
+	server             *grpc.Server
+	listener           net.Listener
+	done               chan struct{}
+	lock               sync.Mutex
+}
+
+// serverLock is a message received through the server and locked with a token.
+type serverLock struct {
+	queue          queues.IMessageQueue
+	message        *queues.MessageEnvelope
+	expirationTime time.Time
+	stream         *serverStream
+}
+
+// serverStream is a Listen call that limits the number of unacknowledged messages.
+type serverStream struct {
+	credits chan struct{}
+}
+
+// NewGrpcMessageQueueServer method are creates a new instance of the server.
+func NewGrpcMessageQueueServer() *GrpcMessageQueueServer {
+	c := GrpcMessageQueueServer{
+		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		lockTimeout:        30000 * time.Millisecond,
+		maxWait:            30000 * time.Millisecond,
+		queues:             map[string]queues.IMessageQueue{},
+		locks:              map[string]*serverLock{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *GrpcMessageQueueServer) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+
+	if names := config.GetAsString("options.queues"); names != "" {
+		c.queueNames = []string{}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				c.queueNames = append(c.queueNames, name)
+			}
+		}
+	}
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+	c.maxWait = time.Duration(config.GetAsLongWithDefault("options.max_wait", int64(c.maxWait/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components and collects queues to expose.
+//   - references 	references to locate the component dependencies.
+func (c *GrpcMessageQueueServer) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+
+	components := references.GetOptional(cref.NewDescriptor("*", "message-queue", "*", "*", "1.0"))
+	for _, component := range components {
+		if queue, ok := component.(queues.IMessageQueue); ok {
+			c.AddQueue(queue)
+		}
+	}
+}
+
+// AddQueue method are exposes a queue through the server.
+// When options.queues is configured, queues with other names are ignored.
+//   - queue    a queue to expose.
+func (c *GrpcMessageQueueServer) AddQueue(queue queues.IMessageQueue) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.queueNames != nil {
+		found := false
+		for _, name := range c.queueNames {
+			found = found || name == queue.Name()
+		}
+		if !found {
+			return
+		}
+	}
+	c.queues[queue.Name()] = queue
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *GrpcMessageQueueServer) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.server != nil
+}
+
+// Open method are starts the gRPC server.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *GrpcMessageQueueServer) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	connection, err := c.ConnectionResolver.Resolve(correlationId)
+	if err != nil {
+		return err
+	}
+
+	host := "0.0.0.0"
+	port := 8090
+	if connection != nil {
+		if connection.Host() != "" {
+			host = connection.Host()
+		}
+		port = connection.PortWithDefault(port)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to listen on "+host+":"+strconv.Itoa(port)).
+			WithCause(err)
+	}
+
+	server := grpc.NewServer()
+	protos.RegisterMessageQueueServer(server, c)
+
+	c.lock.Lock()
+	c.server = server
+	c.listener = listener
+	c.done = make(chan struct{})
+	done := c.done
+	c.lock.Unlock()
+
+	go server.Serve(listener)
+	go c.expireLocks(done)
+
+	c.Logger.Info(correlationId, "Opened gRPC queue server at %s", listener.Addr().String())
+
+	return nil
+}
+
+// Close method are stops the gRPC server and abandons messages locked by the server.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *GrpcMessageQueueServer) Close(correlationId string) error {
+	c.lock.Lock()
+	server := c.server
+	c.server = nil
+	c.listener = nil
+	if c.done != nil {
+		close(c.done)
+		c.done = nil
+	}
+	c.lock.Unlock()
+
+	if server == nil {
+		return nil
+	}
+
+	// Streams are closed by Stop and abandon their messages on exit
+	server.Stop()
+
+	c.lock.Lock()
+	locks := c.locks
+	c.locks = map[string]*serverLock{}
+	c.lock.Unlock()
+
+	for _, lock := range locks {
+		lock.queue.Abandon(lock.message)
+	}
+
+	c.Logger.Info(correlationId, "Closed gRPC queue server")
+
+	return nil
+}
+
+// GetAddress method are gets the address the server listens on.
+// Returns: the host:port address or empty string if the server is not opened.
+func (c *GrpcMessageQueueServer) GetAddress() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.listener == nil {
+		return ""
+	}
+	return c.listener.Addr().String()
+}
+
+// GetQueueNames method are gets names of exposed queues.
+func (c *GrpcMessageQueueServer) GetQueueNames(ctx context.Context, req *protos.GetQueueNamesRequest) (*protos.GetQueueNamesReply, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &protos.GetQueueNamesReply{QueueNames: names}, nil
+}
+
+// ReadMessageCount method are reads the number of messages in the queue.
+func (c *GrpcMessageQueueServer) ReadMessageCount(ctx context.Context, req *protos.QueueRequest) (*protos.ReadMessageCountReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	count, err := queue.ReadMessageCount()
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.ReadMessageCountReply{Count: count}, nil
+}
+
+// Send method are sends a message into the queue.
+func (c *GrpcMessageQueueServer) Send(ctx context.Context, req *protos.SendRequest) (*protos.EmptyReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+	if req.Message == nil {
+		return nil, fromError(cerr.NewBadRequestError(req.CorrelationId, "NO_MESSAGE", "Message is not set"))
+	}
+
+	err = queue.Send(req.CorrelationId, toEnvelope(req.Message))
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.EmptyReply{}, nil
+}
+
+// Peek method are peeks a single message without removing it.
+func (c *GrpcMessageQueueServer) Peek(ctx context.Context, req *protos.QueueRequest) (*protos.MessageReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	message, err := queue.Peek(req.CorrelationId)
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.MessageReply{Message: fromEnvelope(message)}, nil
+}
+
+// PeekBatch method are peeks multiple messages without removing them.
+func (c *GrpcMessageQueueServer) PeekBatch(ctx context.Context, req *protos.PeekBatchRequest) (*protos.MessagesReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	messages, err := queue.PeekBatch(req.CorrelationId, req.MessageCount)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	reply := &protos.MessagesReply{}
+	for _, message := range messages {
+		reply.Messages = append(reply.Messages, fromEnvelope(message))
+	}
+	return reply, nil
+}
+
+// Receive method are receives a message and locks it with a token.
+func (c *GrpcMessageQueueServer) Receive(ctx context.Context, req *protos.ReceiveRequest) (*protos.MessageReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	wait := time.Duration(req.WaitTimeout) * time.Millisecond
+	if wait > c.maxWait {
+		wait = c.maxWait
+	}
+
+	message, err := queue.Receive(req.CorrelationId, wait)
+	if err != nil {
+		return nil, fromError(err)
+	}
+	if message == nil {
+		return &protos.MessageReply{}, nil
+	}
+
+	// The client may have gone while waiting
+	if ctx.Err() != nil {
+		queue.Abandon(message)
+		return nil, ctx.Err()
+	}
+
+	token := c.lockMessage(queue, message, nil)
+	return &protos.MessageReply{Message: fromEnvelope(message), LockToken: token}, nil
+}
+
+// RenewLock method are renews the lock of a received message.
+func (c *GrpcMessageQueueServer) RenewLock(ctx context.Context, req *protos.LockRequest) (*protos.EmptyReply, error) {
+	lock, err := c.getLock(req, false)
+	if err == nil {
+		timeout := time.Duration(req.LockTimeout) * time.Millisecond
+		err = lock.queue.RenewLock(lock.message, timeout)
+		if err == nil {
+			c.lock.Lock()
+			lock.expirationTime = time.Now().Add(timeout)
+			c.lock.Unlock()
+		}
+	}
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.EmptyReply{}, nil
+}
+
+// Complete method are removes a received message from the queue.
+func (c *GrpcMessageQueueServer) Complete(ctx context.Context, req *protos.LockRequest) (*protos.EmptyReply, error) {
+	return c.release(req, func(lock *serverLock) error {
+		return lock.queue.Complete(lock.message)
+	})
+}
+
+// Abandon method are returns a received message into the queue.
+func (c *GrpcMessageQueueServer) Abandon(ctx context.Context, req *protos.LockRequest) (*protos.EmptyReply, error) {
+	return c.release(req, func(lock *serverLock) error {
+		return lock.queue.Abandon(lock.message)
+	})
+}
+
+// MoveToDeadLetter method are moves a received message to dead letter queue.
+func (c *GrpcMessageQueueServer) MoveToDeadLetter(ctx context.Context, req *protos.LockRequest) (*protos.EmptyReply, error) {
+	return c.release(req, func(lock *serverLock) error {
+		return lock.queue.MoveToDeadLetter(lock.message)
+	})
+}
+
+// Clear method are removes all messages from the queue.
+func (c *GrpcMessageQueueServer) Clear(ctx context.Context, req *protos.QueueRequest) (*protos.EmptyReply, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	cleanable, ok := queue.(crun.ICleanable)
+	if !ok {
+		return nil, fromError(cerr.NewUnsupportedError(req.CorrelationId, "CLEAR_NOT_SUPPORTED", "Queue "+queue.Name()+" cannot be cleared"))
+	}
+	err = cleanable.Clear(req.CorrelationId)
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.EmptyReply{}, nil
+}
+
+// Listen method are streams received messages to the client.
+// The next message is received only when the client has less than prefetch unacknowledged messages.
+func (c *GrpcMessageQueueServer) Listen(req *protos.ListenRequest, res protos.MessageQueue_ListenServer) error {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return fromError(err)
+	}
+
+	prefetch := int(req.Prefetch)
+	if prefetch < 1 {
+		prefetch = 1
+	}
+	stream := &serverStream{credits: make(chan struct{}, prefetch)}
+	for i := 0; i < prefetch; i++ {
+		stream.credits <- struct{}{}
+	}
+	defer c.abandonStream(stream)
+
+	c.Logger.Debug(req.CorrelationId, "Started streaming messages of queue %s", queue.Name())
+
+	ctx := res.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stream.credits:
+		}
+
+		var message *queues.MessageEnvelope
+		for message == nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			message, err = queue.Receive(req.CorrelationId, time.Duration(1000)*time.Millisecond)
+			if err != nil {
+				return fromError(err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			queue.Abandon(message)
+			return nil
+		}
+
+		token := c.lockMessage(queue, message, stream)
+		err = res.Send(&protos.MessageReply{Message: fromEnvelope(message), LockToken: token})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// getQueue finds an exposed queue by its name.
+func (c *GrpcMessageQueueServer) getQueue(correlationId string, name string) (queues.IMessageQueue, error) {
+	c.lock.Lock()
+	queue, ok := c.queues[name]
+	c.lock.Unlock()
+
+	if !ok {
+		return nil, cerr.NewNotFoundError(correlationId, "QUEUE_NOT_FOUND", "Queue "+name+" is not found").
+			WithDetails("queue", name)
+	}
+	return queue, nil
+}
+
+// lockMessage keeps a received message until the client completes or abandons it.
+func (c *GrpcMessageQueueServer) lockMessage(queue queues.IMessageQueue, message *queues.MessageEnvelope,
+	stream *serverStream) string {
+	token := cdata.IdGenerator.NextLong()
+
+	c.lock.Lock()
+	c.locks[token] = &serverLock{
+		queue:          queue,
+		message:        message,
+		expirationTime: time.Now().Add(c.lockTimeout),
+		stream:         stream,
+	}
+	c.lock.Unlock()
+
+	return token
+}
+
+// getLock finds a locked message by its token and optionally releases it.
+func (c *GrpcMessageQueueServer) getLock(req *protos.LockRequest, release bool) (*serverLock, error) {
+	queue, err := c.getQueue(req.CorrelationId, req.QueueName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	lock, ok := c.locks[req.LockToken]
+	if !ok || lock.queue != queue {
+		return nil, cerr.NewNotFoundError(req.CorrelationId, "LOCK_NOT_FOUND", "Lock "+req.LockToken+" is not found or expired").
+			WithDetails("token", req.LockToken)
+	}
+	if release {
+		delete(c.locks, req.LockToken)
+	}
+	return lock, nil
+}
+
+// release releases a locked message and gives a credit to its stream.
+func (c *GrpcMessageQueueServer) release(req *protos.LockRequest, action func(lock *serverLock) error) (*protos.EmptyReply, error) {
+	lock, err := c.getLock(req, true)
+	if err != nil {
+		return nil, fromError(err)
+	}
+
+	err = action(lock)
+	lock.stream.release()
+	if err != nil {
+		return nil, fromError(err)
+	}
+	return &protos.EmptyReply{}, nil
+}
+
+// abandonStream returns messages locked by a closed stream into their queues.
+func (c *GrpcMessageQueueServer) abandonStream(stream *serverStream) {
+	locks := []*serverLock{}
+
+	c.lock.Lock()
+	for token, lock := range c.locks {
+		if lock.stream == stream {
+			locks = append(locks, lock)
+			delete(c.locks, token)
+		}
+	}
+	c.lock.Unlock()
+
+	for _, lock := range locks {
+		lock.queue.Abandon(lock.message)
+	}
+}
+
+// expireLocks periodically returns messages with expired locks into their queues.
+func (c *GrpcMessageQueueServer) expireLocks(done chan struct{}) {
+	ticker := time.NewTicker(time.Duration(1000) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			expired := []*serverLock{}
+
+			c.lock.Lock()
+			for token, lock := range c.locks {
+				if lock.expirationTime.Before(now) {
+					expired = append(expired, lock)
+					delete(c.locks, token)
+				}
+			}
+			c.lock.Unlock()
+
+			for _, lock := range expired {
+				lock.queue.Abandon(lock.message)
+				lock.stream.release()
+			}
+		}
+	}
+}
+
+// release gives a credit to receive the next message.
+func (c *serverStream) release() {
+	if c == nil {
+		return
+	}
+
+	select {
+	case c.credits <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,578 @@
+// Remote interface to message queues hosted by GrpcMessageQueueServer.
+// It mirrors IMessageQueue: every request names the queue it is addressed to,
+// and received messages are locked with tokens that are passed back to
+// RenewLock, Complete, Abandon and MoveToDeadLetter calls.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: protos/messagequeue.proto
+
+package protos
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MessageQueue_GetQueueNames_FullMethodName    = "/messagequeue.v1.MessageQueue/GetQueueNames"
+	MessageQueue_ReadMessageCount_FullMethodName = "/messagequeue.v1.MessageQueue/ReadMessageCount"
+	MessageQueue_Send_FullMethodName             = "/messagequeue.v1.MessageQueue/Send"
+	MessageQueue_Peek_FullMethodName             = "/messagequeue.v1.MessageQueue/Peek"
+	MessageQueue_PeekBatch_FullMethodName        = "/messagequeue.v1.MessageQueue/PeekBatch"
+	MessageQueue_Receive_FullMethodName          = "/messagequeue.v1.MessageQueue/Receive"
+	MessageQueue_RenewLock_FullMethodName        = "/messagequeue.v1.MessageQueue/RenewLock"
+	MessageQueue_Complete_FullMethodName         = "/messagequeue.v1.MessageQueue/Complete"
+	MessageQueue_Abandon_FullMethodName          = "/messagequeue.v1.MessageQueue/Abandon"
+	MessageQueue_MoveToDeadLetter_FullMethodName = "/messagequeue.v1.MessageQueue/MoveToDeadLetter"
+	MessageQueue_Clear_FullMethodName            = "/messagequeue.v1.MessageQueue/Clear"
+	MessageQueue_Listen_FullMethodName           = "/messagequeue.v1.MessageQueue/Listen"
+)
+
+// MessageQueueClient is the client API for MessageQueue service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MessageQueueClient interface {
+	// Gets names of queues exposed by the server.
+	GetQueueNames(ctx context.Context, in *GetQueueNamesRequest, opts ...grpc.CallOption) (*GetQueueNamesReply, error)
+	// Gets the number of messages in the queue.
+	ReadMessageCount(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*ReadMessageCountReply, error)
+	// Sends a message into the queue.
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Peeks a single message without removing it. Returns no message when the queue is empty.
+	Peek(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*MessageReply, error)
+	// Peeks multiple messages without removing them.
+	PeekBatch(ctx context.Context, in *PeekBatchRequest, opts ...grpc.CallOption) (*MessagesReply, error)
+	// Receives a message waiting up to wait_timeout and locks it.
+	// Returns no message when the queue stays empty.
+	Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*MessageReply, error)
+	// Renews the lock of a received message.
+	RenewLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Removes a received message from the queue.
+	Complete(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Returns a received message into the queue.
+	Abandon(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Moves a received message to the dead letter queue.
+	MoveToDeadLetter(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Removes all messages from the queue.
+	Clear(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*EmptyReply, error)
+	// Streams received messages. No more than prefetch messages are sent
+	// until earlier ones are acknowledged with Complete, Abandon or MoveToDeadLetter.
+	// Unacknowledged messages are abandoned when the stream is closed.
+	Listen(ctx context.Context, in *ListenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageReply], error)
+}
+
+type messageQueueClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMessageQueueClient(cc grpc.ClientConnInterface) MessageQueueClient {
+	return &messageQueueClient{cc}
+}
+
+func (c *messageQueueClient) GetQueueNames(ctx context.Context, in *GetQueueNamesRequest, opts ...grpc.CallOption) (*GetQueueNamesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetQueueNamesReply)
+	err := c.cc.Invoke(ctx, MessageQueue_GetQueueNames_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) ReadMessageCount(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*ReadMessageCountReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadMessageCountReply)
+	err := c.cc.Invoke(ctx, MessageQueue_ReadMessageCount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Send_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Peek(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*MessageReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Peek_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) PeekBatch(ctx context.Context, in *PeekBatchRequest, opts ...grpc.CallOption) (*MessagesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessagesReply)
+	err := c.cc.Invoke(ctx, MessageQueue_PeekBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*MessageReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MessageReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Receive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) RenewLock(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_RenewLock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Complete(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Complete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Abandon(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Abandon_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) MoveToDeadLetter(ctx context.Context, in *LockRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_MoveToDeadLetter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Clear(ctx context.Context, in *QueueRequest, opts ...grpc.CallOption) (*EmptyReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmptyReply)
+	err := c.cc.Invoke(ctx, MessageQueue_Clear_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *messageQueueClient) Listen(ctx context.Context, in *ListenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MessageReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MessageQueue_ServiceDesc.Streams[0], MessageQueue_Listen_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListenRequest, MessageReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageQueue_ListenClient = grpc.ServerStreamingClient[MessageReply]
+
+// MessageQueueServer is the server API for MessageQueue service.
+// All implementations must embed UnimplementedMessageQueueServer
+// for forward compatibility.
+type MessageQueueServer interface {
+	// Gets names of queues exposed by the server.
+	GetQueueNames(context.Context, *GetQueueNamesRequest) (*GetQueueNamesReply, error)
+	// Gets the number of messages in the queue.
+	ReadMessageCount(context.Context, *QueueRequest) (*ReadMessageCountReply, error)
+	// Sends a message into the queue.
+	Send(context.Context, *SendRequest) (*EmptyReply, error)
+	// Peeks a single message without removing it. Returns no message when the queue is empty.
+	Peek(context.Context, *QueueRequest) (*MessageReply, error)
+	// Peeks multiple messages without removing them.
+	PeekBatch(context.Context, *PeekBatchRequest) (*MessagesReply, error)
+	// Receives a message waiting up to wait_timeout and locks it.
+	// Returns no message when the queue stays empty.
+	Receive(context.Context, *ReceiveRequest) (*MessageReply, error)
+	// Renews the lock of a received message.
+	RenewLock(context.Context, *LockRequest) (*EmptyReply, error)
+	// Removes a received message from the queue.
+	Complete(context.Context, *LockRequest) (*EmptyReply, error)
+	// Returns a received message into the queue.
+	Abandon(context.Context, *LockRequest) (*EmptyReply, error)
+	// Moves a received message to the dead letter queue.
+	MoveToDeadLetter(context.Context, *LockRequest) (*EmptyReply, error)
+	// Removes all messages from the queue.
+	Clear(context.Context, *QueueRequest) (*EmptyReply, error)
+	// Streams received messages. No more than prefetch messages are sent
+	// until earlier ones are acknowledged with Complete, Abandon or MoveToDeadLetter.
+	// Unacknowledged messages are abandoned when the stream is closed.
+	Listen(*ListenRequest, grpc.ServerStreamingServer[MessageReply]) error
+	mustEmbedUnimplementedMessageQueueServer()
+}
+
+// UnimplementedMessageQueueServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMessageQueueServer struct{}
+
+func (UnimplementedMessageQueueServer) GetQueueNames(context.Context, *GetQueueNamesRequest) (*GetQueueNamesReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetQueueNames not implemented")
+}
+func (UnimplementedMessageQueueServer) ReadMessageCount(context.Context, *QueueRequest) (*ReadMessageCountReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReadMessageCount not implemented")
+}
+func (UnimplementedMessageQueueServer) Send(context.Context, *SendRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedMessageQueueServer) Peek(context.Context, *QueueRequest) (*MessageReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Peek not implemented")
+}
+func (UnimplementedMessageQueueServer) PeekBatch(context.Context, *PeekBatchRequest) (*MessagesReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method PeekBatch not implemented")
+}
+func (UnimplementedMessageQueueServer) Receive(context.Context, *ReceiveRequest) (*MessageReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Receive not implemented")
+}
+func (UnimplementedMessageQueueServer) RenewLock(context.Context, *LockRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method RenewLock not implemented")
+}
+func (UnimplementedMessageQueueServer) Complete(context.Context, *LockRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Complete not implemented")
+}
+func (UnimplementedMessageQueueServer) Abandon(context.Context, *LockRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Abandon not implemented")
+}
+func (UnimplementedMessageQueueServer) MoveToDeadLetter(context.Context, *LockRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method MoveToDeadLetter not implemented")
+}
+func (UnimplementedMessageQueueServer) Clear(context.Context, *QueueRequest) (*EmptyReply, error) {
+	return nil, status.Error(codes.Unimplemented, "method Clear not implemented")
+}
+func (UnimplementedMessageQueueServer) Listen(*ListenRequest, grpc.ServerStreamingServer[MessageReply]) error {
+	return status.Error(codes.Unimplemented, "method Listen not implemented")
+}
+func (UnimplementedMessageQueueServer) mustEmbedUnimplementedMessageQueueServer() {}
+func (UnimplementedMessageQueueServer) testEmbeddedByValue()                      {}
+
+// UnsafeMessageQueueServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MessageQueueServer will
+// result in compilation errors.
+type UnsafeMessageQueueServer interface {
+	mustEmbedUnimplementedMessageQueueServer()
+}
+
+func RegisterMessageQueueServer(s grpc.ServiceRegistrar, srv MessageQueueServer) {
+	// If the following call panics, it indicates UnimplementedMessageQueueServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MessageQueue_ServiceDesc, srv)
+}
+
+func _MessageQueue_GetQueueNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQueueNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).GetQueueNames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_GetQueueNames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).GetQueueNames(ctx, req.(*GetQueueNamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_ReadMessageCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).ReadMessageCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_ReadMessageCount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).ReadMessageCount(ctx, req.(*QueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Peek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Peek(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Peek_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Peek(ctx, req.(*QueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_PeekBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeekBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).PeekBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_PeekBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).PeekBatch(ctx, req.(*PeekBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Receive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Receive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Receive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Receive(ctx, req.(*ReceiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_RenewLock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).RenewLock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_RenewLock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).RenewLock(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Complete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Complete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Complete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Complete(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Abandon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Abandon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Abandon_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Abandon(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_MoveToDeadLetter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).MoveToDeadLetter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_MoveToDeadLetter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).MoveToDeadLetter(ctx, req.(*LockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MessageQueueServer).Clear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MessageQueue_Clear_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MessageQueueServer).Clear(ctx, req.(*QueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MessageQueue_Listen_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListenRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MessageQueueServer).Listen(m, &grpc.GenericServerStream[ListenRequest, MessageReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MessageQueue_ListenServer = grpc.ServerStreamingServer[MessageReply]
+
+// MessageQueue_ServiceDesc is the grpc.ServiceDesc for MessageQueue service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MessageQueue_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messagequeue.v1.MessageQueue",
+	HandlerType: (*MessageQueueServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetQueueNames",
+			Handler:    _MessageQueue_GetQueueNames_Handler,
+		},
+		{
+			MethodName: "ReadMessageCount",
+			Handler:    _MessageQueue_ReadMessageCount_Handler,
+		},
+		{
+			MethodName: "Send",
+			Handler:    _MessageQueue_Send_Handler,
+		},
+		{
+			MethodName: "Peek",
+			Handler:    _MessageQueue_Peek_Handler,
+		},
+		{
+			MethodName: "PeekBatch",
+			Handler:    _MessageQueue_PeekBatch_Handler,
+		},
+		{
+			MethodName: "Receive",
+			Handler:    _MessageQueue_Receive_Handler,
+		},
+		{
+			MethodName: "RenewLock",
+			Handler:    _MessageQueue_RenewLock_Handler,
+		},
+		{
+			MethodName: "Complete",
+			Handler:    _MessageQueue_Complete_Handler,
+		},
+		{
+			MethodName: "Abandon",
+			Handler:    _MessageQueue_Abandon_Handler,
+		},
+		{
+			MethodName: "MoveToDeadLetter",
+			Handler:    _MessageQueue_MoveToDeadLetter_Handler,
+		},
+		{
+			MethodName: "Clear",
+			Handler:    _MessageQueue_Clear_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Listen",
+			Handler:       _MessageQueue_Listen_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "protos/messagequeue.proto",
+}
@@ -0,0 +1,970 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+/*
+KafkaMessageQueue message queue that is implemented on top of Kafka-protocol brokers.
+
+Messages are produced into a topic and consumed through a consumer group.
+Kafka tracks consumption with a single committed offset per partition,
+so Complete commits the offset below the lowest message on the partition
+that is still received and not completed. Messages that are not completed
+are delivered again after restart or rebalance of the group.
+
+Abandon and MoveToDeadLetter are emulated with topics: abandoned messages are
+produced into a retry topic, which is consumed together with the main topic,
+and dead messages are produced into a dead letter topic. In both cases the original
+message is completed. When max_retries is set, messages abandoned more times
+are moved to the dead letter topic.
+
+A partition key is taken from a message header, so messages with the same key
+keep their order. Messages without the key are spread across partitions.
+
+Peek reads messages after the committed offsets of the group with a separate
+consumer, skipping messages received by this queue. Messages received by other
+consumers of the group are not known and may be returned by Peek.
+Locks of received messages last until they are completed or the partition is revoked,
+so RenewLock is not supported.
+
+Configuration parameters:
+
+  - name:                        name of the message queue and its topic
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  connection protocol: tcp or ssl (default: tcp)
+    - host:                      host name or IP address of a broker
+    - port:                      port number (default: 9092)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name for SASL authentication
+    - password:                  user password
+    - mechanism:                 SASL mechanism: plain, scram-sha-256 or scram-sha-512 (default: plain)
+  - options:
+    - topic:                     name of the topic (default: queue name)
+    - group:                     name of the consumer group (default: pip-services)
+    - retry_topic:               name of the topic for abandoned messages (default: <topic>.retry)
+    - dead_letter_topic:         name of the topic for dead messages (default: <topic>.dead)
+    - partition_key:             name of the message header with the partition key (default: partition_key)
+    - max_retries:               number of abandons after which a message is moved to dead letter, 0 to disable (default: 0)
+    - auto_create:               true to create the topics on open (default: true)
+    - partitions:                number of partitions in created topics (default: 1)
+    - replication_factor:        replication factor of created topics, -1 for the broker default (default: -1)
+    - timeout:                   timeout in milliseconds of Kafka operations (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See MessageQueue
+See MessagingCapabilities
+
+Example:
+
+    queue := NewKafkaMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 9092,
+    ))
+    queue.Open("123")
+
+    envelope := queues.NewMessageEnvelope("", "mymessage", []byte("ABC"))
+    envelope.SetHeader("partition_key", "customer1")
+    queue.Send("123", envelope)
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type KafkaMessageQueue struct {
+	queues.MessageQueue
+	client            *kgo.Client
+	admin             *kadm.Client
+	clientOptions     []kgo.Opt
+	topic             string
+	group             string
+	retryTopic        string
+	deadLetterTopic   string
+	partitionKey      string
+	maxRetries        int64
+	autoCreate        bool
+	partitions        int32
+	replicationFactor int16
+	timeout           time.Duration
+	received          map[kafkaPartition]*kafkaPartitionState
+	lock              sync.Mutex
+	cancel            int32
+}
+
+// kafkaPartition identifies a partition of a topic.
+type kafkaPartition struct {
+	topic     string
+	partition int32
+}
+
+// kafkaPartitionState keeps offsets of messages received from a partition.
+// Records are received in order, so all offsets below next
+// that are not pending have been completed.
+type kafkaPartitionState struct {
+	pending map[int64]bool
+	next    int64
+}
+
+// Names of record headers
+const (
+	headerMessageId     = "pip-message-id"
+	headerCorrelationId = "pip-correlation-id"
+	headerMessageType   = "pip-message-type"
+	headerGroupId       = "pip-group-id"
+	headerDeliveryCount = "pip-delivery-count"
+	headerPrefix        = "pip-"
+)
+
+// NewKafkaMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *KafkaMessageQueue
+// See MessagingCapabilities
+func NewKafkaMessageQueue(name string) *KafkaMessageQueue {
+	c := KafkaMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, false, true, true, true),
+	)
+
+	c.group = "pip-services"
+	c.partitionKey = "partition_key"
+	c.autoCreate = true
+	c.partitions = 1
+	c.replicationFactor = -1
+	c.timeout = 30000 * time.Millisecond
+	c.received = map[kafkaPartition]*kafkaPartitionState{}
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *KafkaMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.topic = config.GetAsStringWithDefault("options.topic", c.topic)
+	c.group = config.GetAsStringWithDefault("options.group", c.group)
+	c.retryTopic = config.GetAsStringWithDefault("options.retry_topic", c.retryTopic)
+	c.deadLetterTopic = config.GetAsStringWithDefault("options.dead_letter_topic", c.deadLetterTopic)
+	c.partitionKey = config.GetAsStringWithDefault("options.partition_key", c.partitionKey)
+	c.maxRetries = config.GetAsLongWithDefault("options.max_retries", c.maxRetries)
+	c.autoCreate = config.GetAsBooleanWithDefault("options.auto_create", c.autoCreate)
+	c.partitions = int32(config.GetAsIntegerWithDefault("options.partitions", int(c.partitions)))
+	c.replicationFactor = int16(config.GetAsIntegerWithDefault("options.replication_factor", int(c.replicationFactor)))
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *KafkaMessageQueue) IsOpen() bool {
+	return c.client != nil
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *KafkaMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.client != nil {
+		return nil
+	}
+
+	options, err := c.composeOptions(correlationId, connections, credential)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	// Create topics before joining the group, so the first assignment includes them
+	admin, err := kgo.NewClient(options...)
+	if err == nil {
+		err = admin.Ping(ctx)
+		if err == nil && c.autoCreate {
+			err = c.createTopics(ctx, kadm.NewClient(admin))
+		}
+		admin.Close()
+	}
+
+	var client *kgo.Client
+	if err == nil {
+		client, err = kgo.NewClient(append(options,
+			kgo.ConsumerGroup(c.group),
+			kgo.ConsumeTopics(c.getTopic(), c.getRetryTopic()),
+			kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+			kgo.DisableAutoCommit(),
+			kgo.OnPartitionsRevoked(c.dropPartitions),
+			kgo.OnPartitionsLost(c.dropPartitions),
+		)...)
+	}
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to Kafka brokers").
+			WithCause(err)
+	}
+
+	c.lock.Lock()
+	c.received = map[kafkaPartition]*kafkaPartitionState{}
+	c.lock.Unlock()
+
+	c.clientOptions = options
+	c.client = client
+	c.admin = kadm.NewClient(client)
+	atomic.StoreInt32(&c.cancel, 0)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), c.getTopic())
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Messages that were received and not completed are delivered again to the group.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *KafkaMessageQueue) Close(correlationId string) error {
+	if c.client == nil {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+
+	c.client.Close()
+	c.client = nil
+	c.admin = nil
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are deletes all records in the queue, retry and dead letter topics.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *KafkaMessageQueue) Clear(correlationId string) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	ends, err := c.admin.ListEndOffsets(ctx, c.getTopic(), c.getRetryTopic(), c.getDeadLetterTopic())
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	deleted, err := c.admin.DeleteRecords(ctx, ends.Offsets())
+	if err == nil {
+		err = deleted.Error()
+	}
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	// Move the group and this consumer past the deleted records
+	offsets := map[string]map[int32]kgo.EpochOffset{}
+	ends.Each(func(offset kadm.ListedOffset) {
+		if offset.Topic == c.getDeadLetterTopic() {
+			return
+		}
+		if offsets[offset.Topic] == nil {
+			offsets[offset.Topic] = map[int32]kgo.EpochOffset{}
+		}
+		offsets[offset.Topic][offset.Partition] = kgo.EpochOffset{Epoch: -1, Offset: offset.Offset}
+	})
+
+	c.lock.Lock()
+	c.received = map[kafkaPartition]*kafkaPartitionState{}
+	c.lock.Unlock()
+
+	c.client.SetOffsets(offsets)
+	if _, generation := c.client.GroupMetadata(); generation >= 0 {
+		err = c.commit(ctx, offsets)
+	}
+
+	return c.wrapError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// The number is the lag of the consumer group without messages received by this queue.
+// Returns: number of messages or error.
+func (c *KafkaMessageQueue) ReadMessageCount() (int64, error) {
+	err := c.CheckOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	starts, ends, err := c.readOffsets(ctx)
+	if err != nil {
+		return 0, c.wrapError("", err)
+	}
+
+	count := int64(0)
+	for partition, end := range ends {
+		if start := starts[partition]; end > start {
+			count += end - start
+		}
+	}
+	return count, nil
+}
+
+// Send method are sends a message into the queue.
+// The partition key is taken from the message header configured in options.partition_key.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *KafkaMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	envelope.SentTime = time.Now()
+
+	err = c.produce(c.getTopic(), envelope, 0)
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *KafkaMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *KafkaMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	starts, ends, err := c.readOffsets(ctx)
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	messages, err := c.readRecords(ctx, starts, ends, messageCount)
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *KafkaMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Polling without a context returns buffered records right away,
+	// while an expired context returns nothing at all
+	var ctx context.Context
+	if waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), waitTimeout)
+		defer cancel()
+	}
+
+	// Empty fetches can be returned before the timeout expires, so polling is repeated
+	var records []*kgo.Record
+	for {
+		fetches := c.client.PollRecords(ctx, 1)
+		if fetches.IsClientClosed() {
+			return nil, nil
+		}
+		for _, fetchErr := range fetches.Errors() {
+			if !errors.Is(fetchErr.Err, context.DeadlineExceeded) {
+				return nil, c.wrapError(correlationId, fetchErr.Err)
+			}
+		}
+		records = fetches.Records()
+		if len(records) > 0 || ctx == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	record := records[0]
+	c.lock.Lock()
+	partition := kafkaPartition{topic: record.Topic, partition: record.Partition}
+	state, ok := c.received[partition]
+	if !ok {
+		state = &kafkaPartitionState{pending: map[int64]bool{}}
+		c.received[partition] = state
+	}
+	state.pending[record.Offset] = true
+	state.next = record.Offset + 1
+	c.lock.Unlock()
+
+	message := toMessage(record)
+	message.SetReference(record)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+	c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+	return message, nil
+}
+
+// RenewLock method are not supported by Kafka. Received messages stay locked until they are completed
+// or their partition is revoked from the consumer.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *KafkaMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue by committing the offset of the consumer group.
+// The offset can only move past a message after all previous messages in the partition are completed.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *KafkaMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	record, ok := message.GetReference().(*kgo.Record)
+	if !ok {
+		return nil
+	}
+
+	err := c.complete(record)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// The message is produced into the retry topic and the original message is completed.
+// When the message was abandoned more than max_retries times it is moved to the dead letter topic.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *KafkaMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	record, ok := message.GetReference().(*kgo.Record)
+	if !ok {
+		return nil
+	}
+
+	deliveryCount := getDeliveryCount(record) + 1
+	if c.maxRetries > 0 && deliveryCount > c.maxRetries {
+		return c.MoveToDeadLetter(message)
+	}
+
+	err := c.produce(c.getRetryTopic(), toMessage(record), deliveryCount)
+	if err == nil {
+		err = c.complete(record)
+	}
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue.
+// The message is produced into the dead letter topic and the original message is completed.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *KafkaMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	record, ok := message.GetReference().(*kgo.Record)
+	if !ok {
+		return nil
+	}
+
+	err := c.produce(c.getDeadLetterTopic(), toMessage(record), getDeliveryCount(record))
+	if err == nil {
+		err = c.complete(record)
+	}
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *KafkaMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *KafkaMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads all messages from the dead letter topic.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *KafkaMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	starts, err := c.admin.ListStartOffsets(ctx, c.getDeadLetterTopic())
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+	ends, err := c.admin.ListEndOffsets(ctx, c.getDeadLetterTopic())
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	startOffsets := map[kafkaPartition]int64{}
+	starts.Each(func(offset kadm.ListedOffset) {
+		startOffsets[kafkaPartition{topic: offset.Topic, partition: offset.Partition}] = offset.Offset
+	})
+	endOffsets := map[kafkaPartition]int64{}
+	ends.Each(func(offset kadm.ListedOffset) {
+		endOffsets[kafkaPartition{topic: offset.Topic, partition: offset.Partition}] = offset.Offset
+	})
+
+	messages, err := c.readRecords(ctx, startOffsets, endOffsets, -1)
+	return messages, c.wrapError(correlationId, err)
+}
+
+// createTopics creates the queue, retry and dead letter topics if they do not exist.
+func (c *KafkaMessageQueue) createTopics(ctx context.Context, admin *kadm.Client) error {
+	responses, err := admin.CreateTopics(ctx, c.partitions, c.replicationFactor, nil,
+		c.getTopic(), c.getRetryTopic(), c.getDeadLetterTopic())
+	if err != nil {
+		return err
+	}
+	for _, response := range responses {
+		if response.Err != nil && !errors.Is(response.Err, kerr.TopicAlreadyExists) {
+			return response.Err
+		}
+	}
+	return nil
+}
+
+// produce writes a message into the topic and waits until it is acknowledged by brokers.
+func (c *KafkaMessageQueue) produce(topic string, message *queues.MessageEnvelope, deliveryCount int64) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return c.client.ProduceSync(ctx, c.fromMessage(topic, message, deliveryCount)).FirstErr()
+}
+
+// complete removes the record from pending ones and commits the offset
+// below the lowest pending record of the partition.
+func (c *KafkaMessageQueue) complete(record *kgo.Record) error {
+	partition := kafkaPartition{topic: record.Topic, partition: record.Partition}
+
+	c.lock.Lock()
+	state, ok := c.received[partition]
+	if !ok || !state.pending[record.Offset] {
+		// The partition was revoked and the record will be delivered again
+		c.lock.Unlock()
+		return nil
+	}
+	delete(state.pending, record.Offset)
+	offset := state.next
+	for pending := range state.pending {
+		if pending < offset {
+			offset = pending
+		}
+	}
+	c.lock.Unlock()
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return c.commit(ctx, map[string]map[int32]kgo.EpochOffset{
+		record.Topic: {record.Partition: {Epoch: -1, Offset: offset}},
+	})
+}
+
+// commit synchronously commits offsets of the consumer group.
+func (c *KafkaMessageQueue) commit(ctx context.Context, offsets map[string]map[int32]kgo.EpochOffset) error {
+	var commitErr error
+	c.client.CommitOffsetsSync(ctx, offsets,
+		func(client *kgo.Client, request *kmsg.OffsetCommitRequest, response *kmsg.OffsetCommitResponse, err error) {
+			if err != nil {
+				commitErr = err
+				return
+			}
+			for _, topic := range response.Topics {
+				for _, partition := range topic.Partitions {
+					if err := kerr.ErrorForCode(partition.ErrorCode); err != nil && commitErr == nil {
+						commitErr = err
+					}
+				}
+			}
+		})
+	return commitErr
+}
+
+// dropPartitions forgets received records of revoked partitions.
+// The records are delivered again to the new owner of the partitions.
+func (c *KafkaMessageQueue) dropPartitions(ctx context.Context, client *kgo.Client, partitions map[string][]int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for topic, ids := range partitions {
+		for _, id := range ids {
+			delete(c.received, kafkaPartition{topic: topic, partition: id})
+		}
+	}
+}
+
+// readOffsets reads offsets of the queue and retry topic partitions
+// from which messages are to be delivered and the end offsets of the partitions.
+func (c *KafkaMessageQueue) readOffsets(ctx context.Context) (map[kafkaPartition]int64, map[kafkaPartition]int64, error) {
+	topics := []string{c.getTopic(), c.getRetryTopic()}
+
+	starts, err := c.admin.ListStartOffsets(ctx, topics...)
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	ends, err := c.admin.ListEndOffsets(ctx, topics...)
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	committed, err := c.admin.FetchOffsets(ctx, c.group)
+	if err == nil {
+		err = committed.Error()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	startOffsets := map[kafkaPartition]int64{}
+	starts.Each(func(offset kadm.ListedOffset) {
+		partition := kafkaPartition{topic: offset.Topic, partition: offset.Partition}
+		start := offset.Offset
+		if response, ok := committed.Lookup(offset.Topic, offset.Partition); ok && response.At > start {
+			start = response.At
+		}
+		if state, ok := c.received[partition]; ok && state.next > start {
+			start = state.next
+		}
+		startOffsets[partition] = start
+	})
+	endOffsets := map[kafkaPartition]int64{}
+	ends.Each(func(offset kadm.ListedOffset) {
+		endOffsets[kafkaPartition{topic: offset.Topic, partition: offset.Partition}] = offset.Offset
+	})
+
+	return startOffsets, endOffsets, nil
+}
+
+// readRecords reads up to messageCount records (or all records if it is negative)
+// between start and end offsets with a separate consumer outside of the consumer group.
+func (c *KafkaMessageQueue) readRecords(ctx context.Context, starts map[kafkaPartition]int64,
+	ends map[kafkaPartition]int64, messageCount int64) ([]*queues.MessageEnvelope, error) {
+
+	messages := []*queues.MessageEnvelope{}
+
+	offsets := map[string]map[int32]kgo.Offset{}
+	remaining := map[kafkaPartition]bool{}
+	for partition, end := range ends {
+		start := starts[partition]
+		if start >= end {
+			continue
+		}
+		if offsets[partition.topic] == nil {
+			offsets[partition.topic] = map[int32]kgo.Offset{}
+		}
+		offsets[partition.topic][partition.partition] = kgo.NewOffset().At(start)
+		remaining[partition] = true
+	}
+	if len(remaining) == 0 || messageCount == 0 {
+		return messages, nil
+	}
+
+	client, err := kgo.NewClient(append(c.clientOptions, kgo.ConsumePartitions(offsets))...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	for len(remaining) > 0 && (messageCount < 0 || int64(len(messages)) < messageCount) {
+		fetches := client.PollFetches(ctx)
+		for _, fetchErr := range fetches.Errors() {
+			return nil, fetchErr.Err
+		}
+
+		records := fetches.Records()
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].Timestamp.Before(records[j].Timestamp)
+		})
+		for _, record := range records {
+			partition := kafkaPartition{topic: record.Topic, partition: record.Partition}
+			if !remaining[partition] || record.Offset >= ends[partition] {
+				continue
+			}
+			if record.Offset >= ends[partition]-1 {
+				delete(remaining, partition)
+			}
+			if messageCount < 0 || int64(len(messages)) < messageCount {
+				messages = append(messages, toMessage(record))
+			}
+		}
+	}
+
+	return messages, nil
+}
+
+func (c *KafkaMessageQueue) getTopic() string {
+	if c.topic != "" {
+		return c.topic
+	}
+	return c.Name()
+}
+
+func (c *KafkaMessageQueue) getRetryTopic() string {
+	if c.retryTopic != "" {
+		return c.retryTopic
+	}
+	return c.getTopic() + ".retry"
+}
+
+func (c *KafkaMessageQueue) getDeadLetterTopic() string {
+	if c.deadLetterTopic != "" {
+		return c.deadLetterTopic
+	}
+	return c.getTopic() + ".dead"
+}
+
+func (c *KafkaMessageQueue) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *KafkaMessageQueue) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute Kafka operation").
+		WithCause(err)
+}
+
+// composeOptions composes Kafka client options from connection and credential parameters.
+func (c *KafkaMessageQueue) composeOptions(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) ([]kgo.Opt, error) {
+
+	brokers := []string{}
+	useTls := false
+	for _, connection := range connections {
+		protocol := connection.Protocol()
+		host := connection.Host()
+		port := connection.PortWithDefault(9092)
+
+		if uri := connection.Uri(); uri != "" {
+			parsed, err := url.Parse(uri)
+			if err != nil || parsed.Hostname() == "" {
+				return nil, cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid Kafka connection uri").
+					WithCause(err)
+			}
+			protocol = parsed.Scheme
+			host = parsed.Hostname()
+			if parsed.Port() != "" {
+				port, _ = strconv.Atoi(parsed.Port())
+			}
+		}
+
+		if host == "" {
+			return nil, cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+		}
+		switch protocol {
+		case "", "tcp", "kafka":
+		case "ssl", "tls":
+			useTls = true
+		default:
+			return nil, cerr.NewConfigError(correlationId, "WRONG_PROTOCOL", "Unsupported Kafka protocol "+protocol).
+				WithDetails("protocol", protocol)
+		}
+
+		brokers = append(brokers, host+":"+strconv.Itoa(port))
+	}
+
+	options := []kgo.Opt{kgo.SeedBrokers(brokers...)}
+	if useTls {
+		options = append(options, kgo.DialTLSConfig(&tls.Config{}))
+	}
+
+	if credential != nil && credential.Username() != "" {
+		var mechanism sasl.Mechanism
+		switch strings.ToLower(credential.GetAsString("mechanism")) {
+		case "", "plain":
+			mechanism = plain.Auth{User: credential.Username(), Pass: credential.Password()}.AsMechanism()
+		case "scram-sha-256":
+			mechanism = scram.Auth{User: credential.Username(), Pass: credential.Password()}.AsSha256Mechanism()
+		case "scram-sha-512":
+			mechanism = scram.Auth{User: credential.Username(), Pass: credential.Password()}.AsSha512Mechanism()
+		default:
+			return nil, cerr.NewConfigError(correlationId, "WRONG_MECHANISM", "Unsupported SASL mechanism "+credential.GetAsString("mechanism"))
+		}
+		options = append(options, kgo.SASL(mechanism))
+	}
+
+	return options, nil
+}
+
+func (c *KafkaMessageQueue) fromMessage(topic string, message *queues.MessageEnvelope, deliveryCount int64) *kgo.Record {
+	record := &kgo.Record{
+		Topic:     topic,
+		Value:     message.Message,
+		Timestamp: message.SentTime,
+		Headers: []kgo.RecordHeader{
+			{Key: headerMessageId, Value: []byte(message.MessageId)},
+			{Key: headerCorrelationId, Value: []byte(message.CorrelationId)},
+			{Key: headerMessageType, Value: []byte(message.MessageType)},
+		},
+	}
+	if message.GroupId != "" {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: headerGroupId, Value: []byte(message.GroupId)})
+	}
+	if deliveryCount > 0 {
+		record.Headers = append(record.Headers,
+			kgo.RecordHeader{Key: headerDeliveryCount, Value: []byte(strconv.FormatInt(deliveryCount, 10))})
+	}
+	for name, value := range message.Headers {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: name, Value: []byte(value)})
+	}
+	if key := message.GetHeader(c.partitionKey); key != "" {
+		record.Key = []byte(key)
+	}
+	return record
+}
+
+func toMessage(record *kgo.Record) *queues.MessageEnvelope {
+	message := queues.NewEmptyMessageEnvelope()
+	message.SentTime = record.Timestamp
+	message.Message = record.Value
+	for _, header := range record.Headers {
+		switch header.Key {
+		case headerMessageId:
+			message.MessageId = string(header.Value)
+		case headerCorrelationId:
+			message.CorrelationId = string(header.Value)
+		case headerMessageType:
+			message.MessageType = string(header.Value)
+		case headerGroupId:
+			message.GroupId = string(header.Value)
+		default:
+			if !strings.HasPrefix(header.Key, headerPrefix) {
+				message.SetHeader(header.Key, string(header.Value))
+			}
+		}
+	}
+	return message
+}
+
+func getDeliveryCount(record *kgo.Record) int64 {
+	for _, header := range record.Headers {
+		if header.Key == headerDeliveryCount {
+			count, _ := strconv.ParseInt(string(header.Value), 10, 64)
+			return count
+		}
+	}
+	return 0
+}
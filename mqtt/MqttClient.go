@@ -0,0 +1,92 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// Supported MQTT protocol versions.
+const (
+	// ProtocolVersion311 is MQTT 3.1.1 protocol.
+	ProtocolVersion311 = 4
+	// ProtocolVersion5 is MQTT 5 protocol.
+	ProtocolVersion5 = 5
+)
+
+// mqttClient hides differences between MQTT 3.1.1 and MQTT 5 client libraries.
+// Received messages are passed to the handler together with a function that acknowledges them.
+type mqttClient interface {
+	connect(ctx context.Context) error
+	publish(ctx context.Context, topic string, message *queues.MessageEnvelope) error
+	disconnect() error
+}
+
+// mqttClientOptions holds parameters to create mqttClient.
+type mqttClientOptions struct {
+	address      string
+	tlsConfig    *tls.Config
+	clientId     string
+	username     string
+	password     string
+	cleanSession bool
+	qos          byte
+	filter       string
+	timeout      time.Duration
+	handler      func(message *queues.MessageEnvelope, ack func() error)
+	onError      func(err error)
+}
+
+func newMqttClient(version int, options *mqttClientOptions) mqttClient {
+	if version == ProtocolVersion5 {
+		return newMqttV5Client(options)
+	}
+	return newMqttV3Client(options)
+}
+
+// encodeEnvelope serializes a message with all envelope fields for protocols without message properties.
+func encodeEnvelope(message *queues.MessageEnvelope) ([]byte, error) {
+	return json.Marshal(message)
+}
+
+// decodeEnvelope deserializes a message sent by encodeEnvelope.
+// Payloads published by other clients are returned as raw messages.
+func decodeEnvelope(payload []byte) *queues.MessageEnvelope {
+	var fields map[string]interface{}
+	if json.Unmarshal(payload, &fields) == nil && isEnvelope(fields) {
+		message := queues.NewEmptyMessageEnvelope()
+		if message.UnmarshalJSON(payload) == nil {
+			return message
+		}
+	}
+
+	message := queues.NewEmptyMessageEnvelope()
+	message.SentTime = time.Now()
+	message.Message = payload
+	return message
+}
+
+func isEnvelope(fields map[string]interface{}) bool {
+	for _, name := range []string{"message_id", "correlation_id", "message_type"} {
+		if _, ok := fields[name].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func formatSentTime(value time.Time) string {
+	return strconv.FormatInt(value.UnixMilli(), 10)
+}
+
+func parseSentTime(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMilli(millis)
+}
@@ -0,0 +1,639 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+MqttMessageQueue message queue that sends and receives messages via MQTT 3.1.1 or MQTT 5 broker.
+
+The queue subscribes to a topic filter that defaults to the queue name
+and publishes messages to a topic that also defaults to the queue name.
+Received messages are buffered until they are taken by Receive or Listen.
+QoS 1 acknowledgements are sent to the broker only when messages are completed
+or moved to dead letter, so unfinished messages are redelivered by the broker
+when a persistent session reconnects.
+
+MQTT 5 messages carry envelope fields in user properties.
+MQTT 3.1.1 has no message properties, so messages are sent as JSON envelopes
+and payloads published by other clients are received as raw messages.
+
+Configuration parameters:
+
+  - name:                        name of the message queue and its default topic
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  connection protocol: mqtt, tcp, mqtts, ssl or tls (default: mqtt)
+    - host:                      host name or IP address
+    - port:                      port number (default: 1883 or 8883 for TLS)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+    - ssl_ca_file:               path to CA certificate to verify the broker (TLS only)
+    - ssl_crt_file:              path to client certificate (TLS only)
+    - ssl_key_file:              path to client private key (TLS only)
+  - options:
+    - topic:                     topic to publish messages to (default: queue name)
+    - filter:                    topic filter to subscribe to (default: topic)
+    - dead_letter:               topic to publish dead messages to (default: none)
+    - qos:                       quality of service level 0, 1 or 2 (default: 1)
+    - protocol_version:          MQTT protocol version 4 for 3.1.1 or 5 (default: 4)
+    - client_id:                 client identifier (default: generated id)
+    - clean_session:             true to start a clean session, false to keep persistent session (default: true)
+    - timeout:                   timeout in milliseconds to connect and to wait for acknowledgements (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See MessageQueue
+See MessagingCapabilities
+
+Example:
+
+    queue := NewMqttMessageQueue("devices/+/telemetry")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 1883,
+        "options.topic", "devices/123/telemetry",
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type MqttMessageQueue struct {
+	queues.MessageQueue
+	client          mqttClient
+	messages        []*queues.MessageEnvelope
+	topic           string
+	filter          string
+	deadLetter      string
+	qos             int
+	protocolVersion int
+	clientId        string
+	cleanSession    bool
+	timeout         time.Duration
+	cancel          int32
+}
+
+// mqttDelivery is a reference of received message that acknowledges it in the broker.
+type mqttDelivery struct {
+	ack func() error
+}
+
+// NewMqttMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *MqttMessageQueue
+// See MessagingCapabilities
+func NewMqttMessageQueue(name string) *MqttMessageQueue {
+	c := MqttMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, false, true, true, true),
+	)
+
+	c.messages = make([]*queues.MessageEnvelope, 0)
+	c.qos = 1
+	c.protocolVersion = ProtocolVersion311
+	c.cleanSession = true
+	c.timeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MqttMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.topic = config.GetAsStringWithDefault("options.topic", c.topic)
+	c.filter = config.GetAsStringWithDefault("options.filter", c.filter)
+	c.deadLetter = config.GetAsStringWithDefault("options.dead_letter", c.deadLetter)
+	c.qos = config.GetAsIntegerWithDefault("options.qos", c.qos)
+	c.protocolVersion = config.GetAsIntegerWithDefault("options.protocol_version", c.protocolVersion)
+	c.clientId = config.GetAsStringWithDefault("options.client_id", c.clientId)
+	c.cleanSession = config.GetAsBooleanWithDefault("options.clean_session", c.cleanSession)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *MqttMessageQueue) IsOpen() bool {
+	return c.getClient() != nil
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *MqttMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	if c.qos < 0 || c.qos > 2 {
+		return cerr.NewConfigError(correlationId, "WRONG_QOS", "Quality of service level must be 0, 1 or 2").
+			WithDetails("qos", c.qos)
+	}
+	if c.protocolVersion != ProtocolVersion311 && c.protocolVersion != ProtocolVersion5 {
+		return cerr.NewConfigError(correlationId, "WRONG_PROTOCOL_VERSION", "MQTT protocol version must be 4 or 5").
+			WithDetails("protocol_version", c.protocolVersion)
+	}
+
+	options, err := c.composeOptions(correlationId, connections[0], credential)
+	if err != nil {
+		return err
+	}
+
+	client := newMqttClient(c.protocolVersion, options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	err = client.connect(ctx)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to MQTT broker at "+options.address).
+			WithCause(err)
+	}
+
+	c.Lock.Lock()
+	c.client = client
+	c.Lock.Unlock()
+	atomic.StoreInt32(&c.cancel, 0)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), options.address)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Messages that were received but not completed stay unacknowledged in the broker.
+// Listening is stopped before the connection is closed.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *MqttMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	c.EndListen(correlationId)
+
+	c.Lock.Lock()
+	client := c.client
+	c.client = nil
+	c.messages = make([]*queues.MessageEnvelope, 0)
+	c.Lock.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	err := client.disconnect()
+
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "DISCONNECT_FAILED", "Failed to disconnect from MQTT broker").
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are acknowledges and removes all buffered messages.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *MqttMessageQueue) Clear(correlationId string) error {
+	c.Lock.Lock()
+	messages := c.messages
+	c.messages = make([]*queues.MessageEnvelope, 0)
+	c.Lock.Unlock()
+
+	for _, message := range messages {
+		err := c.acknowledge(message)
+		if err != nil {
+			return c.wrapError(correlationId, err)
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Cleared queue %s", c.String())
+
+	return nil
+}
+
+// ReadMessageCount method are reads the current number of buffered messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *MqttMessageQueue) ReadMessageCount() (int64, error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	return int64(len(c.messages)), nil
+}
+
+// Send method are publishes a message to the topic of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *MqttMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	topic := c.getTopic()
+	if strings.ContainsAny(topic, "+#") {
+		return cerr.NewConfigError(correlationId, "WRONG_TOPIC", "Cannot publish to topic filter "+topic+", set options.topic")
+	}
+
+	envelope.SentTime = time.Now()
+	err = c.publish(topic, envelope)
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single buffered message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *MqttMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var message *queues.MessageEnvelope
+
+	c.Lock.Lock()
+	if len(c.messages) > 0 {
+		message = c.messages[0]
+	}
+	c.Lock.Unlock()
+
+	if message != nil {
+		c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+	}
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple buffered messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *MqttMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock.Lock()
+	count := int(messageCount)
+	if count > len(c.messages) {
+		count = len(c.messages)
+	}
+	messages := make([]*queues.MessageEnvelope, count)
+	copy(messages, c.messages[:count])
+	c.Lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+// The message stays unacknowledged in the broker until it is completed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *MqttMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		var message *queues.MessageEnvelope
+
+		c.Lock.Lock()
+		if len(c.messages) > 0 {
+			message = c.messages[0]
+			c.messages = c.messages[1:]
+		}
+		c.Lock.Unlock()
+
+		if message != nil {
+			c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+			c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+			return message, nil
+		}
+
+		if !time.Now().Before(deadline) || atomic.LoadInt32(&c.cancel) != 0 {
+			return nil, nil
+		}
+		time.Sleep(time.Duration(100) * time.Millisecond)
+	}
+}
+
+// RenewLock method are not supported by MQTT. Received messages stay locked until they are completed,
+// abandoned or the connection is closed.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *MqttMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	return nil
+}
+
+// Complete method are acknowledges a message in the broker and permanently removes it from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *MqttMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	if message.GetReference() == nil {
+		return nil
+	}
+
+	err := c.acknowledge(message)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// MQTT cannot reject messages, so the message is returned into the local buffer without acknowledgement.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *MqttMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	if message.GetReference() == nil {
+		return nil
+	}
+
+	c.Lock.Lock()
+	c.messages = append([]*queues.MessageEnvelope{message}, c.messages...)
+	c.Lock.Unlock()
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and publishes it to the dead letter topic.
+// If dead letter topic is not configured the message is just acknowledged.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *MqttMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	if message.GetReference() == nil {
+		return nil
+	}
+
+	if c.deadLetter != "" {
+		err := c.CheckOpen(message.CorrelationId)
+		if err != nil {
+			return err
+		}
+		err = c.publish(c.deadLetter, message)
+		if err != nil {
+			return c.wrapError(message.CorrelationId, err)
+		}
+	}
+
+	err := c.acknowledge(message)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *MqttMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *MqttMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// onMessage buffers a message that came from the broker.
+func (c *MqttMessageQueue) onMessage(message *queues.MessageEnvelope, ack func() error) {
+	message.SetReference(&mqttDelivery{ack: ack})
+
+	c.Lock.Lock()
+	c.messages = append(c.messages, message)
+	c.Lock.Unlock()
+}
+
+func (c *MqttMessageQueue) onError(err error) {
+	c.Logger.Error("", err, "MQTT connection error at %s", c.Name())
+}
+
+func (c *MqttMessageQueue) acknowledge(message *queues.MessageEnvelope) error {
+	delivery, ok := message.GetReference().(*mqttDelivery)
+	if !ok {
+		return nil
+	}
+
+	err := delivery.ack()
+	if err == nil {
+		message.SetReference(nil)
+	}
+	return err
+}
+
+func (c *MqttMessageQueue) getClient() mqttClient {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	return c.client
+}
+
+func (c *MqttMessageQueue) publish(topic string, message *queues.MessageEnvelope) error {
+	client := c.getClient()
+	if client == nil {
+		return cerr.NewInvalidStateError(message.CorrelationId, "NOT_OPENED", "The queue is not opened")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	return client.publish(ctx, topic, message)
+}
+
+func (c *MqttMessageQueue) getTopic() string {
+	if c.topic != "" {
+		return c.topic
+	}
+	return c.Name()
+}
+
+func (c *MqttMessageQueue) getFilter() string {
+	if c.filter != "" {
+		return c.filter
+	}
+	return c.getTopic()
+}
+
+func (c *MqttMessageQueue) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if appErr, ok := err.(*cerr.ApplicationError); ok {
+		return appErr
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to access MQTT topic "+c.getTopic()).
+		WithCause(err)
+}
+
+// composeOptions composes MQTT client options from connection and credential parameters.
+func (c *MqttMessageQueue) composeOptions(correlationId string, connection *cconn.ConnectionParams,
+	credential *cauth.CredentialParams) (*mqttClientOptions, error) {
+
+	protocol := connection.Protocol()
+	host := connection.Host()
+	port := connection.Port()
+
+	if uri := connection.Uri(); uri != "" {
+		address, err := url.Parse(uri)
+		if err != nil {
+			return nil, cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid MQTT connection uri").
+				WithCause(err)
+		}
+		protocol = address.Scheme
+		host = address.Hostname()
+		port, _ = strconv.Atoi(address.Port())
+	}
+
+	if host == "" {
+		return nil, cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+
+	useTls := false
+	switch protocol {
+	case "", "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTls = true
+	default:
+		return nil, cerr.NewConfigError(correlationId, "WRONG_PROTOCOL", "Unsupported MQTT protocol "+protocol).
+			WithDetails("protocol", protocol)
+	}
+
+	if port == 0 {
+		port = 1883
+		if useTls {
+			port = 8883
+		}
+	}
+
+	options := &mqttClientOptions{
+		address:      host + ":" + strconv.Itoa(port),
+		clientId:     c.clientId,
+		cleanSession: c.cleanSession,
+		qos:          byte(c.qos),
+		filter:       c.getFilter(),
+		timeout:      c.timeout,
+		handler:      c.onMessage,
+		onError:      c.onError,
+	}
+	if options.clientId == "" {
+		options.clientId = cdata.IdGenerator.NextShort()
+	}
+
+	if credential != nil {
+		options.username = credential.Username()
+		options.password = credential.Password()
+	}
+
+	if useTls {
+		tlsConfig, err := c.composeTlsConfig(correlationId, host, credential)
+		if err != nil {
+			return nil, err
+		}
+		options.tlsConfig = tlsConfig
+	}
+
+	return options, nil
+}
+
+// composeTlsConfig loads CA and client certificates set in credential parameters.
+func (c *MqttMessageQueue) composeTlsConfig(correlationId string, host string,
+	credential *cauth.CredentialParams) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{ServerName: host}
+	if credential == nil {
+		return tlsConfig, nil
+	}
+
+	if caFile := credential.GetAsString("ssl_ca_file"); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, cerr.NewFileError(correlationId, "READ_FAILED", "Failed to read CA certificate "+caFile).
+				WithCause(err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, cerr.NewConfigError(correlationId, "WRONG_CERTIFICATE", "Invalid CA certificate "+caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	crtFile := credential.GetAsString("ssl_crt_file")
+	keyFile := credential.GetAsString("ssl_key_file")
+	if crtFile != "" && keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(crtFile, keyFile)
+		if err != nil {
+			return nil, cerr.NewConfigError(correlationId, "WRONG_CERTIFICATE", "Invalid client certificate "+crtFile).
+				WithCause(err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
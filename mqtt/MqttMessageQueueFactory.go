@@ -0,0 +1,53 @@
+package mqtt
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// MqttMessageQueueFactory are creates MqttMessageQueue components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See MqttMessageQueue
+type MqttMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewMqttMessageQueueFactory method are create a new instance of the factory.
+func NewMqttMessageQueueFactory() *MqttMessageQueueFactory {
+	c := MqttMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	mqttQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "mqtt", "*", "1.0")
+
+	c.Register(mqttQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *MqttMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewMqttMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
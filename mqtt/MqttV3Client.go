@@ -0,0 +1,107 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// mqttV3Client is MQTT 3.1.1 client.
+// Envelope fields are sent in JSON payload, because the protocol has no message properties.
+// Subscription is restored on every reconnect.
+type mqttV3Client struct {
+	options   *mqttClientOptions
+	client    paho.Client
+	connected int32
+}
+
+func newMqttV3Client(options *mqttClientOptions) *mqttV3Client {
+	return &mqttV3Client{options: options}
+}
+
+func (c *mqttV3Client) connect(ctx context.Context) error {
+	scheme := "tcp://"
+	if c.options.tlsConfig != nil {
+		scheme = "ssl://"
+	}
+
+	clientOptions := paho.NewClientOptions().
+		AddBroker(scheme + c.options.address).
+		SetClientID(c.options.clientId).
+		SetCleanSession(c.options.cleanSession).
+		SetProtocolVersion(ProtocolVersion311).
+		SetConnectTimeout(c.options.timeout).
+		SetAutoAckDisabled(true).
+		SetOnConnectHandler(c.resubscribe).
+		SetConnectionLostHandler(func(client paho.Client, err error) {
+			c.options.onError(err)
+		})
+	if c.options.tlsConfig != nil {
+		clientOptions.SetTLSConfig(c.options.tlsConfig)
+	}
+	if c.options.username != "" {
+		clientOptions.SetUsername(c.options.username)
+		clientOptions.SetPassword(c.options.password)
+	}
+
+	c.client = paho.NewClient(clientOptions)
+	err := c.wait(ctx, c.client.Connect())
+	if err == nil {
+		err = c.wait(ctx, c.subscribe(c.client))
+		if err != nil {
+			c.client.Disconnect(0)
+		}
+	}
+	if err == nil {
+		atomic.StoreInt32(&c.connected, 1)
+	}
+	return err
+}
+
+func (c *mqttV3Client) subscribe(client paho.Client) paho.Token {
+	return client.Subscribe(c.options.filter, c.options.qos, func(client paho.Client, msg paho.Message) {
+		c.options.handler(decodeEnvelope(msg.Payload()), func() error {
+			msg.Ack()
+			return nil
+		})
+	})
+}
+
+// resubscribe restores subscription after automatic reconnect.
+func (c *mqttV3Client) resubscribe(client paho.Client) {
+	if atomic.LoadInt32(&c.connected) == 0 {
+		return
+	}
+
+	token := c.subscribe(client)
+	go func() {
+		if token.WaitTimeout(c.options.timeout) && token.Error() != nil {
+			c.options.onError(token.Error())
+		}
+	}()
+}
+
+func (c *mqttV3Client) publish(ctx context.Context, topic string, message *queues.MessageEnvelope) error {
+	payload, err := encodeEnvelope(message)
+	if err != nil {
+		return err
+	}
+	return c.wait(ctx, c.client.Publish(topic, c.options.qos, false, payload))
+}
+
+func (c *mqttV3Client) disconnect() error {
+	c.client.Disconnect(uint(c.options.timeout.Milliseconds()))
+	return nil
+}
+
+func (c *mqttV3Client) wait(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return errors.New("MQTT operation timed out")
+	}
+}
@@ -0,0 +1,157 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/eclipse/paho.golang/paho"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// Names of MQTT 5 user properties that carry envelope fields
+const (
+	propertyMessageId     = "message_id"
+	propertyCorrelationId = "correlation_id"
+	propertyMessageType   = "message_type"
+	propertySentTime      = "sent_time"
+	propertyGroupId       = "group_id"
+	// Message headers are sent in user properties with this prefix
+	propertyHeaderPrefix = "header."
+)
+
+// mqttV5Client is MQTT 5 client.
+// Envelope fields are sent in user properties, so payloads stay untouched.
+// The client does not reconnect, lost connections are reported to the error handler.
+type mqttV5Client struct {
+	options *mqttClientOptions
+	client  *paho.Client
+}
+
+func newMqttV5Client(options *mqttClientOptions) *mqttV5Client {
+	return &mqttV5Client{options: options}
+}
+
+func (c *mqttV5Client) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: c.options.timeout}
+	var conn net.Conn
+	var err error
+	if c.options.tlsConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.options.tlsConfig}).DialContext(ctx, "tcp", c.options.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.options.address)
+	}
+	if err != nil {
+		return err
+	}
+
+	client := paho.NewClient(paho.ClientConfig{
+		ClientID:                   c.options.clientId,
+		Conn:                       packets.NewThreadSafeConn(conn),
+		EnableManualAcknowledgment: true,
+		PacketTimeout:              c.options.timeout,
+		OnClientError:              c.options.onError,
+		OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+			func(received paho.PublishReceived) (bool, error) {
+				packet := received.Packet
+				c.options.handler(toMessage(packet), func() error {
+					return received.Client.Ack(packet)
+				})
+				return true, nil
+			},
+		},
+	})
+
+	connect := &paho.Connect{
+		ClientID:   c.options.clientId,
+		CleanStart: c.options.cleanSession,
+		KeepAlive:  30,
+	}
+	if !c.options.cleanSession {
+		expiry := uint32(0xFFFFFFFF)
+		connect.Properties = &paho.ConnectProperties{SessionExpiryInterval: &expiry}
+	}
+	if c.options.username != "" {
+		connect.Username = c.options.username
+		connect.UsernameFlag = true
+		connect.Password = []byte(c.options.password)
+		connect.PasswordFlag = true
+	}
+
+	connack, err := client.Connect(ctx, connect)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if connack.ReasonCode >= 0x80 {
+		conn.Close()
+		return errors.New("MQTT connection refused with reason code " + strconv.Itoa(int(connack.ReasonCode)))
+	}
+
+	// Resumed session already keeps the subscription
+	if !connack.SessionPresent {
+		_, err = client.Subscribe(ctx, &paho.Subscribe{
+			Subscriptions: []paho.SubscribeOptions{{Topic: c.options.filter, QoS: c.options.qos}},
+		})
+		if err != nil {
+			client.Disconnect(&paho.Disconnect{})
+			return err
+		}
+	}
+
+	c.client = client
+	return nil
+}
+
+func (c *mqttV5Client) publish(ctx context.Context, topic string, message *queues.MessageEnvelope) error {
+	properties := &paho.PublishProperties{}
+	properties.User.Add(propertyMessageId, message.MessageId)
+	properties.User.Add(propertyCorrelationId, message.CorrelationId)
+	properties.User.Add(propertyMessageType, message.MessageType)
+	properties.User.Add(propertySentTime, formatSentTime(message.SentTime))
+	if message.GroupId != "" {
+		properties.User.Add(propertyGroupId, message.GroupId)
+	}
+	for name, value := range message.Headers {
+		properties.User.Add(propertyHeaderPrefix+name, value)
+	}
+
+	_, err := c.client.Publish(ctx, &paho.Publish{
+		Topic:      topic,
+		QoS:        c.options.qos,
+		Payload:    message.Message,
+		Properties: properties,
+	})
+	return err
+}
+
+func (c *mqttV5Client) disconnect() error {
+	return c.client.Disconnect(&paho.Disconnect{})
+}
+
+func toMessage(packet *paho.Publish) *queues.MessageEnvelope {
+	message := queues.NewEmptyMessageEnvelope()
+	message.Message = packet.Payload
+	if packet.Properties == nil {
+		message.SentTime = time.Now()
+		return message
+	}
+
+	user := packet.Properties.User
+	message.MessageId = user.Get(propertyMessageId)
+	message.CorrelationId = user.Get(propertyCorrelationId)
+	message.MessageType = user.Get(propertyMessageType)
+	message.SentTime = parseSentTime(user.Get(propertySentTime))
+	message.GroupId = user.Get(propertyGroupId)
+	for _, property := range user {
+		if name := strings.TrimPrefix(property.Key, propertyHeaderPrefix); name != property.Key {
+			message.SetHeader(name, property.Value)
+		}
+	}
+	return message
+}
@@ -0,0 +1,652 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	gonats "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+NatsMessageQueue message queue that is implemented on top of NATS JetStream.
+
+Messages are published into a stream with work queue retention and received
+through a durable pull consumer with explicit acknowledgements.
+Complete acks a message, Abandon naks it for immediate redelivery and RenewLock
+sends an in-progress ack that restarts the ack wait timer.
+Messages moved to dead letter are published into a separate dead letter stream.
+
+Configuration parameters:
+
+  - name:                        name of the message queue
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - host:                      host name or IP address
+    - port:                      port number (default: 4222)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - stream:                    name of the JetStream stream (default: queue name)
+    - subject:                   subject to publish messages to (default: queue name)
+    - durable:                   name of the durable consumer (default: queue name)
+    - dead_letter:               subject of the dead letter stream (default: <subject>.dead)
+    - lock_timeout:              ack wait timeout in milliseconds to lock received messages (default: 30000)
+    - max_deliveries:            maximum number of deliveries of a message, -1 for unlimited (default: -1)
+    - timeout:                   timeout in milliseconds of JetStream operations (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See MessageQueue
+See MessagingCapabilities
+
+Example:
+
+    queue := NewNatsMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 4222,
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type NatsMessageQueue struct {
+	queues.MessageQueue
+	connection    *gonats.Conn
+	js            jetstream.JetStream
+	stream        jetstream.Stream
+	deadStream    jetstream.Stream
+	consumer      jetstream.Consumer
+	streamName    string
+	subject       string
+	durable       string
+	deadLetter    string
+	lockTimeout   time.Duration
+	maxDeliveries int
+	timeout       time.Duration
+	cancel        int32
+}
+
+// Names of message headers
+const (
+	headerCorrelationId = "Pip-Correlation-Id"
+	headerMessageType   = "Pip-Message-Type"
+	headerSentTime      = "Pip-Sent-Time"
+	headerGroupId       = "Pip-Group-Id"
+	// Message envelope headers are sent as NATS headers with this prefix
+	headerPrefix = "Pip-Header-"
+)
+
+// NewNatsMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *NatsMessageQueue
+// See MessagingCapabilities
+func NewNatsMessageQueue(name string) *NatsMessageQueue {
+	c := NatsMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.lockTimeout = 30000 * time.Millisecond
+	c.maxDeliveries = -1
+	c.timeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *NatsMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.streamName = config.GetAsStringWithDefault("options.stream", c.streamName)
+	c.subject = config.GetAsStringWithDefault("options.subject", c.subject)
+	c.durable = config.GetAsStringWithDefault("options.durable", c.durable)
+	c.deadLetter = config.GetAsStringWithDefault("options.dead_letter", c.deadLetter)
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+	c.maxDeliveries = config.GetAsIntegerWithDefault("options.max_deliveries", c.maxDeliveries)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *NatsMessageQueue) IsOpen() bool {
+	return c.connection != nil
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+// The stream, the dead letter stream and the durable consumer are created or updated when needed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *NatsMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.connection != nil {
+		return nil
+	}
+
+	uri, err := c.composeUri(correlationId, connections[0])
+	if err != nil {
+		return err
+	}
+
+	options := []gonats.Option{gonats.Name(c.Name()), gonats.Timeout(c.timeout)}
+	if credential != nil && credential.Username() != "" {
+		options = append(options, gonats.UserInfo(credential.Username(), credential.Password()))
+	}
+
+	connection, err := gonats.Connect(uri, options...)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to NATS server at "+uri).
+			WithCause(err)
+	}
+
+	err = c.openStreams(connection)
+	if err != nil {
+		connection.Close()
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to open JetStream stream "+c.getStreamName()).
+			WithCause(err)
+	}
+
+	c.connection = connection
+	atomic.StoreInt32(&c.cancel, 0)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), uri)
+
+	return nil
+}
+
+func (c *NatsMessageQueue) openStreams(connection *gonats.Conn) error {
+	js, err := jetstream.New(connection)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      c.getStreamName(),
+		Subjects:  []string{c.getSubject()},
+		Retention: jetstream.WorkQueuePolicy,
+	})
+	if err != nil {
+		return err
+	}
+
+	deadStream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     c.getStreamName() + "_dead",
+		Subjects: []string{c.getDeadLetter()},
+	})
+	if err != nil {
+		return err
+	}
+
+	consumer, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       c.getDurable(),
+		FilterSubject: c.getSubject(),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       c.lockTimeout,
+		MaxDeliver:    c.maxDeliveries,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.js = js
+	c.stream = stream
+	c.deadStream = deadStream
+	c.consumer = consumer
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *NatsMessageQueue) Close(correlationId string) error {
+	if c.connection == nil {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+
+	c.connection.Close()
+	c.connection = nil
+	c.js = nil
+	c.stream = nil
+	c.deadStream = nil
+	c.consumer = nil
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are purges all messages from the queue and its dead letter stream.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *NatsMessageQueue) Clear(correlationId string) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	err = c.stream.Purge(ctx)
+	if err == nil {
+		err = c.deadStream.Purge(ctx)
+	}
+
+	return c.wrapError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Messages delivered to consumers and waiting for acknowledgement are not counted.
+// Returns: number of messages or error.
+func (c *NatsMessageQueue) ReadMessageCount() (int64, error) {
+	err := c.CheckOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	info, err := c.consumer.Info(ctx)
+	if err != nil {
+		return 0, c.wrapError("", err)
+	}
+
+	return int64(info.NumPending), nil
+}
+
+// Send method are sends a message into the queue.
+// MessageId is passed in Nats-Msg-Id header, so JetStream drops duplicates within its deduplication window.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *NatsMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	envelope.SentTime = time.Now()
+	_, err = c.js.PublishMsg(ctx, fromMessage(c.getSubject(), envelope))
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *NatsMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// Only messages that were not yet delivered to the consumer are returned.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *NatsMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	consumerInfo, err := c.consumer.Info(ctx)
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+	streamInfo, err := c.stream.Info(ctx)
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	messages := []*queues.MessageEnvelope{}
+	seq := consumerInfo.Delivered.Stream + 1
+	if seq < streamInfo.State.FirstSeq {
+		seq = streamInfo.State.FirstSeq
+	}
+	for ; seq <= streamInfo.State.LastSeq && int64(len(messages)) < messageCount; seq++ {
+		msg, err := c.stream.GetMsg(ctx, seq)
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, c.wrapError(correlationId, err)
+		}
+		messages = append(messages, toMessage(msg.Header, msg.Data))
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *NatsMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch jetstream.MessageBatch
+	if waitTimeout > 0 {
+		batch, err = c.consumer.Fetch(1, jetstream.FetchMaxWait(waitTimeout))
+	} else {
+		batch, err = c.consumer.FetchNoWait(1)
+	}
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	var message *queues.MessageEnvelope
+	for msg := range batch.Messages() {
+		message = toMessage(msg.Headers(), msg.Data())
+		message.SetReference(msg)
+	}
+	if message == nil {
+		err = batch.Error()
+		if err != nil && atomic.LoadInt32(&c.cancel) == 0 && !errors.Is(err, gonats.ErrTimeout) {
+			return nil, c.wrapError(correlationId, err)
+		}
+		return nil, nil
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+	c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// An in-progress acknowledgement restarts the ack wait timer set by the lock_timeout option.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds (not used, JetStream restarts the configured ack wait).
+// Returns:  error or nil for success.
+func (c *NatsMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	msg, ok := message.GetReference().(jetstream.Msg)
+	if !ok {
+		return nil
+	}
+
+	err := msg.InProgress()
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// The acknowledgement waits for confirmation from the server.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *NatsMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(jetstream.Msg)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	err := msg.DoubleAck(ctx)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// The message is negatively acknowledged for immediate redelivery.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *NatsMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(jetstream.Msg)
+	if !ok {
+		return nil
+	}
+
+	err := msg.Nak()
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and publishes it to the dead letter stream.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *NatsMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(jetstream.Msg)
+	if !ok {
+		return nil
+	}
+
+	err := c.CheckOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err = c.js.PublishMsg(ctx, fromMessage(c.getDeadLetter(), message))
+	if err == nil {
+		err = msg.Term()
+	}
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *NatsMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *NatsMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads messages from the dead letter stream of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *NatsMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	info, err := c.deadStream.Info(ctx)
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	messages := []*queues.MessageEnvelope{}
+	if info.State.Msgs == 0 {
+		return messages, nil
+	}
+	for seq := info.State.FirstSeq; seq <= info.State.LastSeq; seq++ {
+		msg, err := c.deadStream.GetMsg(ctx, seq)
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, c.wrapError(correlationId, err)
+		}
+		messages = append(messages, toMessage(msg.Header, msg.Data))
+	}
+	return messages, nil
+}
+
+func (c *NatsMessageQueue) getStreamName() string {
+	if c.streamName != "" {
+		return c.streamName
+	}
+	return c.Name()
+}
+
+func (c *NatsMessageQueue) getSubject() string {
+	if c.subject != "" {
+		return c.subject
+	}
+	return c.Name()
+}
+
+func (c *NatsMessageQueue) getDurable() string {
+	if c.durable != "" {
+		return c.durable
+	}
+	return c.Name()
+}
+
+func (c *NatsMessageQueue) getDeadLetter() string {
+	if c.deadLetter != "" {
+		return c.deadLetter
+	}
+	return c.getSubject() + ".dead"
+}
+
+func (c *NatsMessageQueue) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *NatsMessageQueue) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to access JetStream stream "+c.getStreamName()).
+		WithCause(err)
+}
+
+// composeUri composes NATS server url from connection parameters.
+func (c *NatsMessageQueue) composeUri(correlationId string, connection *cconn.ConnectionParams) (string, error) {
+	if uri := connection.Uri(); uri != "" {
+		return uri, nil
+	}
+
+	host := connection.Host()
+	if host == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+	return "nats://" + host + ":" + strconv.Itoa(connection.PortWithDefault(4222)), nil
+}
+
+func fromMessage(subject string, message *queues.MessageEnvelope) *gonats.Msg {
+	msg := gonats.NewMsg(subject)
+	msg.Data = message.Message
+	if message.MessageId != "" {
+		msg.Header.Set(gonats.MsgIdHdr, message.MessageId)
+	}
+	msg.Header.Set(headerCorrelationId, message.CorrelationId)
+	msg.Header.Set(headerMessageType, message.MessageType)
+	msg.Header.Set(headerSentTime, strconv.FormatInt(message.SentTime.UnixMilli(), 10))
+	if message.GroupId != "" {
+		msg.Header.Set(headerGroupId, message.GroupId)
+	}
+	for name, value := range message.Headers {
+		msg.Header.Set(headerPrefix+name, value)
+	}
+	return msg
+}
+
+func toMessage(header gonats.Header, data []byte) *queues.MessageEnvelope {
+	message := queues.NewEmptyMessageEnvelope()
+	message.MessageId = header.Get(gonats.MsgIdHdr)
+	message.CorrelationId = header.Get(headerCorrelationId)
+	message.MessageType = header.Get(headerMessageType)
+	message.GroupId = header.Get(headerGroupId)
+	if millis, err := strconv.ParseInt(header.Get(headerSentTime), 10, 64); err == nil {
+		message.SentTime = time.UnixMilli(millis)
+	}
+	message.Message = data
+	for key, values := range header {
+		if name := strings.TrimPrefix(key, headerPrefix); name != key && len(values) > 0 {
+			message.SetHeader(name, values[0])
+		}
+	}
+	return message
+}
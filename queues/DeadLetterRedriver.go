@@ -0,0 +1,324 @@
+package queues
+
+import (
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+// RedriveResult data object with results of a redrive.
+// See: DeadLetterRedriver
+type RedriveResult struct {
+	// The number of messages sent to the target queue.
+	Redriven int64 `json:"redriven"`
+	// The number of messages left in the dead letter queue by the transform function.
+	Skipped int64 `json:"skipped"`
+}
+
+/*
+DeadLetterRedriver Moves messages from a dead letter queue back to a target queue,
+so they can be processed again after the cause of the failures is fixed.
+
+Redrive takes messages from dead letters of queues that implement IMessageQueueInspector.
+Separate dead letter queues are drained with RedriveQueue, which receives messages from them.
+Every message is sent to the target queue before it is removed from the source,
+so a failure in the middle of a redrive can lead to duplicates, but not to lost messages.
+Target queues that implement IMessageResender receive messages bypassing their deduplication,
+so redriven messages are not dropped as duplicates of their first delivery.
+
+Messages are selected by the filter described in MessageFilter.
+A transform function set by SetTransform can change messages before they are sent
+or leave them in the dead letter queue by returning nil.
+Redrive can be throttled, so a recovering consumer is not flooded with messages.
+
+Configuration parameters:
+
+  - options:
+    - messages_per_second:       maximum rate of redriven messages, 0 for no limit (default: 0)
+    - max_messages:              maximum number of messages redriven at once, 0 for no limit (default: 0)
+    - wait_timeout:              timeout in milliseconds to receive messages from dead letter queues (default: 1000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+
+See IMessageQueueInspector
+See IMessageResender
+See MessageFilter
+
+Example:
+
+    redriver := NewDeadLetterRedriver()
+    redriver.Configure(cconf.NewConfigParamsFromTuples(
+        "options.messages_per_second", 10,
+    ))
+    redriver.SetTransform(func(message *MessageEnvelope) (*MessageEnvelope, error) {
+        message.SetHeader("redriven", "true")
+        return message, nil
+    })
+
+    result, err := redriver.Redrive("123", queue, queue, cdata.NewFilterParamsFromTuples(
+        "message_type", "order",
+        "min_age", 60000,
+    ))
+*/
+type DeadLetterRedriver struct {
+	Logger      *clog.CompositeLogger
+	Counters    *ccount.CompositeCounters
+	maxMessages int64
+	waitTimeout time.Duration
+	limiter     *RateLimiter
+	transform   func(message *MessageEnvelope) (*MessageEnvelope, error)
+	lock        sync.Mutex
+}
+
+// NewDeadLetterRedriver method are creates a new instance of the redriver.
+// Returns: *DeadLetterRedriver
+func NewDeadLetterRedriver() *DeadLetterRedriver {
+	c := DeadLetterRedriver{
+		Logger:      clog.NewCompositeLogger(),
+		Counters:    ccount.NewCompositeCounters(),
+		waitTimeout: 1000 * time.Millisecond,
+		limiter:     NewRateLimiter(0, 1),
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *DeadLetterRedriver) Configure(config *cconf.ConfigParams) {
+	c.Logger.Configure(config)
+
+	c.limiter.SetRate(config.GetAsDoubleWithDefault("options.messages_per_second", c.limiter.Rate()), 1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.maxMessages = config.GetAsLongWithDefault("options.max_messages", c.maxMessages)
+	c.waitTimeout = time.Duration(config.GetAsLongWithDefault("options.wait_timeout", int64(c.waitTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *DeadLetterRedriver) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+}
+
+// SetTransform method are sets a function that changes messages before they are sent to the target queue.
+// When the function returns nil, the message is left in the dead letter queue.
+// When it returns an error, the redrive stops with the error.
+//   - transform     a transform function or nil to send messages as they are.
+func (c *DeadLetterRedriver) SetTransform(transform func(message *MessageEnvelope) (*MessageEnvelope, error)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.transform = transform
+}
+
+// SetMessagesPerSecond method are changes the maximum rate of redriven messages.
+// The rate can be changed while a redrive is running.
+//   - rate      maximum number of messages per second, 0 for no limit.
+func (c *DeadLetterRedriver) SetMessagesPerSecond(rate float64) {
+	c.limiter.SetRate(rate, 1)
+}
+
+// GetMessagesPerSecond method are gets the maximum rate of redriven messages.
+// Returns: maximum number of messages per second, 0 for no limit.
+func (c *DeadLetterRedriver) GetMessagesPerSecond() float64 {
+	return c.limiter.Rate()
+}
+
+// Redrive method are moves messages that match the filter from dead letters of the source queue to the target queue.
+// The source queue must implement IMessageQueueInspector, other queues keep dead letters on their own.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - source            a queue with dead letters.
+//   - target            a queue to send messages to.
+//   - filter            (optional) a filter to select messages.
+// Returns: redrive results or error.
+// See MessageFilter
+// See RedriveQueue
+func (c *DeadLetterRedriver) Redrive(correlationId string, source IMessageQueue, target IMessageQueue,
+	filter *cdata.FilterParams) (*RedriveResult, error) {
+	inspector, ok := source.(IMessageQueueInspector)
+	if !ok {
+		return &RedriveResult{}, cerr.NewBadRequestError(correlationId, "NOT_INSPECTED",
+			"Queue "+source.Name()+" cannot be inspected, use RedriveQueue to redrive a dead letter queue").
+			WithDetails("queue", source.Name())
+	}
+
+	result, err := c.redriveDeadLetters(correlationId, inspector, target, filter)
+	c.trace(correlationId, source, target, result)
+	return result, err
+}
+
+// RedriveQueue method are moves messages that match the filter from a dead letter queue to the target queue.
+// All messages of the dead letter queue are received from it, so it shall not be a live queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - deadLetterQueue   a dead letter queue to receive messages from.
+//   - target            a queue to send messages to.
+//   - filter            (optional) a filter to select messages.
+// Returns: redrive results or error.
+// See MessageFilter
+// See Redrive
+func (c *DeadLetterRedriver) RedriveQueue(correlationId string, deadLetterQueue IMessageQueue, target IMessageQueue,
+	filter *cdata.FilterParams) (*RedriveResult, error) {
+	result, err := c.redriveQueue(correlationId, deadLetterQueue, target, filter)
+	c.trace(correlationId, deadLetterQueue, target, result)
+	return result, err
+}
+
+// trace counts and logs redriven messages.
+func (c *DeadLetterRedriver) trace(correlationId string, source IMessageQueue, target IMessageQueue, result *RedriveResult) {
+	if result.Redriven > 0 {
+		c.Counters.Increment("queue."+target.Name()+".redriven_messages", int(result.Redriven))
+	}
+	c.Logger.Info(correlationId, "Redrove %d messages from %s to %s", result.Redriven, source.Name(), target.Name())
+}
+
+// redriveDeadLetters takes messages from dead letters of a queue that can be inspected.
+func (c *DeadLetterRedriver) redriveDeadLetters(correlationId string, source IMessageQueueInspector,
+	target IMessageQueue, filter *cdata.FilterParams) (*RedriveResult, error) {
+	result := &RedriveResult{}
+
+	deadFilter := cdata.NewEmptyFilterParams()
+	if filter != nil {
+		for key, value := range filter.Value() {
+			deadFilter.Put(key, value)
+		}
+	}
+	deadFilter.Put("dead_letter", true)
+
+	for {
+		page, err := source.BrowseMessages(correlationId, deadFilter, cdata.NewPagingParams(result.Skipped, 100, false))
+		if err != nil || len(page.Data) == 0 {
+			return result, err
+		}
+
+		for _, message := range page.Data {
+			if c.limitReached(result) {
+				return result, nil
+			}
+
+			sent, err := c.send(correlationId, message, target)
+			if err != nil {
+				return result, err
+			}
+			if !sent {
+				result.Skipped++
+				continue
+			}
+
+			// Only the redriven entry is removed, dead letters with the same id stay
+			removed, err := source.RemoveMessage(correlationId, message, true)
+			if err != nil {
+				return result, err
+			}
+			if !removed {
+				c.Logger.Warn(correlationId, "Redriven message %s was already removed from dead letters", message.String())
+			}
+			result.Redriven++
+		}
+	}
+}
+
+// redriveQueue receives messages from a dead letter queue.
+// Messages that do not match the filter are abandoned, the redrive stops
+// when the queue is empty or messages start to repeat.
+func (c *DeadLetterRedriver) redriveQueue(correlationId string, source IMessageQueue,
+	target IMessageQueue, filter *cdata.FilterParams) (*RedriveResult, error) {
+	result := &RedriveResult{}
+	messageFilter := NewMessageFilter(filter)
+
+	c.lock.Lock()
+	waitTimeout := c.waitTimeout
+	c.lock.Unlock()
+
+	abandoned := map[string]bool{}
+	for !c.limitReached(result) {
+		message, err := source.Receive(correlationId, waitTimeout)
+		if err != nil || message == nil {
+			return result, err
+		}
+		if abandoned[message.MessageId] {
+			return result, source.Abandon(message)
+		}
+
+		matched := messageFilter.Match(message)
+		sent := false
+		if matched {
+			sent, err = c.send(correlationId, message, target)
+			if err != nil {
+				source.Abandon(message)
+				return result, err
+			}
+		}
+		if !sent {
+			if matched {
+				result.Skipped++
+			}
+			abandoned[message.MessageId] = true
+			if err = source.Abandon(message); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		if err = source.Complete(message); err != nil {
+			return result, err
+		}
+		result.Redriven++
+	}
+	return result, nil
+}
+
+func (c *DeadLetterRedriver) limitReached(result *RedriveResult) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.maxMessages > 0 && result.Redriven >= c.maxMessages
+}
+
+// send transforms the message and sends it to the target queue when the rate limiter allows.
+// Returns false when the transform function decided to skip the message.
+func (c *DeadLetterRedriver) send(correlationId string, message *MessageEnvelope, target IMessageQueue) (bool, error) {
+	c.lock.Lock()
+	transform := c.transform
+	c.lock.Unlock()
+
+	// The source message keeps its lock reference, so the copy is sent
+	envelope := *message
+	envelope.SetReference(nil)
+	if envelope.Headers != nil {
+		headers := map[string]string{}
+		for name, value := range envelope.Headers {
+			headers[name] = value
+		}
+		envelope.Headers = headers
+	}
+
+	output := &envelope
+	if transform != nil {
+		var err error
+		output, err = transform(output)
+		if err != nil || output == nil {
+			return false, err
+		}
+	}
+
+	c.limiter.Wait(nil)
+	var err error
+	if resender, ok := target.(IMessageResender); ok {
+		err = resender.Resend(correlationId, output)
+	} else {
+		err = target.Send(correlationId, output)
+	}
+	return err == nil, err
+}
@@ -0,0 +1,1096 @@
+package queues
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// Synchronization modes of the FileMessageQueue log.
+const (
+	// FileSyncAlways flushes the log to disk after every write.
+	FileSyncAlways = "always"
+	// FileSyncInterval flushes the log to disk periodically.
+	FileSyncInterval = "interval"
+	// FileSyncNone leaves flushing to the operating system.
+	FileSyncNone = "none"
+)
+
+/*
+FileMessageQueue message queue that persists messages and their locks in an append-only log file.
+On open the log is replayed to restore the queue state, so messages survive process restarts.
+The log is periodically compacted to drop records of completed messages.
+Messages moved to dead letter are kept in the log, so they can be inspected
+and removed through IMessageQueueInspector interface.
+This queue is typically used in small edge deployments that cannot run an external message broker.
+
+Configuration parameters:
+
+  - name:                        name of the message queue
+  - path:                        path to the log file where messages are stored
+  - options:
+    - lock_timeout:              timeout in milliseconds to lock received messages (default: 30000)
+    - sync:                      log synchronization mode: always, interval or none (default: interval)
+    - sync_interval:             interval in milliseconds to flush the log in interval mode (default: 1000)
+    - compact_interval:          interval in milliseconds to check the log for compaction (default: 60000)
+    - compact_threshold:         number of obsolete records that triggers compaction (default: 1000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+
+See MessageQueue
+See MessagingCapabilities
+See IMessageQueueInspector
+
+Example:
+
+    queue := NewFileMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "path", "./data/myqueue.log",
+    ))
+    queue.Open("123")
+
+    queue.Send("123", NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type FileMessageQueue struct {
+	MessageQueue
+	path             string
+	lockTimeout      time.Duration
+	syncMode         string
+	syncInterval     time.Duration
+	compactInterval  time.Duration
+	compactThreshold int64
+
+	file              *os.File
+	entries           map[int64]*fileQueueEntry
+	pending           []*fileQueueEntry
+	lockedMessages    map[int64]*fileQueueEntry
+	deadMessages      []*fileQueueEntry
+	sequence          int64
+	lockTokenSequence int64
+	records           int64
+	dirty             bool
+	stop              chan struct{}
+	opened            bool
+	cancel            int32
+}
+
+// fileQueueEntry keeps a stored message together with its lock.
+type fileQueueEntry struct {
+	seq            int64
+	position       int64
+	message        *MessageEnvelope
+	token          int64
+	expirationTime time.Time
+}
+
+// fileQueueRecord is a single record in the queue log.
+type fileQueueRecord struct {
+	Op         string           `json:"op"`
+	Seq        int64            `json:"seq,omitempty"`
+	Token      int64            `json:"token,omitempty"`
+	Expiration int64            `json:"exp,omitempty"`
+	Message    *MessageEnvelope `json:"msg,omitempty"`
+}
+
+const (
+	fileOpSend     = "send"
+	fileOpLock     = "lock"
+	fileOpRenew    = "renew"
+	fileOpAbandon  = "abandon"
+	fileOpComplete = "complete"
+	fileOpDead     = "dead"
+	fileOpRemove   = "remove"
+	fileOpClear    = "clear"
+)
+
+// NewFileMessageQueue method are creates a new instance of the file message queue.
+//   - name  (optional) a queue name.
+// Returns: *FileMessageQueue
+// See MessagingCapabilities
+func NewFileMessageQueue(name string) *FileMessageQueue {
+	c := FileMessageQueue{}
+
+	c.MessageQueue = *InheritMessageQueue(
+		&c, name, NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.lockTimeout = 30000 * time.Millisecond
+	c.syncMode = FileSyncInterval
+	c.syncInterval = 1000 * time.Millisecond
+	c.compactInterval = 60000 * time.Millisecond
+	c.compactThreshold = 1000
+	c.resetState()
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *FileMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.path = config.GetAsStringWithDefault("path", c.path)
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+	c.syncMode = config.GetAsStringWithDefault("options.sync", c.syncMode)
+	c.syncInterval = time.Duration(config.GetAsLongWithDefault("options.sync_interval", int64(c.syncInterval/time.Millisecond))) * time.Millisecond
+	c.compactInterval = time.Duration(config.GetAsLongWithDefault("options.compact_interval", int64(c.compactInterval/time.Millisecond))) * time.Millisecond
+	c.compactThreshold = config.GetAsLongWithDefault("options.compact_threshold", c.compactThreshold)
+}
+
+// IsOpen method are checks if the component is opened.
+// Return true if the component has been opened and false otherwise.
+func (c *FileMessageQueue) IsOpen() bool {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	return c.opened
+}
+
+// Open method are opens the component, replays the log and restores the queue state.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Retruns: error or nil no errors occured.
+func (c *FileMessageQueue) Open(correlationId string) (err error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	if c.opened {
+		return nil
+	}
+
+	if c.path == "" {
+		return cerr.NewConfigError(correlationId, "NO_PATH", "Path to the queue log is not set")
+	}
+	switch c.syncMode {
+	case FileSyncAlways, FileSyncInterval, FileSyncNone:
+	default:
+		return cerr.NewConfigError(correlationId, "WRONG_SYNC_MODE", "Unsupported log sync mode "+c.syncMode).
+			WithDetails("sync", c.syncMode)
+	}
+
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return cerr.NewFileError(correlationId, "CANNOT_CREATE_DIR", "Failed to create directory for queue log "+c.path).
+				WithCause(err)
+		}
+	}
+
+	file, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_OPEN_FILE", "Failed to open queue log "+c.path).
+			WithCause(err)
+	}
+
+	c.resetState()
+	if err = c.replay(correlationId, file); err != nil {
+		file.Close()
+		return err
+	}
+	c.file = file
+
+	if c.records-c.liveRecords() >= c.compactThreshold {
+		if err = c.compact(correlationId); err != nil {
+			c.file.Close()
+			c.file = nil
+			return err
+		}
+	}
+
+	c.stop = make(chan struct{})
+	go c.maintain(c.stop)
+
+	c.opened = true
+	atomic.StoreInt32(&c.cancel, 0)
+
+	c.Logger.Debug(correlationId, "Opened queue %s with %d messages from %s", c.Name(), len(c.entries), c.path)
+
+	return nil
+}
+
+// Close method are closes component, flushes the log and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *FileMessageQueue) Close(correlationId string) (err error) {
+	atomic.StoreInt32(&c.cancel, 1)
+
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	if !c.opened {
+		return nil
+	}
+
+	close(c.stop)
+	c.opened = false
+
+	err = c.file.Sync()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	c.file = nil
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_CLOSE_FILE", "Failed to close queue log "+c.path).
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are clears component state and truncates the log.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *FileMessageQueue) Clear(correlationId string) (err error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	atomic.StoreInt32(&c.cancel, 0)
+
+	if !c.opened {
+		c.resetState()
+		return nil
+	}
+
+	if err = c.write(correlationId, &fileQueueRecord{Op: fileOpClear}); err != nil {
+		return err
+	}
+	c.resetState()
+
+	return c.compact(correlationId)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *FileMessageQueue) ReadMessageCount() (count int64, err error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	c.releaseExpiredLocks(time.Now())
+
+	return int64(len(c.pending)), nil
+}
+
+// Send method are sends a message into the queue and writes it to the log.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *FileMessageQueue) Send(correlationId string, envelope *MessageEnvelope) (err error) {
+	err = c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	envelope.SentTime = time.Now()
+	message := *envelope
+	message.SetReference(nil)
+
+	c.Lock.Lock()
+	c.sequence++
+	entry := &fileQueueEntry{seq: c.sequence, message: &message}
+	err = c.write(correlationId, &fileQueueRecord{Op: fileOpSend, Seq: entry.seq, Message: entry.message})
+	if err == nil {
+		c.entries[entry.seq] = entry
+		c.pending = append(c.pending, entry)
+	}
+	c.Lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *FileMessageQueue) Peek(correlationId string) (result *MessageEnvelope, err error) {
+	err = c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var message *MessageEnvelope
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	if len(c.pending) > 0 {
+		envelope := *c.pending[0].message
+		message = &envelope
+	}
+	c.Lock.Unlock()
+
+	if message != nil {
+		c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+	}
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *FileMessageQueue) PeekBatch(correlationId string, messageCount int64) (result []*MessageEnvelope, err error) {
+	err = c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []*MessageEnvelope{}
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	for _, entry := range c.pending {
+		if int64(len(messages)) >= messageCount {
+			break
+		}
+		envelope := *entry.message
+		messages = append(messages, &envelope)
+	}
+	c.Lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message, locks it and records the lock in the log.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *FileMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var message *MessageEnvelope
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		c.Lock.Lock()
+		now := time.Now()
+		c.releaseExpiredLocks(now)
+		if len(c.pending) > 0 {
+			entry := c.pending[0]
+
+			c.lockTokenSequence++
+			token := c.lockTokenSequence
+			expirationTime := now.Add(c.lockTimeout)
+
+			err = c.write(correlationId, &fileQueueRecord{
+				Op: fileOpLock, Seq: entry.seq, Token: token, Expiration: expirationTime.UnixNano(),
+			})
+			if err == nil {
+				c.pending = c.pending[1:]
+				entry.token = token
+				entry.expirationTime = expirationTime
+				c.lockedMessages[token] = entry
+
+				envelope := *entry.message
+				envelope.SetReference(token)
+				message = &envelope
+			}
+		}
+		c.Lock.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+		if message != nil || !time.Now().Before(deadline) || atomic.LoadInt32(&c.cancel) != 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if message != nil {
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	}
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// This method is usually used to extend the message processing time.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *FileMessageQueue) RenewLock(message *MessageEnvelope, lockTimeout time.Duration) (err error) {
+	token, ok := message.GetReference().(int64)
+	if !ok {
+		return nil
+	}
+
+	if lockTimeout <= 0 {
+		lockTimeout = c.lockTimeout
+	}
+
+	c.Lock.Lock()
+	now := time.Now()
+	c.releaseExpiredLocks(now)
+	entry, ok := c.lockedMessages[token]
+	if ok {
+		expirationTime := now.Add(lockTimeout)
+		err = c.write(message.CorrelationId, &fileQueueRecord{
+			Op: fileOpRenew, Token: token, Expiration: expirationTime.UnixNano(),
+		})
+		if err == nil {
+			entry.expirationTime = expirationTime
+		}
+	}
+	c.Lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *FileMessageQueue) Complete(message *MessageEnvelope) (err error) {
+	err = c.removeLocked(message, fileOpComplete)
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *FileMessageQueue) Abandon(message *MessageEnvelope) (err error) {
+	token, ok := message.GetReference().(int64)
+	if !ok {
+		return nil
+	}
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	entry, ok := c.lockedMessages[token]
+	if ok {
+		err = c.write(message.CorrelationId, &fileQueueRecord{Op: fileOpAbandon, Token: token})
+		if err == nil {
+			delete(c.lockedMessages, token)
+			entry.token = 0
+			c.pending = append(c.pending, entry)
+			message.SetReference(nil)
+		}
+	}
+	c.Lock.Unlock()
+
+	if err != nil || !ok {
+		return err
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *FileMessageQueue) MoveToDeadLetter(message *MessageEnvelope) (err error) {
+	token, ok := message.GetReference().(int64)
+	if !ok {
+		return nil
+	}
+
+	c.Lock.Lock()
+	entry, ok := c.lockedMessages[token]
+	if ok {
+		err = c.write(message.CorrelationId, &fileQueueRecord{Op: fileOpDead, Token: token})
+		if err == nil {
+			delete(c.lockedMessages, token)
+			delete(c.entries, entry.seq)
+			entry.token = 0
+			c.deadMessages = append(c.deadMessages, entry)
+		}
+	}
+	if err == nil {
+		message.SetReference(nil)
+	}
+	c.Lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// ReadStats method are reads the current state of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: the queue statistics or error.
+func (c *FileMessageQueue) ReadStats(correlationId string) (*MessageQueueStats, error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	c.releaseExpiredLocks(time.Now())
+
+	stats := &MessageQueueStats{
+		Name:            c.Name(),
+		MessageCount:    int64(len(c.pending)),
+		LockedCount:     int64(len(c.lockedMessages)),
+		DeadLetterCount: int64(len(c.deadMessages)),
+	}
+	for _, entry := range c.pending {
+		if age := time.Since(entry.message.SentTime); age > stats.OldestMessageAge {
+			stats.OldestMessageAge = age
+		}
+	}
+	return stats, nil
+}
+
+// BrowseMessages method are gets a page of messages that match the filter without removing them.
+// When paging does not set the number of messages to take, up to 100 messages are returned.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter to select messages.
+//   - paging            (optional) paging parameters.
+// Returns: a page with messages or error.
+// See MessageFilter
+func (c *FileMessageQueue) BrowseMessages(correlationId string, filter *cdata.FilterParams,
+	paging *cdata.PagingParams) (*MessageEnvelopePage, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	messageFilter := NewMessageFilter(filter)
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	entries := c.pending
+	if messageFilter.DeadLetter {
+		entries = c.deadMessages
+	}
+	messages := make([]*MessageEnvelope, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.message
+	}
+	page := filterMessagePage(messages, messageFilter, paging)
+	c.Lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Browsed %d messages on %s", len(page.Data), c.Name())
+
+	return page, nil
+}
+
+// RemoveMessages method are removes messages that match the filter from the queue
+// and records their removal in the log.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter to select messages.
+// Returns: a list with removed messages or error.
+// See MessageFilter
+func (c *FileMessageQueue) RemoveMessages(correlationId string, filter *cdata.FilterParams) ([]*MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	messageFilter := NewMessageFilter(filter)
+	removed := []*MessageEnvelope{}
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	entries := c.pending
+	if messageFilter.DeadLetter {
+		entries = c.deadMessages
+	}
+
+	remaining := make([]*fileQueueEntry, 0, len(entries))
+	for index, entry := range entries {
+		if err != nil || !messageFilter.Match(entry.message) {
+			remaining = append(remaining, entry)
+			continue
+		}
+		err = c.write(correlationId, &fileQueueRecord{Op: fileOpRemove, Seq: entry.seq})
+		if err != nil {
+			remaining = append(remaining, entries[index:]...)
+			break
+		}
+		delete(c.entries, entry.seq)
+		message := *entry.message
+		removed = append(removed, &message)
+	}
+
+	if messageFilter.DeadLetter {
+		c.deadMessages = remaining
+	} else {
+		c.pending = remaining
+	}
+	c.Lock.Unlock()
+
+	if err != nil {
+		return removed, err
+	}
+
+	c.Logger.Debug(correlationId, "Removed %d messages from %s", len(removed), c.Name())
+
+	return removed, nil
+}
+
+// RemoveMessage method are removes a single message returned by BrowseMessages
+// and records its removal in the log. Other messages with the same id are left in the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - message           a browsed message to be removed.
+//   - deadLetter        true to remove the message from the dead letter queue.
+// Returns: true if the message was removed, false if it is no longer in the queue, or error.
+func (c *FileMessageQueue) RemoveMessage(correlationId string, message *MessageEnvelope, deadLetter bool) (bool, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return false, err
+	}
+
+	c.Lock.Lock()
+	c.releaseExpiredLocks(time.Now())
+	entries := c.pending
+	if deadLetter {
+		entries = c.deadMessages
+	}
+
+	index := -1
+	for i, entry := range entries {
+		if sameMessage(entry.message, message) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		c.Lock.Unlock()
+		return false, nil
+	}
+
+	entry := entries[index]
+	err = c.write(correlationId, &fileQueueRecord{Op: fileOpRemove, Seq: entry.seq})
+	if err != nil {
+		c.Lock.Unlock()
+		return false, err
+	}
+	delete(c.entries, entry.seq)
+
+	remaining := make([]*fileQueueEntry, 0, len(entries)-1)
+	remaining = append(remaining, entries[:index]...)
+	remaining = append(remaining, entries[index+1:]...)
+	if deadLetter {
+		c.deadMessages = remaining
+	} else {
+		c.pending = remaining
+	}
+	c.Lock.Unlock()
+
+	c.Logger.Debug(correlationId, "Removed message %s from %s", message.String(), c.Name())
+
+	return true, nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *FileMessageQueue) Listen(correlationId string, receiver IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *FileMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// Compact method are rewrites the log keeping only records of messages that are still in the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: error or nil for success.
+func (c *FileMessageQueue) Compact(correlationId string) error {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	if !c.opened {
+		return nil
+	}
+
+	return c.compact(correlationId)
+}
+
+func (c *FileMessageQueue) resetState() {
+	c.entries = make(map[int64]*fileQueueEntry)
+	c.pending = make([]*fileQueueEntry, 0)
+	c.lockedMessages = make(map[int64]*fileQueueEntry)
+	c.deadMessages = make([]*fileQueueEntry, 0)
+	c.records = 0
+	c.dirty = false
+}
+
+// liveRecords returns the number of records a compacted log would contain.
+func (c *FileMessageQueue) liveRecords() int64 {
+	return int64(len(c.entries) + len(c.lockedMessages) + 2*len(c.deadMessages))
+}
+
+// removeLocked removes a locked message from the queue and records the operation in the log.
+func (c *FileMessageQueue) removeLocked(message *MessageEnvelope, op string) (err error) {
+	token, ok := message.GetReference().(int64)
+	if !ok {
+		return nil
+	}
+
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
+
+	entry, ok := c.lockedMessages[token]
+	if !ok {
+		message.SetReference(nil)
+		return nil
+	}
+
+	err = c.write(message.CorrelationId, &fileQueueRecord{Op: op, Token: token})
+	if err != nil {
+		return err
+	}
+
+	delete(c.lockedMessages, token)
+	delete(c.entries, entry.seq)
+	message.SetReference(nil)
+
+	return nil
+}
+
+// releaseExpiredLocks returns messages with expired locks back into the queue.
+// The lock records carry expiration times, so releases are restored on replay without extra records.
+func (c *FileMessageQueue) releaseExpiredLocks(now time.Time) {
+	if len(c.lockedMessages) == 0 {
+		return
+	}
+
+	expired := make([]*fileQueueEntry, 0)
+	for token, entry := range c.lockedMessages {
+		if !entry.expirationTime.After(now) {
+			delete(c.lockedMessages, token)
+			entry.token = 0
+			expired = append(expired, entry)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].seq < expired[j].seq })
+	c.pending = append(c.pending, expired...)
+}
+
+// write appends a record to the log and syncs it according to the sync mode.
+func (c *FileMessageQueue) write(correlationId string, record *fileQueueRecord) error {
+	if c.file == nil {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The queue is not opened")
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return cerr.NewInternalError(correlationId, "CANNOT_SERIALIZE", "Failed to serialize queue log record").
+			WithCause(err)
+	}
+	data = append(data, '\n')
+
+	if _, err = c.file.Write(data); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_WRITE_FILE", "Failed to write queue log "+c.path).
+			WithCause(err)
+	}
+	c.records++
+
+	if c.syncMode == FileSyncAlways {
+		if err = c.file.Sync(); err != nil {
+			return cerr.NewFileError(correlationId, "CANNOT_SYNC_FILE", "Failed to sync queue log "+c.path).
+				WithCause(err)
+		}
+	} else {
+		c.dirty = true
+	}
+
+	return nil
+}
+
+// replay restores the queue state from the log.
+// A torn record at the end of the log left by a crash is truncated.
+func (c *FileMessageQueue) replay(correlationId string, file *os.File) error {
+	var position int64
+	var offset int64
+	locked := make(map[int64]*fileQueueEntry)
+	dead := make(map[int64]*fileQueueEntry)
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				c.Logger.Warn(correlationId, "Truncated incomplete record at the end of queue log %s", c.path)
+			}
+			break
+		}
+		if err != nil {
+			return cerr.NewFileError(correlationId, "CANNOT_READ_FILE", "Failed to read queue log "+c.path).
+				WithCause(err)
+		}
+
+		record := fileQueueRecord{}
+		if err = json.Unmarshal(line, &record); err != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				c.Logger.Warn(correlationId, "Truncated corrupted record at the end of queue log %s", c.path)
+				break
+			}
+			return cerr.NewFileError(correlationId, "CORRUPTED_FILE", "Queue log "+c.path+" is corrupted").
+				WithDetails("offset", offset).WithCause(err)
+		}
+		offset += int64(len(line))
+		c.records++
+
+		switch record.Op {
+		case fileOpSend:
+			if record.Message == nil {
+				continue
+			}
+			position++
+			c.entries[record.Seq] = &fileQueueEntry{seq: record.Seq, position: position, message: record.Message}
+			if record.Seq > c.sequence {
+				c.sequence = record.Seq
+			}
+		case fileOpLock:
+			entry, ok := c.entries[record.Seq]
+			if !ok {
+				continue
+			}
+			if entry.token != 0 {
+				delete(locked, entry.token)
+			}
+			entry.token = record.Token
+			entry.expirationTime = time.Unix(0, record.Expiration)
+			locked[record.Token] = entry
+			if record.Token > c.lockTokenSequence {
+				c.lockTokenSequence = record.Token
+			}
+		case fileOpRenew:
+			if entry, ok := locked[record.Token]; ok {
+				entry.expirationTime = time.Unix(0, record.Expiration)
+			}
+		case fileOpAbandon:
+			if entry, ok := locked[record.Token]; ok {
+				delete(locked, record.Token)
+				entry.token = 0
+				position++
+				entry.position = position
+			}
+		case fileOpComplete:
+			if entry, ok := locked[record.Token]; ok {
+				delete(locked, record.Token)
+				delete(c.entries, entry.seq)
+			}
+		case fileOpDead:
+			// Compacted logs refer dead messages by their sequence numbers
+			entry, ok := locked[record.Token]
+			if record.Token == 0 {
+				entry, ok = c.entries[record.Seq]
+			}
+			if ok && entry.token == record.Token {
+				delete(locked, record.Token)
+				delete(c.entries, entry.seq)
+				entry.token = 0
+				position++
+				entry.position = position
+				dead[entry.seq] = entry
+			}
+		case fileOpRemove:
+			// Removed messages may still have expired locks in the log
+			if entry, ok := c.entries[record.Seq]; ok {
+				if entry.token != 0 {
+					delete(locked, entry.token)
+				}
+				delete(c.entries, record.Seq)
+			}
+			delete(dead, record.Seq)
+		case fileOpClear:
+			c.entries = make(map[int64]*fileQueueEntry)
+			locked = make(map[int64]*fileQueueEntry)
+			dead = make(map[int64]*fileQueueEntry)
+		}
+	}
+
+	if err := file.Truncate(offset); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_WRITE_FILE", "Failed to truncate queue log "+c.path).
+			WithCause(err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_READ_FILE", "Failed to seek queue log "+c.path).
+			WithCause(err)
+	}
+
+	now := time.Now()
+	pending := make([]*fileQueueEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if entry.token != 0 && entry.expirationTime.After(now) {
+			c.lockedMessages[entry.token] = entry
+		} else {
+			entry.token = 0
+			pending = append(pending, entry)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].position < pending[j].position })
+	c.pending = pending
+
+	for _, entry := range dead {
+		c.deadMessages = append(c.deadMessages, entry)
+	}
+	sort.Slice(c.deadMessages, func(i, j int) bool { return c.deadMessages[i].position < c.deadMessages[j].position })
+
+	return nil
+}
+
+// compact rewrites the log into a temporary file with the current state and replaces the original log.
+func (c *FileMessageQueue) compact(correlationId string) error {
+	tempPath := c.path + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_OPEN_FILE", "Failed to create compacted queue log "+tempPath).
+			WithCause(err)
+	}
+
+	// Keep the original order: pending messages first, then locked ones
+	entries := make([]*fileQueueEntry, 0, len(c.entries))
+	entries = append(entries, c.pending...)
+	locked := make([]*fileQueueEntry, 0, len(c.lockedMessages))
+	for _, entry := range c.lockedMessages {
+		locked = append(locked, entry)
+	}
+	sort.Slice(locked, func(i, j int) bool { return locked[i].seq < locked[j].seq })
+	entries = append(entries, locked...)
+
+	// Dead messages go last and are marked by their sequence numbers
+	entries = append(entries, c.deadMessages...)
+	dead := len(entries) - len(c.deadMessages)
+
+	writer := bufio.NewWriter(file)
+	records := int64(0)
+	for index, entry := range entries {
+		batch := []*fileQueueRecord{{Op: fileOpSend, Seq: entry.seq, Message: entry.message}}
+		if entry.token != 0 {
+			batch = append(batch, &fileQueueRecord{
+				Op: fileOpLock, Seq: entry.seq, Token: entry.token, Expiration: entry.expirationTime.UnixNano(),
+			})
+		}
+		if index >= dead {
+			batch = append(batch, &fileQueueRecord{Op: fileOpDead, Seq: entry.seq})
+		}
+		for _, record := range batch {
+			data, _ := json.Marshal(record)
+			writer.Write(data)
+			writer.WriteByte('\n')
+			records++
+		}
+	}
+
+	err = writer.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tempPath, c.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return cerr.NewFileError(correlationId, "CANNOT_WRITE_FILE", "Failed to compact queue log "+c.path).
+			WithCause(err)
+	}
+
+	if dir, err := os.Open(filepath.Dir(c.path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	if c.file != nil {
+		c.file.Close()
+	}
+	c.file = file
+	c.records = records
+	c.dirty = false
+
+	c.Logger.Trace(correlationId, "Compacted queue log %s to %d records", c.path, records)
+
+	return nil
+}
+
+// maintain periodically syncs and compacts the log until the queue is closed.
+func (c *FileMessageQueue) maintain(stop chan struct{}) {
+	var syncTicker <-chan time.Time
+	if c.syncMode == FileSyncInterval && c.syncInterval > 0 {
+		ticker := time.NewTicker(c.syncInterval)
+		defer ticker.Stop()
+		syncTicker = ticker.C
+	}
+
+	var compactTicker <-chan time.Time
+	if c.compactInterval > 0 {
+		ticker := time.NewTicker(c.compactInterval)
+		defer ticker.Stop()
+		compactTicker = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-syncTicker:
+			c.Lock.Lock()
+			if c.opened && c.dirty {
+				if err := c.file.Sync(); err != nil {
+					c.Logger.Error("", err, "Failed to sync queue log %s", c.path)
+				} else {
+					c.dirty = false
+				}
+			}
+			c.Lock.Unlock()
+		case <-compactTicker:
+			c.Lock.Lock()
+			if c.opened && c.records-c.liveRecords() >= c.compactThreshold {
+				if err := c.compact(""); err != nil {
+					c.Logger.Error("", err, "Failed to compact queue log %s", c.path)
+				}
+			}
+			c.Lock.Unlock()
+		}
+	}
+}
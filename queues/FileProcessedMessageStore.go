@@ -0,0 +1,268 @@
+package queues
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+/*
+FileProcessedMessageStore Store that keeps ids of processed messages in a file,
+so they are remembered after process restarts.
+Every processed message is appended to the file as a line with its expiration time and id.
+On open the file is read and rewritten without expired ids.
+Claims of messages that are being processed are kept in memory,
+so they protect only from consumers in the same process.
+
+Configuration parameters:
+
+  - path:                        path to the file where processed messages are stored
+  - options:
+    - ttl:                       time in milliseconds to remember processed messages (default: 86400000)
+    - claim_timeout:             time in milliseconds to keep claims of messages that are being processed (default: 60000)
+
+See IProcessedMessageStore
+See IdempotentMessageReceiver
+
+Example:
+
+    store := NewFileProcessedMessageStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "path", "./data/processed.log",
+    ))
+    store.Open("123")
+
+    receiver := NewIdempotentMessageReceiver(NewMyMessageReceiver(), store)
+    queue.BeginListen("123", receiver)
+*/
+type FileProcessedMessageStore struct {
+	path      string
+	ttl       time.Duration
+	processed *MemoryProcessedMessageStore
+	file      *os.File
+	lock      sync.Mutex
+}
+
+// NewFileProcessedMessageStore method are creates a new instance of the store.
+// Returns: *FileProcessedMessageStore
+func NewFileProcessedMessageStore() *FileProcessedMessageStore {
+	c := FileProcessedMessageStore{
+		ttl:       24 * time.Hour,
+		processed: NewMemoryProcessedMessageStore(),
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *FileProcessedMessageStore) Configure(config *cconf.ConfigParams) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.path = config.GetAsStringWithDefault("path", c.path)
+	c.ttl = time.Duration(config.GetAsLongWithDefault("options.ttl", int64(c.ttl/time.Millisecond))) * time.Millisecond
+	c.processed.Configure(config)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *FileProcessedMessageStore) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.file != nil
+}
+
+// Open method are reads processed messages from the file and opens it to append new ones.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *FileProcessedMessageStore) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.file != nil {
+		return nil
+	}
+	if c.path == "" {
+		return cerr.NewConfigError(correlationId, "NO_PATH", "Path to the processed messages file is not set")
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return cerr.NewFileError(correlationId, "CANNOT_CREATE_DIR", "Failed to create directory for processed messages file "+c.path).
+				WithCause(err)
+		}
+	}
+
+	expirations, err := c.read(correlationId)
+	if err != nil {
+		return err
+	}
+	if err = c.rewrite(correlationId, expirations); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_OPEN_FILE", "Failed to open processed messages file "+c.path).
+			WithCause(err)
+	}
+
+	c.processed.Clear(correlationId)
+	c.processed.lock.Lock()
+	for messageId, expiration := range expirations {
+		c.processed.mark(messageId, expiration)
+	}
+	c.processed.lock.Unlock()
+
+	c.file = file
+	return nil
+}
+
+// Close method are closes the file.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *FileProcessedMessageStore) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	err := c.file.Close()
+	c.file = nil
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_CLOSE_FILE", "Failed to close processed messages file "+c.path).
+			WithCause(err)
+	}
+	return nil
+}
+
+// IsProcessed method are checks if a message with the given id was processed within the time to live.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was processed or error.
+func (c *FileProcessedMessageStore) IsProcessed(correlationId string, messageId string) (bool, error) {
+	return c.processed.IsProcessed(correlationId, messageId)
+}
+
+// TryMarkProcessing method are atomically claims a message with the given id for processing.
+// The claim fails when the message was processed or is claimed by another consumer within the claim timeout.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was claimed or error.
+func (c *FileProcessedMessageStore) TryMarkProcessing(correlationId string, messageId string) (bool, error) {
+	return c.processed.TryMarkProcessing(correlationId, messageId)
+}
+
+// UnmarkProcessing method are releases a claim of a message that failed to be processed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *FileProcessedMessageStore) UnmarkProcessing(correlationId string, messageId string) error {
+	return c.processed.UnmarkProcessing(correlationId, messageId)
+}
+
+// MarkProcessed method are remembers that a message with the given id was processed and releases its claim.
+// The id is written to the file before the call returns.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *FileProcessedMessageStore) MarkProcessed(correlationId string, messageId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.file == nil {
+		return cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The store is not opened")
+	}
+
+	expiration := time.Now().Add(c.ttl)
+	line := fmt.Sprintf("%d %s\n", expiration.UnixMilli(), messageId)
+	if _, err := c.file.WriteString(line); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_WRITE_FILE", "Failed to write processed messages file "+c.path).
+			WithCause(err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_SYNC_FILE", "Failed to sync processed messages file "+c.path).
+			WithCause(err)
+	}
+
+	c.processed.lock.Lock()
+	c.processed.mark(messageId, expiration)
+	c.processed.lock.Unlock()
+	return nil
+}
+
+// read reads ids that are not expired yet with their expiration times.
+func (c *FileProcessedMessageStore) read(correlationId string) (map[string]time.Time, error) {
+	expirations := map[string]time.Time{}
+
+	file, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return expirations, nil
+	}
+	if err != nil {
+		return nil, cerr.NewFileError(correlationId, "CANNOT_OPEN_FILE", "Failed to open processed messages file "+c.path).
+			WithCause(err)
+	}
+	defer file.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// A line written partially before a crash is skipped
+		value, messageId, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || messageId == "" {
+			continue
+		}
+		millis, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if expiration := time.UnixMilli(millis); expiration.After(now) {
+			expirations[messageId] = expiration
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, cerr.NewFileError(correlationId, "CANNOT_READ_FILE", "Failed to read processed messages file "+c.path).
+			WithCause(err)
+	}
+	return expirations, nil
+}
+
+// rewrite replaces the file with a new one that contains only the given ids.
+func (c *FileProcessedMessageStore) rewrite(correlationId string, expirations map[string]time.Time) error {
+	tempPath := c.path + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return cerr.NewFileError(correlationId, "CANNOT_OPEN_FILE", "Failed to create processed messages file "+tempPath).
+			WithCause(err)
+	}
+
+	writer := bufio.NewWriter(file)
+	for messageId, expiration := range expirations {
+		fmt.Fprintf(writer, "%d %s\n", expiration.UnixMilli(), messageId)
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+	if err == nil {
+		err = os.Rename(tempPath, c.path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return cerr.NewFileError(correlationId, "CANNOT_WRITE_FILE", "Failed to compact processed messages file "+c.path).
+			WithCause(err)
+	}
+	return nil
+}
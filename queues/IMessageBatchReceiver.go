@@ -0,0 +1,36 @@
+package queues
+
+/*
+IMessageBatchReceiver callback interface to receive incoming messages in batches.
+It is used by consumers that process many messages at once, for instance to write them into a database in bulk.
+Like IMessageReceiver, the receiver is responsible to complete or abandon received messages.
+
+Example:
+
+    type MyBatchReceiver struct {}
+
+    func (c *MyBatchReceiver) ReceiveMessages(messages []*MessageEnvelope, queue IMessageQueue) error {
+        err := saveOrders(messages)
+        if err != nil {
+            queue.AbandonBatch(messages)
+            return err
+        }
+        return queue.CompleteBatch(messages)
+    }
+
+    listener := NewMessageBatchListener(messageQueue, &MyBatchReceiver{})
+    listener.BeginListen("123")
+
+See MessageBatchListener
+See IMessageReceiver
+*/
+type IMessageBatchReceiver interface {
+
+	// ReceiveMessages method are receives a batch of incoming messages from the queue.
+	//   - messages  a list of incoming messages
+	//   - queue     a queue where the messages come from
+	// Returns: error or nil for success.
+	// See: MessageEnvelope
+	// See: IMessageQueue
+	ReceiveMessages(messages []*MessageEnvelope, queue IMessageQueue) (err error)
+}
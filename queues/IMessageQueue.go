@@ -44,6 +44,15 @@ type IMessageQueue interface {
 	// See Send
 	SendAsObject(correlationId string, messageType string, value interface{}) error
 
+	// SendBatch method are sends multiple messages into the queue.
+	// When the queue supports atomic batches, either all messages are sent or none of them.
+	// Otherwise messages are sent one by one and sending stops at the first error.
+	//  - correlationId     (optional) transaction id to trace execution through call chain.
+	//  - envelopes         a list of message envelops to be sent.
+	// Returns: error or nil for success.
+	// See MessagingCapabilities.CanSendAtomicBatch
+	SendBatch(correlationId string, envelopes []*MessageEnvelope) error
+
 	// Peek method are peeks a single incoming message from the queue without removing it.
 	// If there are no messages available in the queue it returns nil.
 	//  - correlationId     (optional) transaction id to trace execution through call chain.
@@ -63,6 +72,15 @@ type IMessageQueue interface {
 	// Returns: a message or error.
 	Receive(correlationId string, waitTimeout time.Duration) (result *MessageEnvelope, err error)
 
+	// ReceiveBatch method are receives multiple incoming messages and locks them in the queue.
+	// It waits for the first message up to the wait timeout and then takes messages that are already available.
+	// If there are no messages available in the queue it returns an empty list.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - messageCount      a maximum number of messages to receive.
+	//   - waitTimeout       a timeout in milliseconds to wait for the first message to come.
+	// Returns: list with messages or error.
+	ReceiveBatch(correlationId string, messageCount int64, waitTimeout time.Duration) (result []*MessageEnvelope, err error)
+
 	// RenewLock methodd are renews a lock on a message that makes it invisible from other receivers in the queue.
 	// This method is usually used to extend the message processing time.
 	//   - message       a message to extend its lock.
@@ -84,6 +102,16 @@ type IMessageQueue interface {
 	// Retruns: error or nil for success.
 	Abandon(message *MessageEnvelope) error
 
+	// CompleteBatch method are permanently removes multiple messages from the queue.
+	//   - messages  a list of messages to remove.
+	// Returns: error or nil for success.
+	CompleteBatch(messages []*MessageEnvelope) error
+
+	// AbandonBatch method are returns multiple messages into the queue to receive them again.
+	//   - messages  a list of messages to return.
+	// Returns: error or nil for success.
+	AbandonBatch(messages []*MessageEnvelope) error
+
 	// MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
 	//   - message   a message to be removed.
 	// Results: error or nil for success.
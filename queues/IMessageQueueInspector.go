@@ -0,0 +1,47 @@
+package queues
+
+import (
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// IMessageQueueInspector Interface for message queues that let administration tools
+// read their state and browse or remove messages that wait for delivery.
+// Locked messages are not browsed or removed.
+//
+// Messages are selected by the filter described in MessageFilter.
+// When the filter has "dead_letter" set to true, the methods work with
+// the dead letter queue instead of the queue itself.
+//
+// Queues that implement it can be served by IMessageQueueAdmin implementations.
+// Purging is done by Clear method of ICleanable interface.
+//
+// See MessageFilter
+// See MemoryMessageQueue
+type IMessageQueueInspector interface {
+
+	// ReadStats method are reads the current state of the queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	// Returns: the queue statistics or error.
+	ReadStats(correlationId string) (*MessageQueueStats, error)
+
+	// BrowseMessages method are gets a page of messages that match the filter without removing them.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - filter            (optional) a filter to select messages.
+	//   - paging            (optional) paging parameters.
+	// Returns: a page with messages or error.
+	BrowseMessages(correlationId string, filter *cdata.FilterParams, paging *cdata.PagingParams) (*MessageEnvelopePage, error)
+
+	// RemoveMessages method are removes messages that match the filter from the queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - filter            (optional) a filter to select messages.
+	// Returns: a list with removed messages or error.
+	RemoveMessages(correlationId string, filter *cdata.FilterParams) ([]*MessageEnvelope, error)
+
+	// RemoveMessage method are removes a single message returned by BrowseMessages.
+	// Other messages with the same id are left in the queue.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - message           a browsed message to be removed.
+	//   - deadLetter        true to remove the message from the dead letter queue.
+	// Returns: true if the message was removed, false if it is no longer in the queue, or error.
+	RemoveMessage(correlationId string, message *MessageEnvelope, deadLetter bool) (bool, error)
+}
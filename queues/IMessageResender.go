@@ -0,0 +1,19 @@
+package queues
+
+/*
+IMessageResender Interface for message queues that can send again messages
+that were already delivered, like redriven dead letters or messages moved between queues.
+Resent messages skip deduplication, so they are not dropped as duplicates of their first delivery.
+
+See DeadLetterRedriver
+See MemoryMessageQueue
+*/
+type IMessageResender interface {
+
+	// Resend method are sends a message that was already delivered into the queue again
+	// without checking it for duplicates.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - envelope          a message envelop to be sent.
+	// Returns: error or nil for success.
+	Resend(correlationId string, envelope *MessageEnvelope) error
+}
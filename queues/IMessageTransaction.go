@@ -0,0 +1,63 @@
+package queues
+
+/*
+IMessageTransaction interface for a unit of work that sends and completes messages atomically.
+Messages sent within the transaction are not visible to receivers until it is committed.
+Messages completed within the transaction stay locked until it is committed,
+and remain locked by the receiver when it is rolled back.
+
+See ITransactionalMessageQueue
+*/
+type IMessageTransaction interface {
+
+	// Send method are sends a message into the queue within the transaction.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - envelope          a message envelop to be sent.
+	// Returns: error or nil for success.
+	Send(correlationId string, envelope *MessageEnvelope) error
+
+	// Complete method are permanently removes a received message from the queue when the transaction is committed.
+	//   - message   a message to remove.
+	// Returns: error or nil for success.
+	Complete(message *MessageEnvelope) error
+
+	// Commit method are applies all sends and completions of the transaction at once.
+	// When any of them cannot be applied, nothing is applied and an error is returned.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	// Returns: error or nil for success.
+	Commit(correlationId string) error
+
+	// Rollback method are discards all sends and completions of the transaction.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	// Returns: error or nil for success.
+	Rollback(correlationId string) error
+}
+
+/*
+ITransactionalMessageQueue interface for message queues that support transactions.
+To verify if a queue supports transactions consult with MessagingCapabilities.
+
+See IMessageTransaction
+See MessagingCapabilities
+
+Example:
+
+    tx, err := queue.BeginTransaction("123")
+    if err != nil {
+        return err
+    }
+    tx.Send("123", NewMessageEnvelope("123", "order.accepted", []byte("ABC")))
+    tx.Send("123", NewMessageEnvelope("123", "invoice.created", []byte("DEF")))
+    tx.Complete(message)
+    if err = tx.Commit("123"); err != nil {
+        return err
+    }
+*/
+type ITransactionalMessageQueue interface {
+	IMessageQueue
+
+	// BeginTransaction method are starts a new transaction.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	// Returns: a started transaction or error.
+	BeginTransaction(correlationId string) (IMessageTransaction, error)
+}
@@ -0,0 +1,40 @@
+package queues
+
+/*
+IProcessedMessageStore interface for stores that remember ids of processed messages.
+It is used by IdempotentMessageReceiver to skip messages that were already processed.
+Messages are claimed before processing, so consumers that receive the same message
+at the same time do not process it twice.
+
+See IdempotentMessageReceiver
+See MemoryProcessedMessageStore
+See FileProcessedMessageStore
+*/
+type IProcessedMessageStore interface {
+
+	// IsProcessed method are checks if a message with the given id was processed.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - messageId         an id of the message.
+	// Returns: true if the message was processed or error.
+	IsProcessed(correlationId string, messageId string) (bool, error)
+
+	// TryMarkProcessing method are atomically claims a message with the given id for processing.
+	// The claim fails when the message was processed or is claimed by another consumer.
+	// Claims expire after a timeout, so messages claimed by consumers that crashed can be processed again.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - messageId         an id of the message.
+	// Returns: true if the message was claimed or error.
+	TryMarkProcessing(correlationId string, messageId string) (bool, error)
+
+	// UnmarkProcessing method are releases a claim of a message that failed to be processed.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - messageId         an id of the message.
+	// Returns: error or nil for success.
+	UnmarkProcessing(correlationId string, messageId string) error
+
+	// MarkProcessed method are remembers that a message with the given id was processed and releases its claim.
+	//   - correlationId     (optional) transaction id to trace execution through call chain.
+	//   - messageId         an id of the message.
+	// Returns: error or nil for success.
+	MarkProcessed(correlationId string, messageId string) error
+}
@@ -0,0 +1,146 @@
+package queues
+
+import (
+	"sync/atomic"
+	"time"
+
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+/*
+IdempotentMessageReceiver Message receiver decorator that gives exactly-once effects
+on top of at-least-once delivery.
+
+Ids of messages successfully processed by the inner receiver are recorded in a processed message store.
+When a message with a recorded id comes again, it is completed without calling the inner receiver.
+Messages without ids are always passed to the inner receiver.
+
+Messages are claimed in the store before processing. When another consumer processes the same message
+at the moment, the message is returned into the queue after the postpone delay, so it is received again
+and skipped once the other consumer records it. When the inner receiver fails or panics,
+the claim is released, so the message can be processed again.
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+
+See IMessageReceiver
+See IProcessedMessageStore
+
+Example:
+
+    store := NewMemoryProcessedMessageStore()
+    receiver := NewIdempotentMessageReceiver(NewMyMessageReceiver(), store)
+
+    messageQueue := NewMemoryMessageQueue("myqueue")
+    messageQueue.BeginListen("123", receiver)
+*/
+type IdempotentMessageReceiver struct {
+	Logger   *clog.CompositeLogger
+	Counters *ccount.CompositeCounters
+	receiver IMessageReceiver
+	store    IProcessedMessageStore
+	skipped  int64
+	postpone int64
+}
+
+// idempotentPostponeDelay is the default delay before messages claimed by other consumers are returned into the queue.
+const idempotentPostponeDelay = 1000 * time.Millisecond
+
+// NewIdempotentMessageReceiver method are creates a new instance of the receiver.
+//   - receiver    an inner receiver to process messages.
+//   - store       (optional) a store of processed messages, MemoryProcessedMessageStore is used when it is nil.
+// Returns: *IdempotentMessageReceiver
+func NewIdempotentMessageReceiver(receiver IMessageReceiver, store IProcessedMessageStore) *IdempotentMessageReceiver {
+	if store == nil {
+		store = NewMemoryProcessedMessageStore()
+	}
+
+	c := IdempotentMessageReceiver{
+		Logger:   clog.NewCompositeLogger(),
+		Counters: ccount.NewCompositeCounters(),
+		receiver: receiver,
+		store:    store,
+		postpone: int64(idempotentPostponeDelay),
+	}
+	return &c
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *IdempotentMessageReceiver) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+}
+
+// Store method are gets the store of processed messages.
+// Returns: the processed message store.
+func (c *IdempotentMessageReceiver) Store() IProcessedMessageStore {
+	return c.store
+}
+
+// SetPostponeDelay method are sets the delay before messages claimed by other consumers are returned into the queue.
+//   - delay     a delay in milliseconds (default: 1000).
+func (c *IdempotentMessageReceiver) SetPostponeDelay(delay time.Duration) {
+	atomic.StoreInt64(&c.postpone, int64(delay))
+}
+
+// SkippedCount method are gets the number of duplicate messages skipped by the receiver.
+// Returns: the number of skipped messages.
+func (c *IdempotentMessageReceiver) SkippedCount() int64 {
+	return atomic.LoadInt64(&c.skipped)
+}
+
+// ReceiveMessage method are receives incoming message from the queue.
+// Processed messages are completed, messages claimed by other consumers are postponed,
+// other messages are passed to the inner receiver and recorded in the store when it returns no error.
+//   - envelope  an incoming message
+//   - queue     a queue where the message comes from
+// Returns: error or nil for success.
+func (c *IdempotentMessageReceiver) ReceiveMessage(envelope *MessageEnvelope, queue IMessageQueue) error {
+	if envelope.MessageId == "" {
+		return c.receiver.ReceiveMessage(envelope, queue)
+	}
+
+	claimed, err := c.store.TryMarkProcessing(envelope.CorrelationId, envelope.MessageId)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		processed, err := c.store.IsProcessed(envelope.CorrelationId, envelope.MessageId)
+		if err != nil {
+			return err
+		}
+		if processed {
+			atomic.AddInt64(&c.skipped, 1)
+			c.Counters.IncrementOne("queue." + queue.Name() + ".skipped_duplicates")
+			c.Logger.Debug(envelope.CorrelationId, "Skipped processed message %s at %s", envelope.String(), queue.Name())
+			return queue.Complete(envelope)
+		}
+
+		// The message is returned later, so it is not received again while the other consumer processes it
+		c.Logger.Debug(envelope.CorrelationId, "Postponed message %s claimed by another consumer at %s", envelope.String(), queue.Name())
+		time.AfterFunc(time.Duration(atomic.LoadInt64(&c.postpone)), func() {
+			if err := queue.Abandon(envelope); err != nil {
+				c.Logger.Error(envelope.CorrelationId, err, "Failed to return postponed message %s at %s", envelope.String(), queue.Name())
+			}
+		})
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.store.UnmarkProcessing(envelope.CorrelationId, envelope.MessageId)
+			panic(r)
+		}
+	}()
+
+	if err = c.receiver.ReceiveMessage(envelope, queue); err != nil {
+		c.store.UnmarkProcessing(envelope.CorrelationId, envelope.MessageId)
+		return err
+	}
+	return c.store.MarkProcessed(envelope.CorrelationId, envelope.MessageId)
+}
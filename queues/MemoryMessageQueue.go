@@ -1,17 +1,38 @@
 package queues
 
 import (
-	"fmt"
 	"sync/atomic"
 	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
 )
 
 /*
 MemoryMessageQueue Message queue that sends and receives messages within the same process by using shared memory.
 This queue is typically used for testing to mock real queues.
+Messages moved to dead letter are kept in the queue, so they can be inspected
+and removed through IMessageQueueInspector interface.
+Duplicate messages sent within the deduplication window are accepted, but not added to the queue.
+
+Messages can be sent and completed atomically within transactions started by BeginTransaction.
+Messages of a batch sent with SendBatch are added to the queue at once.
+
+Messages with the same GroupId are delivered in order one at a time, like sessions or FIFO message groups.
+While a message of a group is locked, the group is locked as well and other messages of the group
+are not received. The group lock is released when the message is completed, abandoned or
+moved to dead letter, or when its lock expires. RenewLock renews the group lock together with the message lock.
+
 Configuration parameters:
 
   - name:                        name of the message queue
+  - options:
+    - lock_timeout:              timeout in milliseconds to lock received messages (default: 30000)
+    - dedup_window:              time window in milliseconds to skip duplicate messages, 0 to turn deduplication off (default: 0)
+    - dedup_header:              header with deduplication keys, message ids are used when it is not set (default: none)
+    - listen_rate:               maximum number of messages per second received by Listen, 0 for no limit (default: 0)
+    - listen_burst:              maximum number of messages received by Listen at once after a pause (default: 1)
 
 References:
 
@@ -20,6 +41,8 @@ References:
 
 See MessageQueue
 See MessagingCapabilities
+See IMessageQueueInspector
+See ITransactionalMessageQueue
 
 Example:
 
@@ -34,13 +57,20 @@ Example:
 */
 type MemoryMessageQueue struct {
 	MessageQueue
+	sequence          int64
+	lockTimeout       time.Duration
 	messages          []MessageEnvelope
 	lockTokenSequence int
 	lockedMessages    map[int]*LockedMessage
-	opened            bool
+	deadMessages      []MessageEnvelope
+	lockedGroups      map[string]int
+	opened            int32
 	cancel            int32
 }
 
+// memoryQueueSequence numbers memory queues, so transactions lock them in the same order.
+var memoryQueueSequence int64
+
 // NewMemoryMessageQueue method are creates a new instance of the message queue.
 //   - name  (optional) a queue name.
 // Returns: *MemoryMessageQueue
@@ -49,22 +79,35 @@ func NewMemoryMessageQueue(name string) *MemoryMessageQueue {
 	c := MemoryMessageQueue{}
 
 	c.MessageQueue = *InheritMessageQueue(
-		&c, name, NewMessagingCapabilities(true, true, true, true, true, true, true, false, true),
+		&c, name, NewMessagingCapabilities(true, true, true, true, true, true, true, true, true).
+			WithDeduplication(true).WithTransactions(true).WithAtomicBatches(true),
 	)
 
+	c.sequence = atomic.AddInt64(&memoryQueueSequence, 1)
+	c.lockTimeout = 30000 * time.Millisecond
 	c.messages = make([]MessageEnvelope, 0)
 	c.lockTokenSequence = 0
 	c.lockedMessages = make(map[int]*LockedMessage, 0)
-	c.opened = false
+	c.deadMessages = make([]MessageEnvelope, 0)
+	c.lockedGroups = make(map[string]int, 0)
+	c.opened = 0
 	c.cancel = 0
 
 	return &c
 }
 
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MemoryMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+}
+
 // IsOpen method are checks if the component is opened.
 // Return true if the component has been opened and false otherwise.
 func (c *MemoryMessageQueue) IsOpen() bool {
-	return c.opened
+	return atomic.LoadInt32(&c.opened) != 0
 }
 
 // OpenWithParams method are opens the component with given connection and credential parameters.
@@ -73,7 +116,7 @@ func (c *MemoryMessageQueue) IsOpen() bool {
 //   - credential        credential parameters
 // Retruns: error or nil no errors occured.
 func (c *MemoryMessageQueue) Open(correlationId string) (err error) {
-	c.opened = true
+	atomic.StoreInt32(&c.opened, 1)
 
 	c.Logger.Debug(correlationId, "Opened queue %s", c.Name())
 
@@ -84,7 +127,7 @@ func (c *MemoryMessageQueue) Open(correlationId string) (err error) {
 //   - correlationId 	(optional) transaction id to trace execution through call chain.
 // Returns: error or nil no errors occured.
 func (c *MemoryMessageQueue) Close(correlationId string) (err error) {
-	c.opened = false
+	atomic.StoreInt32(&c.opened, 0)
 	atomic.StoreInt32(&c.cancel, 1)
 
 	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
@@ -101,6 +144,11 @@ func (c *MemoryMessageQueue) Clear(correlationId string) (err error) {
 
 	c.messages = make([]MessageEnvelope, 0)
 	c.lockedMessages = make(map[int]*LockedMessage, 0)
+	c.deadMessages = make([]MessageEnvelope, 0)
+	c.lockedGroups = make(map[string]int, 0)
+	if deduplicator := c.Deduplicator(); deduplicator != nil {
+		deduplicator.Clear()
+	}
 	atomic.StoreInt32(&c.cancel, 0)
 
 	return nil
@@ -117,10 +165,101 @@ func (c *MemoryMessageQueue) ReadMessageCount() (count int64, err error) {
 }
 
 // Send method are sends a message into the queue.
+// When deduplication is on, a duplicate message is accepted without adding it to the queue.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - envelope          a message envelop to be sent.
 // Returns: error or nil for success.
 func (c *MemoryMessageQueue) Send(correlationId string, envelope *MessageEnvelope) (err error) {
+	c.enqueueBatch(correlationId, []*MessageEnvelope{envelope})
+	return nil
+}
+
+// Resend method are sends a message that was already delivered into the queue again
+// without checking it for duplicates.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+// See IMessageResender
+func (c *MemoryMessageQueue) Resend(correlationId string, envelope *MessageEnvelope) error {
+	if envelope == nil {
+		return cerr.NewBadRequestError(correlationId, "NO_MESSAGE", "Message cannot be nil")
+	}
+
+	// Resent messages keep their sent time, so filters by age still match them
+	if envelope.SentTime.IsZero() {
+		envelope.SentTime = time.Now()
+	}
+
+	c.Lock.Lock()
+	c.messages = append(c.messages, *envelope)
+	c.Lock.Unlock()
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// SendBatch method are sends multiple messages into the queue at once.
+// Receivers see either all messages of the batch or none of them,
+// and no other messages are placed between them.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelopes         a list of message envelops to be sent.
+// Returns: error or nil for success.
+func (c *MemoryMessageQueue) SendBatch(correlationId string, envelopes []*MessageEnvelope) error {
+	for _, envelope := range envelopes {
+		if envelope == nil {
+			return cerr.NewBadRequestError(correlationId, "NO_MESSAGE", "Message in the batch cannot be nil")
+		}
+	}
+
+	c.enqueueBatch(correlationId, envelopes)
+	return nil
+}
+
+// enqueueBatch adds messages to the end of the queue at once.
+// Duplicate messages are skipped when deduplication is on.
+func (c *MemoryMessageQueue) enqueueBatch(correlationId string, envelopes []*MessageEnvelope) {
+	c.Lock.Lock()
+	messages, duplicates := c.appendBatch(envelopes)
+	c.Lock.Unlock()
+
+	c.traceBatch(messages, duplicates)
+}
+
+// appendBatch adds messages that are not duplicates to the end of the queue.
+// The queue shall be locked by the caller.
+// Returns: added messages and skipped duplicates.
+func (c *MemoryMessageQueue) appendBatch(envelopes []*MessageEnvelope) ([]MessageEnvelope, []*MessageEnvelope) {
+	now := time.Now()
+	messages := make([]MessageEnvelope, 0, len(envelopes))
+	duplicates := []*MessageEnvelope{}
+	for _, envelope := range envelopes {
+		if c.IsDuplicate(envelope) {
+			duplicates = append(duplicates, envelope)
+			continue
+		}
+		envelope.SentTime = now
+		messages = append(messages, *envelope)
+	}
+	c.messages = append(c.messages, messages...)
+	return messages, duplicates
+}
+
+// traceBatch logs and counts messages added by appendBatch.
+func (c *MemoryMessageQueue) traceBatch(messages []MessageEnvelope, duplicates []*MessageEnvelope) {
+	for _, envelope := range duplicates {
+		c.Counters.IncrementOne("queue." + c.Name() + ".duplicate_messages")
+		c.Logger.Debug(envelope.CorrelationId, "Skipped duplicate message %s via %s", envelope.String(), c.Name())
+	}
+	for _, message := range messages {
+		c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+		c.Logger.Debug(message.CorrelationId, "Sent message %s via %s", message.String(), c.Name())
+	}
+}
+
+// enqueue adds a message to the end of the queue.
+func (c *MemoryMessageQueue) enqueue(envelope *MessageEnvelope) error {
 	envelope.SentTime = time.Now()
 
 	// Add message to the queue
@@ -134,6 +273,34 @@ func (c *MemoryMessageQueue) Send(correlationId string, envelope *MessageEnvelop
 	return nil
 }
 
+// requeue returns an abandoned message into the queue.
+// Messages of a group are put before other messages of the same group to keep their order.
+func (c *MemoryMessageQueue) requeue(envelope *MessageEnvelope) error {
+	if envelope.GroupId == "" {
+		return c.enqueue(envelope)
+	}
+
+	envelope.SentTime = time.Now()
+
+	c.Lock.Lock()
+	index := len(c.messages)
+	for i := range c.messages {
+		if c.messages[i].GroupId == envelope.GroupId {
+			index = i
+			break
+		}
+	}
+	c.messages = append(c.messages, MessageEnvelope{})
+	copy(c.messages[index+1:], c.messages[index:])
+	c.messages[index] = *envelope
+	c.Lock.Unlock()
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
 // Peek meethod are peeks a single incoming message from the queue without removing it.
 // If there are no messages available in the queue it returns nil.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
@@ -179,51 +346,147 @@ func (c *MemoryMessageQueue) PeekBatch(correlationId string, messageCount int64)
 }
 
 //  Receive method are receives an incoming message and removes it from the queue.
+// The message is locked for the configured lock timeout.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - waitTimeout       a timeout in milliseconds to wait for a message to come.
 // Returns: a message or error.
 func (c *MemoryMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*MessageEnvelope, error) {
-	messageReceived := false
-	var message *MessageEnvelope
-	elapsedTime := time.Duration(0)
+	messages, err := c.ReceiveBatch(correlationId, 1, waitTimeout)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return messages[0], nil
+}
 
-	for elapsedTime < waitTimeout && !messageReceived {
+// ReceiveBatch method are receives multiple incoming messages and locks them in the queue
+// for the configured lock timeout.
+// It waits for the first message up to the wait timeout and then takes all available messages at once.
+// Only one message of every group is taken, so messages of the group stay in order.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to receive.
+//   - waitTimeout       a timeout in milliseconds to wait for the first message to come.
+// Returns: list with messages or error.
+func (c *MemoryMessageQueue) ReceiveBatch(correlationId string, messageCount int64, waitTimeout time.Duration) ([]*MessageEnvelope, error) {
+	return c.ReceiveBatchWithLock(correlationId, messageCount, waitTimeout, c.lockTimeout)
+}
+
+// ReceiveBatchWithLock method are receives multiple incoming messages and locks them for the given time.
+// It is used by servers that expose the queue to remote clients with their own lock timeouts.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to receive.
+//   - waitTimeout       a timeout in milliseconds to wait for the first message to come.
+//   - lockTimeout       a timeout in milliseconds to lock received messages, the configured timeout is used when it is 0.
+// Returns: list with messages or error.
+func (c *MemoryMessageQueue) ReceiveBatchWithLock(correlationId string, messageCount int64,
+	waitTimeout time.Duration, lockTimeout time.Duration) ([]*MessageEnvelope, error) {
+	if lockTimeout <= 0 {
+		lockTimeout = c.lockTimeout
+	}
+
+	messages := []*MessageEnvelope{}
+	deadline := time.Now().Add(waitTimeout)
+
+	for messageCount > 0 {
 		c.Lock.Lock()
-		if len(c.messages) == 0 {
-			c.Lock.Unlock()
-			time.Sleep(time.Duration(100) * time.Millisecond)
-			elapsedTime += time.Duration(100)
-			continue
+		for int64(len(messages)) < messageCount {
+			message := c.lockNextMessage(lockTimeout)
+			if message == nil {
+				break
+			}
+			messages = append(messages, message)
+		}
+		c.Lock.Unlock()
+
+		if len(messages) > 0 || !time.Now().Before(deadline) {
+			break
 		}
+		time.Sleep(time.Duration(100) * time.Millisecond)
+	}
 
-		// Get message from the queue
+	for _, message := range messages {
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	}
+
+	return messages, nil
+}
+
+// lockNextMessage takes the next message that can be received from the queue and locks it.
+// It shall be called under the queue lock.
+// Returns: the locked message or nil when no messages can be received.
+func (c *MemoryMessageQueue) lockNextMessage(lockTimeout time.Duration) *MessageEnvelope {
+	index := c.nextMessageIndex()
+	if index < 0 {
+		return nil
+	}
+
+	// Get message from the queue
+	var message *MessageEnvelope
+	if index == 0 {
 		message = &c.messages[0]
 		c.messages = c.messages[1:]
+	} else {
+		// Peeked messages point to the queue, so it is copied instead of shifted
+		item := c.messages[index]
+		message = &item
+		c.messages = append(c.messages[:index:index], c.messages[index+1:]...)
+	}
 
-		// Generate and set locked token
-		lockedToken := c.lockTokenSequence
-		c.lockTokenSequence++
-		message.SetReference(lockedToken)
-
-		// Add messages to locked messages list
-		now := time.Now().Add(waitTimeout)
-		lockedMessage := &LockedMessage{
-			ExpirationTime: now,
-			Message:        message,
-			Timeout:        waitTimeout,
-		}
-		c.lockedMessages[lockedToken] = lockedMessage
+	// Generate and set locked token
+	lockedToken := c.lockTokenSequence
+	c.lockTokenSequence++
+	message.SetReference(lockedToken)
 
-		messageReceived = true
-		c.Lock.Unlock()
+	// Lock the message group until the message is processed
+	if message.GroupId != "" {
+		c.lockedGroups[message.GroupId] = lockedToken
 	}
 
-	if message != nil {
-		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
-		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	// Add messages to locked messages list
+	lockedMessage := &LockedMessage{
+		ExpirationTime: time.Now().Add(lockTimeout),
+		Message:        message,
+		Timeout:        lockTimeout,
 	}
+	c.lockedMessages[lockedToken] = lockedMessage
 
-	return message, nil
+	return message
+}
+
+// nextMessageIndex finds the first message that can be received.
+// Messages of locked groups are skipped. It shall be called under the queue lock.
+// Returns: the message index or -1 when no messages can be received.
+func (c *MemoryMessageQueue) nextMessageIndex() int {
+	now := time.Now()
+	for index := range c.messages {
+		groupId := c.messages[index].GroupId
+		if groupId == "" || !c.isGroupLocked(groupId, now) {
+			return index
+		}
+	}
+	return -1
+}
+
+// isGroupLocked checks if a message of the group is locked.
+// Locks of completed or expired messages are released. It shall be called under the queue lock.
+func (c *MemoryMessageQueue) isGroupLocked(groupId string, now time.Time) bool {
+	lockedToken, ok := c.lockedGroups[groupId]
+	if !ok {
+		return false
+	}
+	lockedMessage, ok := c.lockedMessages[lockedToken]
+	if !ok || !lockedMessage.ExpirationTime.After(now) {
+		delete(c.lockedGroups, groupId)
+		return false
+	}
+	return true
+}
+
+// unlockGroup releases the group lock held by the message. It shall be called under the queue lock.
+func (c *MemoryMessageQueue) unlockGroup(message *MessageEnvelope, lockedToken int) {
+	if token, ok := c.lockedGroups[message.GroupId]; ok && token == lockedToken {
+		delete(c.lockedGroups, message.GroupId)
+	}
 }
 
 // RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
@@ -269,6 +532,7 @@ func (c *MemoryMessageQueue) Complete(message *MessageEnvelope) (err error) {
 	c.Lock.Lock()
 	lockedToken := reference.(int)
 	delete(c.lockedMessages, lockedToken)
+	c.unlockGroup(message, lockedToken)
 	message.SetReference(nil)
 	c.Lock.Unlock()
 
@@ -296,6 +560,7 @@ func (c *MemoryMessageQueue) Abandon(message *MessageEnvelope) (err error) {
 	if ok {
 		// Remove from locked messages
 		delete(c.lockedMessages, lockedToken)
+		c.unlockGroup(message, lockedToken)
 		message.SetReference(nil)
 
 		// Skip if it is already expired
@@ -311,8 +576,8 @@ func (c *MemoryMessageQueue) Abandon(message *MessageEnvelope) (err error) {
 
 	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
 
-	// Add back to message queue
-	return c.Send(message.CorrelationId, message)
+	// Add back to message queue, abandoned messages are not duplicates
+	return c.requeue(message)
 }
 
 // MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
@@ -326,8 +591,13 @@ func (c *MemoryMessageQueue) MoveToDeadLetter(message *MessageEnvelope) (err err
 
 	c.Lock.Lock()
 	lockedToken := reference.(int)
+	_, ok := c.lockedMessages[lockedToken]
 	delete(c.lockedMessages, lockedToken)
+	c.unlockGroup(message, lockedToken)
 	message.SetReference(nil)
+	if ok {
+		c.deadMessages = append(c.deadMessages, *message)
+	}
 	c.Lock.Unlock()
 
 	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
@@ -336,42 +606,149 @@ func (c *MemoryMessageQueue) MoveToDeadLetter(message *MessageEnvelope) (err err
 	return nil
 }
 
-// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+// BeginTransaction method are starts a new transaction to send and complete messages atomically.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
-//   - receiver          a receiver to receive incoming messages.
-// See IMessageReceiver
-// See Receive
-func (c *MemoryMessageQueue) Listen(correlationId string, receiver IMessageReceiver) error {
-	c.Logger.Trace("", "Started listening messages at %s", c.String())
+// Returns: a started transaction or error.
+// See MemoryMessageTransaction
+func (c *MemoryMessageQueue) BeginTransaction(correlationId string) (IMessageTransaction, error) {
+	return newMemoryMessageTransaction(c), nil
+}
 
-	// Unset cancellation token
-	atomic.StoreInt32(&c.cancel, 0)
+// ReadStats method are reads the current state of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: the queue statistics or error.
+func (c *MemoryMessageQueue) ReadStats(correlationId string) (*MessageQueueStats, error) {
+	c.Lock.Lock()
+	defer c.Lock.Unlock()
 
-	for atomic.LoadInt32(&c.cancel) == 0 {
-		message, err := c.Receive(correlationId, time.Duration(1000)*time.Millisecond)
-		if err != nil {
-			c.Logger.Error(correlationId, err, "Failed to receive the message")
+	stats := &MessageQueueStats{
+		Name:            c.Name(),
+		MessageCount:    int64(len(c.messages)),
+		LockedCount:     int64(len(c.lockedMessages)),
+		DeadLetterCount: int64(len(c.deadMessages)),
+	}
+	for _, message := range c.messages {
+		if age := time.Since(message.SentTime); age > stats.OldestMessageAge {
+			stats.OldestMessageAge = age
 		}
+	}
+	return stats, nil
+}
 
-		if message != nil && atomic.LoadInt32(&c.cancel) == 0 {
-			// Todo: shall we recover after panic here??
-			func(message *MessageEnvelope) {
-				defer func() {
-					if r := recover(); r != nil {
-						err := fmt.Sprintf("%v", r)
-						c.Logger.Error(correlationId, nil, "Failed to process the message - "+err)
-					}
-				}()
-
-				err = receiver.ReceiveMessage(message, c)
-				if err != nil {
-					c.Logger.Error(correlationId, err, "Failed to process the message")
-				}
-			}(message)
+// BrowseMessages method are gets a page of messages that match the filter without removing them.
+// Returned messages are copies, so changing them does not affect the queue.
+// When paging does not set the number of messages to take, up to 100 messages are returned.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter to select messages.
+//   - paging            (optional) paging parameters.
+// Returns: a page with messages or error.
+// See MessageFilter
+func (c *MemoryMessageQueue) BrowseMessages(correlationId string, filter *cdata.FilterParams,
+	paging *cdata.PagingParams) (*MessageEnvelopePage, error) {
+	messageFilter := NewMessageFilter(filter)
+
+	c.Lock.Lock()
+	source := c.messages
+	if messageFilter.DeadLetter {
+		source = c.deadMessages
+	}
+	messages := make([]*MessageEnvelope, len(source))
+	for i := range source {
+		messages[i] = &source[i]
+	}
+	page := filterMessagePage(messages, messageFilter, paging)
+	c.Lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Browsed %d messages on %s", len(page.Data), c.Name())
+
+	return page, nil
+}
+
+// RemoveMessages method are removes messages that match the filter from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - filter            (optional) a filter to select messages.
+// Returns: a list with removed messages or error.
+// See MessageFilter
+func (c *MemoryMessageQueue) RemoveMessages(correlationId string, filter *cdata.FilterParams) ([]*MessageEnvelope, error) {
+	messageFilter := NewMessageFilter(filter)
+
+	c.Lock.Lock()
+	messages := c.messages
+	if messageFilter.DeadLetter {
+		messages = c.deadMessages
+	}
+
+	removed := []*MessageEnvelope{}
+	remaining := make([]MessageEnvelope, 0, len(messages))
+	for _, message := range messages {
+		if messageFilter.Match(&message) {
+			item := message
+			removed = append(removed, &item)
+		} else {
+			remaining = append(remaining, message)
 		}
 	}
 
-	return nil
+	if messageFilter.DeadLetter {
+		c.deadMessages = remaining
+	} else {
+		c.messages = remaining
+	}
+	c.Lock.Unlock()
+
+	c.Logger.Debug(correlationId, "Removed %d messages from %s", len(removed), c.Name())
+
+	return removed, nil
+}
+
+// RemoveMessage method are removes a single message returned by BrowseMessages.
+// Other messages with the same id are left in the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - message           a browsed message to be removed.
+//   - deadLetter        true to remove the message from the dead letter queue.
+// Returns: true if the message was removed, false if it is no longer in the queue, or error.
+func (c *MemoryMessageQueue) RemoveMessage(correlationId string, message *MessageEnvelope, deadLetter bool) (bool, error) {
+	c.Lock.Lock()
+	messages := c.messages
+	if deadLetter {
+		messages = c.deadMessages
+	}
+
+	index := -1
+	for i := range messages {
+		if sameMessage(&messages[i], message) {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		remaining := make([]MessageEnvelope, 0, len(messages)-1)
+		remaining = append(remaining, messages[:index]...)
+		remaining = append(remaining, messages[index+1:]...)
+		if deadLetter {
+			c.deadMessages = remaining
+		} else {
+			c.messages = remaining
+		}
+	}
+	c.Lock.Unlock()
+
+	if index < 0 {
+		return false, nil
+	}
+
+	c.Logger.Debug(correlationId, "Removed message %s from %s", message.String(), c.Name())
+
+	return true, nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *MemoryMessageQueue) Listen(correlationId string, receiver IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
 }
 
 // EndListen method are ends listening for incoming messages.
@@ -0,0 +1,226 @@
+package queues
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+)
+
+// memoryTransactionSend is a message staged to be sent on commit.
+type memoryTransactionSend struct {
+	queue    *MemoryMessageQueue
+	envelope *MessageEnvelope
+}
+
+/*
+MemoryMessageTransaction Transaction of MemoryMessageQueue.
+Staged messages are kept in the transaction and added to queues on commit,
+so they are invisible to receivers before that. Messages can be sent to other
+memory queues in the same process within the transaction with SendTo method.
+On commit the transaction fails when a lock of any completed message has expired.
+Commit holds locks of all involved queues while it applies the transaction,
+so receivers never see a part of it. Queues are locked in the order they were created
+to avoid deadlocks between concurrent transactions.
+On rollback locks of completed messages are renewed, so the receiver can abandon them or try again.
+
+See IMessageTransaction
+See MemoryMessageQueue
+*/
+type MemoryMessageTransaction struct {
+	queue     *MemoryMessageQueue
+	sends     []memoryTransactionSend
+	completes []*MessageEnvelope
+	closed    bool
+	lock      sync.Mutex
+}
+
+func newMemoryMessageTransaction(queue *MemoryMessageQueue) *MemoryMessageTransaction {
+	c := MemoryMessageTransaction{
+		queue:     queue,
+		sends:     []memoryTransactionSend{},
+		completes: []*MessageEnvelope{},
+	}
+	return &c
+}
+
+// Send method are stages a message to be sent into the queue on commit.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *MemoryMessageTransaction) Send(correlationId string, envelope *MessageEnvelope) error {
+	return c.SendTo(correlationId, c.queue, envelope)
+}
+
+// SendTo method are stages a message to be sent into another memory queue on commit.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - queue             a queue to send the message to.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *MemoryMessageTransaction) SendTo(correlationId string, queue *MemoryMessageQueue, envelope *MessageEnvelope) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.checkActive(correlationId); err != nil {
+		return err
+	}
+	if queue == nil {
+		return cerr.NewBadRequestError(correlationId, "NO_QUEUE", "Queue cannot be nil")
+	}
+	if envelope == nil {
+		return cerr.NewBadRequestError(correlationId, "NO_MESSAGE", "Message cannot be nil")
+	}
+
+	c.sends = append(c.sends, memoryTransactionSend{queue: queue, envelope: envelope})
+	return nil
+}
+
+// Complete method are stages a received message to be removed from the queue on commit.
+// The message stays locked until the transaction ends.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *MemoryMessageTransaction) Complete(message *MessageEnvelope) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.checkActive(""); err != nil {
+		return err
+	}
+
+	lockedToken, ok := message.GetReference().(int)
+	if ok {
+		c.queue.Lock.Lock()
+		_, ok = c.queue.lockedMessages[lockedToken]
+		c.queue.Lock.Unlock()
+	}
+	if !ok {
+		return cerr.NewBadRequestError(message.CorrelationId, "MESSAGE_NOT_LOCKED",
+			"Message "+message.MessageId+" is not locked in queue "+c.queue.Name()).
+			WithDetails("message_id", message.MessageId)
+	}
+
+	c.completes = append(c.completes, message)
+	return nil
+}
+
+// Commit method are completes staged messages and adds staged messages to queues.
+// When a lock of any completed message has expired nothing is applied.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: error or nil for success.
+func (c *MemoryMessageTransaction) Commit(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.checkActive(correlationId); err != nil {
+		return err
+	}
+	c.closed = true
+
+	queue := c.queue
+	order := []*MemoryMessageQueue{}
+	batches := map[*MemoryMessageQueue][]*MessageEnvelope{}
+	for _, send := range c.sends {
+		if _, ok := batches[send.queue]; !ok {
+			order = append(order, send.queue)
+		}
+		batches[send.queue] = append(batches[send.queue], send.envelope)
+	}
+
+	// All involved queues are locked while the transaction is applied
+	involved := append([]*MemoryMessageQueue{queue}, order...)
+	sort.Slice(involved, func(i, j int) bool { return involved[i].sequence < involved[j].sequence })
+	locked := []*MemoryMessageQueue{}
+	for _, target := range involved {
+		if len(locked) == 0 || locked[len(locked)-1] != target {
+			target.Lock.Lock()
+			locked = append(locked, target)
+		}
+	}
+	unlock := func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			locked[i].Lock.Unlock()
+		}
+	}
+
+	// Messages completed or abandoned after they were staged have no lock tokens anymore
+	now := time.Now()
+	tokens := make([]int, len(c.completes))
+	for i, message := range c.completes {
+		lockedToken, ok := message.GetReference().(int)
+		var lockedMessage *LockedMessage
+		if ok {
+			lockedMessage, ok = queue.lockedMessages[lockedToken]
+		}
+		if !ok || !lockedMessage.ExpirationTime.After(now) {
+			unlock()
+			return cerr.NewConflictError(correlationId, "LOCK_LOST",
+				"Lock of message "+message.MessageId+" in queue "+queue.Name()+" is lost").
+				WithDetails("message_id", message.MessageId)
+		}
+		tokens[i] = lockedToken
+	}
+	for i, message := range c.completes {
+		delete(queue.lockedMessages, tokens[i])
+		queue.unlockGroup(message, tokens[i])
+		message.SetReference(nil)
+	}
+	sent := make([][]MessageEnvelope, len(order))
+	duplicates := make([][]*MessageEnvelope, len(order))
+	for i, target := range order {
+		sent[i], duplicates[i] = target.appendBatch(batches[target])
+	}
+	unlock()
+
+	for i, target := range order {
+		target.traceBatch(sent[i], duplicates[i])
+	}
+
+	for _, message := range c.completes {
+		queue.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, queue.Name())
+	}
+	queue.Logger.Debug(correlationId, "Committed transaction with %d sent and %d completed messages at %s",
+		len(c.sends), len(c.completes), queue.Name())
+
+	return nil
+}
+
+// Rollback method are discards staged messages and renews locks of messages staged to be completed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: error or nil for success.
+func (c *MemoryMessageTransaction) Rollback(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.checkActive(correlationId); err != nil {
+		return err
+	}
+	c.closed = true
+
+	queue := c.queue
+	queue.Lock.Lock()
+	now := time.Now()
+	for _, message := range c.completes {
+		lockedToken, ok := message.GetReference().(int)
+		if !ok {
+			continue
+		}
+		lockedMessage, ok := queue.lockedMessages[lockedToken]
+		if ok && lockedMessage.ExpirationTime.After(now) {
+			lockedMessage.ExpirationTime = now.Add(lockedMessage.Timeout)
+		}
+	}
+	queue.Lock.Unlock()
+
+	queue.Logger.Debug(correlationId, "Rolled back transaction with %d sent and %d completed messages at %s",
+		len(c.sends), len(c.completes), queue.Name())
+
+	return nil
+}
+
+func (c *MemoryMessageTransaction) checkActive(correlationId string) error {
+	if c.closed {
+		return cerr.NewInvalidStateError(correlationId, "TRANSACTION_CLOSED", "The transaction is already committed or rolled back")
+	}
+	return nil
+}
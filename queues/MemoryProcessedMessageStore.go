@@ -0,0 +1,159 @@
+package queues
+
+import (
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+)
+
+/*
+MemoryProcessedMessageStore Store that keeps ids of processed messages in memory.
+Ids are forgotten when their time to live passes, so the store does not grow without limits.
+The time to live shall be longer than the time in which duplicates can be delivered.
+Claims of messages that are being processed expire after the claim timeout,
+which shall be longer than the time to process a message.
+
+Configuration parameters:
+
+  - options:
+    - ttl:                       time in milliseconds to remember processed messages (default: 86400000)
+    - claim_timeout:             time in milliseconds to keep claims of messages that are being processed (default: 60000)
+
+See IProcessedMessageStore
+See IdempotentMessageReceiver
+
+Example:
+
+    store := NewMemoryProcessedMessageStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "options.ttl", 3600000,
+    ))
+
+    store.MarkProcessed("123", "message1")
+    processed, err := store.IsProcessed("123", "message1") // Result: true
+*/
+type MemoryProcessedMessageStore struct {
+	ttl          time.Duration
+	claimTimeout time.Duration
+	processed    map[string]time.Time
+	processing   map[string]time.Time
+	lastClean    time.Time
+	lock         sync.Mutex
+}
+
+// NewMemoryProcessedMessageStore method are creates a new instance of the store.
+// Returns: *MemoryProcessedMessageStore
+func NewMemoryProcessedMessageStore() *MemoryProcessedMessageStore {
+	c := MemoryProcessedMessageStore{
+		ttl:          24 * time.Hour,
+		claimTimeout: 60 * time.Second,
+		processed:    map[string]time.Time{},
+		processing:   map[string]time.Time{},
+		lastClean:    time.Now(),
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MemoryProcessedMessageStore) Configure(config *cconf.ConfigParams) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ttl = time.Duration(config.GetAsLongWithDefault("options.ttl", int64(c.ttl/time.Millisecond))) * time.Millisecond
+	c.claimTimeout = time.Duration(config.GetAsLongWithDefault("options.claim_timeout", int64(c.claimTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsProcessed method are checks if a message with the given id was processed within the time to live.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was processed or error.
+func (c *MemoryProcessedMessageStore) IsProcessed(correlationId string, messageId string) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	expiration, ok := c.processed[messageId]
+	return ok && expiration.After(time.Now()), nil
+}
+
+// TryMarkProcessing method are atomically claims a message with the given id for processing.
+// The claim fails when the message was processed or is claimed by another consumer within the claim timeout.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was claimed or error.
+func (c *MemoryProcessedMessageStore) TryMarkProcessing(correlationId string, messageId string) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if expiration, ok := c.processed[messageId]; ok && expiration.After(now) {
+		return false, nil
+	}
+	if expiration, ok := c.processing[messageId]; ok && expiration.After(now) {
+		return false, nil
+	}
+	c.processing[messageId] = now.Add(c.claimTimeout)
+	return true, nil
+}
+
+// UnmarkProcessing method are releases a claim of a message that failed to be processed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *MemoryProcessedMessageStore) UnmarkProcessing(correlationId string, messageId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.processing, messageId)
+	return nil
+}
+
+// MarkProcessed method are remembers that a message with the given id was processed and releases its claim.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *MemoryProcessedMessageStore) MarkProcessed(correlationId string, messageId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.mark(messageId, time.Now().Add(c.ttl))
+	return nil
+}
+
+// Clear method are forgets all processed messages.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *MemoryProcessedMessageStore) Clear(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.processed = map[string]time.Time{}
+	c.processing = map[string]time.Time{}
+	return nil
+}
+
+// mark remembers the message id until the expiration time.
+// Expired ids are removed at most once per time to live to keep marking fast.
+func (c *MemoryProcessedMessageStore) mark(messageId string, expiration time.Time) {
+	now := time.Now()
+	if now.Sub(c.lastClean) >= c.ttl {
+		for id, idExpiration := range c.processed {
+			if !idExpiration.After(now) {
+				delete(c.processed, id)
+			}
+		}
+		for id, idExpiration := range c.processing {
+			if !idExpiration.After(now) {
+				delete(c.processing, id)
+			}
+		}
+		c.lastClean = now
+	}
+
+	delete(c.processing, messageId)
+
+	if expiration.After(now) {
+		c.processed[messageId] = expiration
+	}
+}
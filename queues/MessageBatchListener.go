@@ -0,0 +1,167 @@
+package queues
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+)
+
+/*
+MessageBatchListener Listens for incoming messages in a queue and passes them to IMessageBatchReceiver in batches.
+Messages are received with ReceiveBatch, so a batch contains messages that are available at the moment,
+up to the batch size. A batch is passed to the receiver as soon as at least one message comes.
+When receiving fails the listener waits for wait_timeout before the next attempt.
+When the receiver panics the batch is abandoned, so its messages can be received again.
+
+Configuration parameters:
+
+  - options:
+    - batch_size:                maximum number of messages in a batch (default: 100)
+    - wait_timeout:              timeout in milliseconds to wait for messages to come (default: 1000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+
+See IMessageBatchReceiver
+See IMessageQueue
+
+Example:
+
+    listener := NewMessageBatchListener(messageQueue, NewMyBatchReceiver())
+    listener.Configure(cconf.NewConfigParamsFromTuples(
+        "options.batch_size", 500,
+    ))
+    listener.BeginListen("123")
+    ...
+    listener.EndListen("123")
+*/
+type MessageBatchListener struct {
+	Logger      *clog.CompositeLogger
+	Counters    *ccount.CompositeCounters
+	queue       IMessageQueue
+	receiver    IMessageBatchReceiver
+	batchSize   int64
+	waitTimeout time.Duration
+	cancel      int32
+	lock        sync.Mutex
+}
+
+// NewMessageBatchListener method are creates a new instance of the listener.
+//   - queue       a queue to receive messages from.
+//   - receiver    a receiver to process batches of messages.
+// Returns: *MessageBatchListener
+func NewMessageBatchListener(queue IMessageQueue, receiver IMessageBatchReceiver) *MessageBatchListener {
+	c := MessageBatchListener{
+		Logger:      clog.NewCompositeLogger(),
+		Counters:    ccount.NewCompositeCounters(),
+		queue:       queue,
+		receiver:    receiver,
+		batchSize:   100,
+		waitTimeout: 1000 * time.Millisecond,
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *MessageBatchListener) Configure(config *cconf.ConfigParams) {
+	c.Logger.Configure(config)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.batchSize = config.GetAsLongWithDefault("options.batch_size", c.batchSize)
+	c.waitTimeout = time.Duration(config.GetAsLongWithDefault("options.wait_timeout", int64(c.waitTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *MessageBatchListener) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until EndListen is called.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+// See IMessageBatchReceiver
+// See EndListen
+func (c *MessageBatchListener) Listen(correlationId string) error {
+	// Unset cancellation token
+	atomic.StoreInt32(&c.cancel, 0)
+	return c.listen(correlationId)
+}
+
+func (c *MessageBatchListener) listen(correlationId string) error {
+	c.lock.Lock()
+	batchSize := c.batchSize
+	waitTimeout := c.waitTimeout
+	c.lock.Unlock()
+
+	c.Logger.Trace(correlationId, "Started listening message batches at %s", c.queue.Name())
+
+	for atomic.LoadInt32(&c.cancel) == 0 {
+		messages, err := c.queue.ReceiveBatch(correlationId, batchSize, waitTimeout)
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to receive messages")
+			time.Sleep(waitTimeout)
+			continue
+		}
+
+		if len(messages) > 0 && atomic.LoadInt32(&c.cancel) == 0 {
+			c.receive(correlationId, messages)
+		} else if len(messages) > 0 {
+			// Messages received after cancellation are returned for other consumers
+			c.queue.AbandonBatch(messages)
+		}
+	}
+
+	c.Logger.Trace(correlationId, "Stopped listening message batches at %s", c.queue.Name())
+	return nil
+}
+
+func (c *MessageBatchListener) receive(correlationId string, messages []*MessageEnvelope) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Sprintf("%v", r)
+			c.Logger.Error(correlationId, nil, "Failed to process the messages - "+err)
+			// Messages are returned right away instead of waiting for their locks to expire
+			c.queue.AbandonBatch(messages)
+		}
+	}()
+
+	c.Counters.IncrementOne("queue." + c.queue.Name() + ".received_batches")
+	err := c.receiver.ReceiveMessages(messages, c.queue)
+	if err != nil {
+		c.Logger.Error(correlationId, err, "Failed to process the messages")
+	}
+}
+
+// BeginListen method are listens for incoming messages without blocking the current thread.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// See Listen
+func (c *MessageBatchListener) BeginListen(correlationId string) {
+	// Unset cancellation token before, so EndListen called right after is not lost
+	atomic.StoreInt32(&c.cancel, 0)
+	go func() {
+		err := c.listen(correlationId)
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to listen the message queue "+c.queue.Name())
+		}
+	}()
+}
+
+// EndListen method are ends listening for incoming messages.
+// Listen returns after the current batch is processed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *MessageBatchListener) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
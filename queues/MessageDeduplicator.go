@@ -0,0 +1,122 @@
+package queues
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupEntry is a deduplication key with the time it was seen.
+type dedupEntry struct {
+	key  string
+	time time.Time
+}
+
+/*
+MessageDeduplicator Remembers keys of sent messages for a sliding time window
+to detect duplicates created by producers that retry sending after a timeout.
+
+A message key is the value of the deduplication header when it is set,
+or the message id otherwise. Messages without a key are never treated as duplicates.
+Keys are forgotten when the window passes since they were seen first.
+
+See MessageQueue
+
+Example:
+
+    deduplicator := NewMessageDeduplicator(10*time.Minute, "")
+
+    envelope := NewMessageEnvelope("123", "mymessage", []byte("ABC"))
+    deduplicator.IsDuplicate(envelope) // Result: false
+    deduplicator.IsDuplicate(envelope) // Result: true
+*/
+type MessageDeduplicator struct {
+	window  time.Duration
+	header  string
+	keys    map[string]time.Time
+	entries []dedupEntry
+	lock    sync.Mutex
+}
+
+// NewMessageDeduplicator method are creates a new instance of the deduplicator.
+//   - window    a time window to remember message keys.
+//   - header    (optional) a header with deduplication keys, message ids are used when it is empty.
+// Returns: *MessageDeduplicator
+func NewMessageDeduplicator(window time.Duration, header string) *MessageDeduplicator {
+	c := MessageDeduplicator{
+		window:  window,
+		header:  header,
+		keys:    map[string]time.Time{},
+		entries: []dedupEntry{},
+	}
+	return &c
+}
+
+// Window method are gets the time window to remember message keys.
+// Returns: the deduplication window.
+func (c *MessageDeduplicator) Window() time.Duration {
+	return c.window
+}
+
+// Header method are gets the header with deduplication keys.
+// Returns: the header name or empty string when message ids are used.
+func (c *MessageDeduplicator) Header() string {
+	return c.header
+}
+
+// GetKey method are gets the deduplication key of a message.
+//   - envelope    a message envelope.
+// Returns: the deduplication key or empty string if the message has no key.
+func (c *MessageDeduplicator) GetKey(envelope *MessageEnvelope) string {
+	if c.header != "" {
+		if key := envelope.GetHeader(c.header); key != "" {
+			return key
+		}
+	}
+	return envelope.MessageId
+}
+
+// IsDuplicate method are checks if a message with the same key was seen within the window.
+// Keys of new messages are remembered, so the following calls for them return true.
+//   - envelope    a message envelope to check.
+// Returns: true if the message is a duplicate and false otherwise.
+func (c *MessageDeduplicator) IsDuplicate(envelope *MessageEnvelope) bool {
+	key := c.GetKey(envelope)
+	if key == "" {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	c.expire(now)
+
+	if _, ok := c.keys[key]; ok {
+		return true
+	}
+	c.keys[key] = now
+	c.entries = append(c.entries, dedupEntry{key: key, time: now})
+	return false
+}
+
+// Clear method are forgets all remembered keys.
+func (c *MessageDeduplicator) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.keys = map[string]time.Time{}
+	c.entries = []dedupEntry{}
+}
+
+// expire removes keys that were seen before the window.
+// Entries are kept in the order they were seen, so only the oldest ones are checked.
+func (c *MessageDeduplicator) expire(now time.Time) {
+	expired := 0
+	for expired < len(c.entries) && now.Sub(c.entries[expired].time) >= c.window {
+		delete(c.keys, c.entries[expired].key)
+		expired++
+	}
+	if expired > 0 {
+		c.entries = c.entries[expired:]
+	}
+}
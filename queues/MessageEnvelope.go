@@ -29,6 +29,11 @@ type MessageEnvelope struct {
 	SentTime time.Time `json:"sent_time"`
 	//The stored message.
 	Message []byte `json:"message"`
+	// Optional message headers (metadata) that are sent along with the message.
+	Headers map[string]string `json:"headers"`
+	// Optional key of the message group (session). Queues that support groups
+	// deliver messages of the same group one at a time in the order they were sent.
+	GroupId string `json:"group_id"`
 }
 
 // NewMessageEnvelope method are creates an empty MessageEnvelope
@@ -64,6 +69,23 @@ func (c *MessageEnvelope) SetReference(value interface{}) {
 	c.reference = value
 }
 
+// GetHeader method are returns the value of a message header.
+//   - name    the header name.
+// Returns: the header value or empty string when the header is not set.
+func (c *MessageEnvelope) GetHeader(name string) string {
+	return c.Headers[name]
+}
+
+// SetHeader method are sets the value of a message header.
+//   - name    the header name.
+//   - value   the header value.
+func (c *MessageEnvelope) SetHeader(name string, value string) {
+	if c.Headers == nil {
+		c.Headers = map[string]string{}
+	}
+	c.Headers[name] = value
+}
+
 // GetMessageAsString method are returns the information stored in this message as a string.
 func (c *MessageEnvelope) GetMessageAsString() string {
 	return string(c.Message)
@@ -165,6 +187,14 @@ func (c *MessageEnvelope) MarshalJSON() ([]byte, error) {
 		jsonData["message"] = string(base64Text)
 	}
 
+	if len(c.Headers) > 0 {
+		jsonData["headers"] = c.Headers
+	}
+
+	if c.GroupId != "" {
+		jsonData["group_id"] = c.GroupId
+	}
+
 	return json.Marshal(jsonData)
 }
 
@@ -190,5 +220,17 @@ func (c *MessageEnvelope) UnmarshalJSON(data []byte) error {
 		c.Message = data[:len]
 	}
 
+	headers, ok := jsonData["headers"].(map[string]interface{})
+	if ok {
+		c.Headers = map[string]string{}
+		for name, value := range headers {
+			c.Headers[name] = cconv.StringConverter.ToString(value)
+		}
+	}
+
+	if groupId, ok := jsonData["group_id"].(string); ok {
+		c.GroupId = groupId
+	}
+
 	return nil
 }
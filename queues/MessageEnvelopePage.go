@@ -0,0 +1,90 @@
+package queues
+
+import (
+	"bytes"
+	"math"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+// MessageEnvelopePage data object that is used to return pages of browsed messages.
+// See: IMessageQueueInspector
+type MessageEnvelopePage struct {
+	// The total number of messages that match the filter, when it was requested.
+	Total *int64 `json:"total"`
+	// The messages of the page.
+	Data []*MessageEnvelope `json:"data"`
+}
+
+// NewMessageEnvelopePage method are creates a new page of messages.
+//   - total     (optional) the total number of messages.
+//   - data      the messages of the page.
+// Returns: *MessageEnvelopePage
+func NewMessageEnvelopePage(total *int64, data []*MessageEnvelope) *MessageEnvelopePage {
+	return &MessageEnvelopePage{Total: total, Data: data}
+}
+
+// filterMessagePage selects a page of messages that match the filter.
+// Messages in the page are copies of the original messages.
+// When paging does not set the number of messages to take, up to 100 messages are returned.
+func filterMessagePage(messages []*MessageEnvelope, filter *MessageFilter, paging *cdata.PagingParams) *MessageEnvelopePage {
+	if paging == nil {
+		paging = cdata.NewEmptyPagingParams()
+	}
+	skip := paging.GetSkip(0)
+	take := int64(100)
+	if paging.Take != nil {
+		take = paging.GetTake(math.MaxInt64)
+	}
+
+	data := []*MessageEnvelope{}
+	total := int64(0)
+	for _, message := range messages {
+		if !filter.Match(message) {
+			continue
+		}
+		if total >= skip && int64(len(data)) < take {
+			data = append(data, copyMessage(message))
+		}
+		total++
+	}
+
+	if paging.Total {
+		return NewMessageEnvelopePage(&total, data)
+	}
+	return NewMessageEnvelopePage(nil, data)
+}
+
+// copyMessage creates a copy of the message that shares no data with it,
+// so changes in browsed messages do not change messages in the queue.
+func copyMessage(message *MessageEnvelope) *MessageEnvelope {
+	item := *message
+	item.SetReference(nil)
+	if message.Message != nil {
+		item.Message = append([]byte{}, message.Message...)
+	}
+	if message.Headers != nil {
+		item.Headers = make(map[string]string, len(message.Headers))
+		for name, value := range message.Headers {
+			item.Headers[name] = value
+		}
+	}
+	return &item
+}
+
+// sameMessage checks if a stored message is the one that was returned by browsing.
+// Browsed messages are copies, so all their fields are compared.
+func sameMessage(stored *MessageEnvelope, browsed *MessageEnvelope) bool {
+	if stored.MessageId != browsed.MessageId || stored.CorrelationId != browsed.CorrelationId ||
+		stored.MessageType != browsed.MessageType || stored.GroupId != browsed.GroupId ||
+		!stored.SentTime.Equal(browsed.SentTime) || !bytes.Equal(stored.Message, browsed.Message) ||
+		len(stored.Headers) != len(browsed.Headers) {
+		return false
+	}
+	for name, value := range stored.Headers {
+		if browsedValue, ok := browsed.Headers[name]; !ok || browsedValue != value {
+			return false
+		}
+	}
+	return true
+}
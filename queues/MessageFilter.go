@@ -0,0 +1,115 @@
+package queues
+
+import (
+	"strings"
+	"time"
+
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+)
+
+/*
+MessageFilter Selects messages by filter parameters used to browse, move and redrive messages.
+All set conditions must match.
+
+Filter parameters:
+
+  - message_id:                  message id
+  - correlation_id:              correlation id
+  - message_type:                message type or comma separated list of types
+  - group_id:                    key of the message group
+  - min_age:                     minimum age of messages in milliseconds
+  - max_age:                     maximum age of messages in milliseconds
+  - header.{name}:               value of the named message header
+  - dead_letter:                 true to select messages in the dead letter queue (default: false)
+
+Example:
+
+    filter := NewMessageFilter(cdata.NewFilterParamsFromTuples(
+        "message_type", "order",
+        "header.tenant", "tenant1",
+    ))
+    if filter.Match(envelope) {
+        ...
+    }
+*/
+type MessageFilter struct {
+	MessageId     string
+	CorrelationId string
+	MessageTypes  []string
+	GroupId       string
+	MinAge        time.Duration
+	MaxAge        time.Duration
+	Headers       map[string]string
+	DeadLetter    bool
+}
+
+// NewMessageFilter method are creates a new message filter from filter parameters.
+//   - filter    (optional) filter parameters.
+// Returns: *MessageFilter
+func NewMessageFilter(filter *cdata.FilterParams) *MessageFilter {
+	c := MessageFilter{
+		Headers: map[string]string{},
+	}
+	if filter == nil {
+		return &c
+	}
+
+	c.MessageId = filter.GetAsString("message_id")
+	c.CorrelationId = filter.GetAsString("correlation_id")
+	for _, messageType := range strings.Split(filter.GetAsString("message_type"), ",") {
+		if messageType = strings.TrimSpace(messageType); messageType != "" {
+			c.MessageTypes = append(c.MessageTypes, messageType)
+		}
+	}
+	c.GroupId = filter.GetAsString("group_id")
+	c.MinAge = time.Duration(filter.GetAsLong("min_age")) * time.Millisecond
+	c.MaxAge = time.Duration(filter.GetAsLong("max_age")) * time.Millisecond
+	c.DeadLetter = filter.GetAsBoolean("dead_letter")
+
+	for _, key := range filter.Keys() {
+		if strings.HasPrefix(key, "header.") {
+			c.Headers[strings.TrimPrefix(key, "header.")] = filter.GetAsString(key)
+		}
+	}
+
+	return &c
+}
+
+// Match method are checks if the message matches the filter.
+//   - envelope  a message to check.
+// Returns: true if the message matches all filter conditions.
+func (c *MessageFilter) Match(envelope *MessageEnvelope) bool {
+	if c.MessageId != "" && envelope.MessageId != c.MessageId {
+		return false
+	}
+	if c.CorrelationId != "" && envelope.CorrelationId != c.CorrelationId {
+		return false
+	}
+	if c.GroupId != "" && envelope.GroupId != c.GroupId {
+		return false
+	}
+	if len(c.MessageTypes) > 0 {
+		found := false
+		for _, messageType := range c.MessageTypes {
+			found = found || envelope.MessageType == messageType
+		}
+		if !found {
+			return false
+		}
+	}
+	if c.MinAge > 0 || c.MaxAge > 0 {
+		age := time.Since(envelope.SentTime)
+		if c.MinAge > 0 && age < c.MinAge {
+			return false
+		}
+		if c.MaxAge > 0 && age > c.MaxAge {
+			return false
+		}
+	}
+	for name, value := range c.Headers {
+		if envelope.GetHeader(name) != value {
+			return false
+		}
+	}
+	return true
+}
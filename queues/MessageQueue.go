@@ -1,7 +1,10 @@
 package queues
 
 import (
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
@@ -41,6 +44,14 @@ Configuration parameters:
     - password:                  user password
     - access_id:                 application access id
     - access_key:                application secret key
+  - options:
+    - dedup_window:              time window in milliseconds to skip duplicate messages, 0 to turn deduplication off (default: 0)
+    - dedup_header:              header with deduplication keys, message ids are used when it is not set (default: none)
+    - listen_rate:               maximum number of messages per second received by Listen, 0 for no limit (default: 0)
+    - listen_burst:              maximum number of messages received by Listen at once after a pause (default: 1)
+
+Deduplication is applied only by queues that support it.
+The listen rate can be changed at runtime with SetListenRate, so consumers do not overload their dependencies.
 
 References:
 
@@ -58,6 +69,8 @@ type MessageQueue struct {
 	Lock               sync.Mutex
 	name               string
 	capabilities       *MessagingCapabilities
+	deduplicator       *MessageDeduplicator
+	rateLimiter        *RateLimiter
 }
 
 // NewMessageQueue method are creates a new instance of the message queue.
@@ -69,6 +82,7 @@ func InheritMessageQueue(overrides IMessageQueueOverrides, name string, capabili
 		Overrides:    overrides,
 		name:         name,
 		capabilities: capabilities,
+		rateLimiter:  NewRateLimiter(0, 1),
 	}
 	c.Logger = clog.NewCompositeLogger()
 	c.Counters = ccount.NewCompositeCounters()
@@ -103,6 +117,17 @@ func (c *MessageQueue) Configure(config *cconf.ConfigParams) {
 
 	c.name = cconf.NameResolver.ResolveWithDefault(config, c.name)
 	c.name = config.GetAsStringWithDefault("queue", c.name)
+
+	dedupWindow := config.GetAsLongWithDefault("options.dedup_window", 0)
+	dedupHeader := config.GetAsStringWithDefault("options.dedup_header", "")
+	c.deduplicator = nil
+	if dedupWindow > 0 {
+		c.deduplicator = NewMessageDeduplicator(time.Duration(dedupWindow)*time.Millisecond, dedupHeader)
+	}
+
+	listenRate := config.GetAsDoubleWithDefault("options.listen_rate", 0)
+	listenBurst := config.GetAsLongWithDefault("options.listen_burst", 1)
+	c.rateLimiter.SetRate(listenRate, listenBurst)
 }
 
 // SetReferences mmethod are sets references to dependent components.
@@ -160,6 +185,119 @@ func (c *MessageQueue) CheckOpen(correlationId string) error {
 	return nil
 }
 
+// SetDeduplicator method are sets a deduplicator to skip duplicate messages.
+// Usually the deduplicator is created from configuration parameters.
+//   - deduplicator    a deduplicator or nil to turn deduplication off.
+func (c *MessageQueue) SetDeduplicator(deduplicator *MessageDeduplicator) {
+	c.deduplicator = deduplicator
+}
+
+// Deduplicator method are gets the deduplicator used by the queue.
+// Returns: the deduplicator or nil if deduplication is off.
+func (c *MessageQueue) Deduplicator() *MessageDeduplicator {
+	return c.deduplicator
+}
+
+// IsDuplicate method are checks if the message is a duplicate of another one sent within the deduplication window.
+// Queues that support deduplication call it before they send messages.
+//   - envelope    a message envelope to be sent.
+// Returns: true if the message shall be skipped and false otherwise.
+// See MessageDeduplicator
+func (c *MessageQueue) IsDuplicate(envelope *MessageEnvelope) bool {
+	if c.deduplicator == nil || c.capabilities == nil || !c.capabilities.CanDeduplicate() {
+		return false
+	}
+	return c.deduplicator.IsDuplicate(envelope)
+}
+
+// SetListenRate method are changes the maximum rate of messages received by Listen.
+// The new rate is applied to listening that is already in progress.
+//   - messagesPerSecond     a maximum number of messages per second, 0 for no limit.
+//   - burst                 a maximum number of messages received at once after a pause.
+func (c *MessageQueue) SetListenRate(messagesPerSecond float64, burst int64) {
+	c.rateLimiter.SetRate(messagesPerSecond, burst)
+}
+
+// RateLimiter method are gets the rate limiter of Listen.
+// Returns: the rate limiter.
+func (c *MessageQueue) RateLimiter() *RateLimiter {
+	return c.rateLimiter
+}
+
+// ThrottleListen method are waits until the next message can be passed to the receiver by Listen.
+// It is called by queue implementations after a message is received, so polls without messages
+// are not throttled. The time spent waiting is recorded in the "queue.<name>.throttled_time" counter.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - cancel            a cancellation token of the listening loop, waiting stops when it is set.
+// Returns: false if listening was cancelled while waiting.
+func (c *MessageQueue) ThrottleListen(correlationId string, cancel *int32) bool {
+	throttled, ok := c.rateLimiter.Wait(func() bool {
+		return atomic.LoadInt32(cancel) != 0
+	})
+	if throttled > 0 {
+		c.Counters.Stats("queue."+c.Name()+".throttled_time", float32(throttled.Milliseconds()))
+		c.Logger.Trace(correlationId, "Throttled listening at %s for %s", c.Name(), throttled)
+	}
+	return ok
+}
+
+// ListenMessages method are receives messages and passes them to the receiver until listening is cancelled.
+// It is called by queue implementations in their Listen methods. Received messages are throttled
+// by ThrottleListen, and messages received after listening was cancelled are returned into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+//   - cancel            a cancellation token of the listening loop. It is unset when listening starts.
+// Returns: error or nil for success.
+func (c *MessageQueue) ListenMessages(correlationId string, receiver IMessageReceiver, cancel *int32) error {
+	c.Logger.Trace("", "Started listening messages at %s", c.String())
+
+	// Unset cancellation token
+	atomic.StoreInt32(cancel, 0)
+
+	for atomic.LoadInt32(cancel) == 0 {
+		message, err := c.Overrides.Receive(correlationId, time.Duration(1000)*time.Millisecond)
+		if err != nil {
+			c.Logger.Error(correlationId, err, "Failed to receive the message")
+			time.Sleep(time.Duration(1000) * time.Millisecond)
+			continue
+		}
+		if message == nil {
+			continue
+		}
+
+		if atomic.LoadInt32(cancel) != 0 || !c.ThrottleListen(correlationId, cancel) {
+			err = c.Overrides.Abandon(message)
+			if err != nil {
+				c.Logger.Error(correlationId, err, "Failed to return the message")
+			}
+			break
+		}
+
+		c.DeliverMessage(correlationId, receiver, message)
+	}
+
+	return nil
+}
+
+// DeliverMessage method are passes a received message to the receiver.
+// Errors and panics of the receiver are logged and do not stop listening.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive the message.
+//   - message           a received message.
+func (c *MessageQueue) DeliverMessage(correlationId string, receiver IMessageReceiver, message *MessageEnvelope) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Sprintf("%v", r)
+			c.Logger.Error(correlationId, nil, "Failed to process the message - "+err)
+		}
+	}()
+
+	err := receiver.ReceiveMessage(message, c.Overrides)
+	if err != nil {
+		c.Logger.Error(correlationId, err, "Failed to process the message")
+	}
+}
+
 // SendAsObject method are sends an object into the queue.
 // Before sending the object is converted into JSON string and wrapped in a MessageEnvelop.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
@@ -173,6 +311,78 @@ func (c *MessageQueue) SendAsObject(correlationId string, messageType string, me
 	return c.Overrides.Send(correlationId, envelope)
 }
 
+// SendBatch method are sends multiple messages into the queue one by one.
+// Sending stops at the first error, so messages before it stay in the queue.
+// Queues that can send batches atomically override this method and report it
+// with MessagingCapabilities.WithAtomicBatches.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelopes         a list of message envelops to be sent.
+// Returns: error or nil for success.
+func (c *MessageQueue) SendBatch(correlationId string, envelopes []*MessageEnvelope) error {
+	for _, envelope := range envelopes {
+		if err := c.Overrides.Send(correlationId, envelope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReceiveBatch method are receives multiple incoming messages one by one.
+// It waits for the first message up to the wait timeout and then receives messages without waiting.
+// Messages received before an error are returned together with the error.
+// Queues that can receive messages in one call override this method.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to receive.
+//   - waitTimeout       a timeout in milliseconds to wait for the first message to come.
+// Returns: list with messages or error.
+func (c *MessageQueue) ReceiveBatch(correlationId string, messageCount int64, waitTimeout time.Duration) ([]*MessageEnvelope, error) {
+	messages := []*MessageEnvelope{}
+	for int64(len(messages)) < messageCount {
+		timeout := waitTimeout
+		if len(messages) > 0 {
+			timeout = 0
+		}
+
+		message, err := c.Overrides.Receive(correlationId, timeout)
+		if err != nil {
+			return messages, err
+		}
+		if message == nil {
+			break
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// CompleteBatch method are permanently removes multiple messages from the queue one by one.
+// All messages are tried and the first error is returned.
+//   - messages  a list of messages to remove.
+// Returns: error or nil for success.
+func (c *MessageQueue) CompleteBatch(messages []*MessageEnvelope) error {
+	var result error
+	for _, message := range messages {
+		if err := c.Overrides.Complete(message); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// AbandonBatch method are returns multiple messages into the queue one by one.
+// All messages are tried and the first error is returned.
+//   - messages  a list of messages to return.
+// Returns: error or nil for success.
+func (c *MessageQueue) AbandonBatch(messages []*MessageEnvelope) error {
+	var result error
+	for _, message := range messages {
+		if err := c.Overrides.Abandon(message); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
 // BeginListen method are listens for incoming messages without blocking the current thread.
 //   - correlationId     (optional) transaction id to trace execution through call chain.
 //   - receiver          a receiver to receive incoming messages.
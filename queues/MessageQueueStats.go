@@ -0,0 +1,18 @@
+package queues
+
+import "time"
+
+// MessageQueueStats data object with the state of a message queue used by administration tools.
+// See: IMessageQueueInspector
+type MessageQueueStats struct {
+	// The name of the queue.
+	Name string `json:"name"`
+	// The number of messages available for delivery.
+	MessageCount int64 `json:"message_count"`
+	// The number of received messages that are locked and not completed yet.
+	LockedCount int64 `json:"locked_count"`
+	// The number of messages in the dead letter queue.
+	DeadLetterCount int64 `json:"dead_letter_count"`
+	// The age of the oldest message available for delivery or 0 when the queue is empty.
+	OldestMessageAge time.Duration `json:"oldest_message_age"`
+}
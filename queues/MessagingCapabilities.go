@@ -3,15 +3,18 @@ package queues
 // MessagingCapabilities data object that contains supported capabilities of a message queue.
 // If certain capability is not supported a queue will throw NotImplemented exception.
 type MessagingCapabilities struct {
-	canMessageCount bool
-	canSend         bool
-	canReceive      bool
-	canPeek         bool
-	canPeekBatch    bool
-	canRenewLock    bool
-	canAbandon      bool
-	canDeadLetter   bool
-	canClear        bool
+	canMessageCount    bool
+	canSend            bool
+	canReceive         bool
+	canPeek            bool
+	canPeekBatch       bool
+	canRenewLock       bool
+	canAbandon         bool
+	canDeadLetter      bool
+	canClear           bool
+	canDeduplicate     bool
+	canTransact        bool
+	canSendAtomicBatch bool
 }
 
 // NewMessagingCapabilities method are creates a new instance of the capabilities object.
@@ -95,3 +98,50 @@ func (c *MessagingCapabilities) CanDeadLetter() bool {
 func (c *MessagingCapabilities) CanClear() bool {
 	return c.canClear
 }
+
+// WithDeduplication method are sets if the queue is able to skip duplicate messages.
+//   - canDeduplicate    true if queue is able to skip duplicate messages.
+// Returns: the capabilities object.
+func (c *MessagingCapabilities) WithDeduplication(canDeduplicate bool) *MessagingCapabilities {
+	c.canDeduplicate = canDeduplicate
+	return c
+}
+
+// CanDeduplicate method are informs if the queue is able to skip duplicate messages sent within a deduplication window.
+// Returns: true if queue is able to skip duplicate messages.
+func (c *MessagingCapabilities) CanDeduplicate() bool {
+	return c.canDeduplicate
+}
+
+// WithTransactions method are sets if the queue is able to send and complete messages in transactions.
+//   - canTransact    true if queue supports transactions.
+// Returns: the capabilities object.
+func (c *MessagingCapabilities) WithTransactions(canTransact bool) *MessagingCapabilities {
+	c.canTransact = canTransact
+	return c
+}
+
+// CanTransact method are informs if the queue is able to send and complete messages in transactions.
+// Returns: true if queue supports transactions.
+// See ITransactionalMessageQueue
+func (c *MessagingCapabilities) CanTransact() bool {
+	return c.canTransact
+}
+
+// WithAtomicBatches method are sets if the queue is able to send a batch of messages all at once.
+//   - canSendAtomicBatch    true if a batch is sent entirely or not sent at all.
+// Returns: the capabilities object.
+func (c *MessagingCapabilities) WithAtomicBatches(canSendAtomicBatch bool) *MessagingCapabilities {
+	c.canSendAtomicBatch = canSendAtomicBatch
+	return c
+}
+
+// CanSendAtomicBatch method are informs if the queue is able to send a batch of messages all at once.
+// When it is false a batch can be sent partially when sending fails.
+// Only MemoryMessageQueue sends atomic batches, all other queues send batches
+// one message at a time and report false.
+// Returns: true if a batch is sent entirely or not sent at all.
+// See IMessageQueue.SendBatch
+func (c *MessagingCapabilities) CanSendAtomicBatch() bool {
+	return c.canSendAtomicBatch
+}
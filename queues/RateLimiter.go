@@ -0,0 +1,150 @@
+package queues
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+RateLimiter Token bucket rate limiter that limits how often an operation is performed.
+The bucket holds up to burst tokens and is refilled at the given rate.
+Every operation takes one token and waits when the bucket is empty.
+The rate can be changed at any time, and waiting operations follow the new rate.
+
+Example:
+
+    limiter := NewRateLimiter(10, 5)
+    for {
+        throttled, ok := limiter.Wait(nil)
+        ...
+    }
+*/
+type RateLimiter struct {
+	rate          float64
+	burst         int64
+	tokens        float64
+	updated       time.Time
+	throttledTime time.Duration
+	lock          sync.Mutex
+}
+
+// rateLimiterCheckInterval is the longest sleep before cancellation and rate changes are checked.
+const rateLimiterCheckInterval = 100 * time.Millisecond
+
+// NewRateLimiter method are creates a new instance of the rate limiter.
+//   - rate      a maximum number of operations per second, 0 for no limit.
+//   - burst     a maximum number of operations performed at once after a pause, at least 1.
+// Returns: *RateLimiter
+func NewRateLimiter(rate float64, burst int64) *RateLimiter {
+	c := RateLimiter{}
+	c.SetRate(rate, burst)
+	c.tokens = float64(c.burst)
+	return &c
+}
+
+// SetRate method are changes the rate and the burst of the limiter.
+//   - rate      a maximum number of operations per second, 0 for no limit.
+//   - burst     a maximum number of operations performed at once after a pause, at least 1.
+func (c *RateLimiter) SetRate(rate float64, burst int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.refill(time.Now())
+	if rate < 0 {
+		rate = 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	c.rate = rate
+	c.burst = burst
+	c.tokens = math.Min(c.tokens, float64(burst))
+}
+
+// Rate method are gets the maximum number of operations per second.
+// Returns: the rate or 0 when there is no limit.
+func (c *RateLimiter) Rate() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.rate
+}
+
+// Burst method are gets the maximum number of operations performed at once after a pause.
+// Returns: the burst size.
+func (c *RateLimiter) Burst() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.burst
+}
+
+// ThrottledTime method are gets the total time operations waited for the limiter.
+// Returns: the throttled time.
+func (c *RateLimiter) ThrottledTime() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.throttledTime
+}
+
+// TryAcquire method are takes a token when it is available without waiting.
+// Returns: true if the operation can be performed and false otherwise.
+func (c *RateLimiter) TryAcquire() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.acquire(time.Now()) == 0
+}
+
+// Wait method are waits until a token is available and takes it.
+//   - cancelled     (optional) a function that stops waiting when it returns true.
+// Returns: the time spent waiting and false when waiting was cancelled.
+func (c *RateLimiter) Wait(cancelled func() bool) (time.Duration, bool) {
+	start := time.Now()
+	for {
+		c.lock.Lock()
+		now := time.Now()
+		delay := c.acquire(now)
+		if delay == 0 {
+			throttled := now.Sub(start)
+			c.throttledTime += throttled
+			c.lock.Unlock()
+			return throttled, true
+		}
+		c.lock.Unlock()
+
+		if cancelled != nil && cancelled() {
+			return time.Since(start), false
+		}
+		// Sleep in short intervals to follow rate changes and cancellation
+		if delay > rateLimiterCheckInterval {
+			delay = rateLimiterCheckInterval
+		}
+		time.Sleep(delay)
+	}
+}
+
+// acquire takes a token or calculates the time until it is available. It shall be called under the lock.
+func (c *RateLimiter) acquire(now time.Time) time.Duration {
+	if c.rate == 0 {
+		return 0
+	}
+
+	c.refill(now)
+	if c.tokens >= 1 {
+		c.tokens--
+		return 0
+	}
+	return time.Duration((1 - c.tokens) / c.rate * float64(time.Second))
+}
+
+// refill adds tokens for the time passed since the last update. It shall be called under the lock.
+func (c *RateLimiter) refill(now time.Time) {
+	if !c.updated.IsZero() && c.rate > 0 {
+		elapsed := now.Sub(c.updated).Seconds()
+		c.tokens = math.Min(c.tokens+elapsed*c.rate, float64(c.burst))
+	}
+	c.updated = now
+}
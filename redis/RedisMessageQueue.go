@@ -0,0 +1,766 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+/*
+RedisMessageQueue message queue that is implemented on top of Redis Streams.
+
+Messages are appended to a stream and received through a consumer group.
+A received message stays in the pending entries list of the group until it is completed.
+The lock of a message is its idle time in the pending list: RenewLock claims the message
+again to reset the idle time, and messages idle longer than the lock timeout
+are found with XPENDING and claimed for redelivery.
+Messages moved to dead letter are appended to a separate stream.
+
+Configuration parameters:
+
+  - name:                        name of the message queue and its stream
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - host:                      host name or IP address
+    - port:                      port number (default: 6379)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - db:                        number of the Redis database (default: 0)
+    - group:                     name of the consumer group (default: pip-services)
+    - consumer:                  name of the consumer within the group (default: generated id)
+    - dead_letter:               name of the dead letter stream (default: <name>:dead)
+    - lock_timeout:              timeout in milliseconds to lock received messages (default: 30000)
+    - max_deliveries:            number of deliveries after which a message is moved to dead letter, 0 to disable (default: 0)
+    - timeout:                   timeout in milliseconds of Redis operations (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See MessageQueue
+See MessagingCapabilities
+
+Example:
+
+    queue := NewRedisMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 6379,
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type RedisMessageQueue struct {
+	queues.MessageQueue
+	client        *goredis.Client
+	db            int
+	group         string
+	consumer      string
+	deadLetter    string
+	lockTimeout   time.Duration
+	maxDeliveries int64
+	timeout       time.Duration
+	cancel        int32
+}
+
+// Names of stream entry fields
+const (
+	fieldMessageId     = "message_id"
+	fieldCorrelationId = "correlation_id"
+	fieldMessageType   = "message_type"
+	fieldSentTime      = "sent_time"
+	fieldGroupId       = "group_id"
+	fieldMessage       = "message"
+	// Headers are stored as separate fields with this prefix
+	fieldHeaderPrefix = "header."
+)
+
+// NewRedisMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *RedisMessageQueue
+// See MessagingCapabilities
+func NewRedisMessageQueue(name string) *RedisMessageQueue {
+	c := RedisMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.group = "pip-services"
+	c.consumer = cdata.IdGenerator.NextShort()
+	c.lockTimeout = 30000 * time.Millisecond
+	c.timeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *RedisMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.db = config.GetAsIntegerWithDefault("options.db", c.db)
+	c.group = config.GetAsStringWithDefault("options.group", c.group)
+	c.consumer = config.GetAsStringWithDefault("options.consumer", c.consumer)
+	c.deadLetter = config.GetAsStringWithDefault("options.dead_letter", c.deadLetter)
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+	c.maxDeliveries = config.GetAsLongWithDefault("options.max_deliveries", c.maxDeliveries)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *RedisMessageQueue) IsOpen() bool {
+	return c.client != nil
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *RedisMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.client != nil {
+		return nil
+	}
+
+	options, err := c.composeOptions(correlationId, connections[0], credential)
+	if err != nil {
+		return err
+	}
+
+	client := goredis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	err = client.Ping(ctx).Err()
+	if err == nil {
+		err = client.XGroupCreateMkStream(ctx, c.stream(), c.group, "0").Err()
+		if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			err = nil
+		}
+	}
+	if err != nil {
+		client.Close()
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to Redis server at "+options.Addr).
+			WithCause(err)
+	}
+
+	c.client = client
+	atomic.StoreInt32(&c.cancel, 0)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), options.Addr)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *RedisMessageQueue) Close(correlationId string) error {
+	if c.client == nil {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+
+	err := c.client.Close()
+	c.client = nil
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "DISCONNECT_FAILED", "Failed to disconnect from Redis server").
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are deletes the queue and dead letter streams and recreates the consumer group.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *RedisMessageQueue) Clear(correlationId string) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	err = c.client.Del(ctx, c.stream(), c.deadLetterStream()).Err()
+	if err == nil {
+		err = c.client.XGroupCreateMkStream(ctx, c.stream(), c.group, "0").Err()
+	}
+
+	return c.wrapError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Messages received by consumers and waiting for completion are not counted.
+// Returns: number of messages or error.
+func (c *RedisMessageQueue) ReadMessageCount() (int64, error) {
+	err := c.CheckOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	length, err := c.client.XLen(ctx, c.stream()).Result()
+	if err != nil {
+		return 0, c.wrapError("", err)
+	}
+	pending, err := c.client.XPending(ctx, c.stream(), c.group).Result()
+	if err != nil {
+		return 0, c.wrapError("", err)
+	}
+
+	count := length - pending.Count
+	if count < 0 {
+		count = 0
+	}
+	return count, nil
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *RedisMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	envelope.SentTime = time.Now()
+	err = c.client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: c.stream(),
+		Values: fromMessage(envelope),
+	}).Err()
+	if err != nil {
+		return c.wrapError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *RedisMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// Only messages that were not delivered to the consumer group are returned.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *RedisMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	// Completed messages are deleted from the stream,
+	// so undelivered messages are the ones after the last pending entry
+	start := "-"
+	pending, err := c.client.XPending(ctx, c.stream(), c.group).Result()
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+	if pending.Count > 0 {
+		start = "(" + pending.Higher
+	}
+
+	entries, err := c.client.XRangeN(ctx, c.stream(), start, "+", messageCount).Result()
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	messages := []*queues.MessageEnvelope{}
+	for _, entry := range entries {
+		messages = append(messages, toMessage(entry))
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+// Messages with expired locks are claimed for redelivery before new messages are read.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *RedisMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := c.claimExpired(correlationId)
+	if err != nil || message != nil {
+		return message, err
+	}
+
+	block := waitTimeout
+	if block <= 0 {
+		block = -1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout+waitTimeout)
+	defer cancel()
+
+	streams, err := c.client.XReadGroup(ctx, &goredis.XReadGroupArgs{
+		Group:    c.group,
+		Consumer: c.consumer,
+		Streams:  []string{c.stream(), ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if err == goredis.Nil || (err != nil && atomic.LoadInt32(&c.cancel) != 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	for _, stream := range streams {
+		for _, entry := range stream.Messages {
+			message = toMessage(entry)
+		}
+	}
+
+	if message != nil {
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	}
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// The message is claimed again to reset its idle time. Only messages still owned by this consumer
+// are renewed, otherwise LOCK_LOST conflict error is returned.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds. It cannot be longer than the lock_timeout option,
+//                   which is used when it is not set.
+// Returns:  error or nil for success.
+func (c *RedisMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	id, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	err := c.CheckOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	pending, err := c.client.XPendingExt(ctx, &goredis.XPendingExtArgs{
+		Stream:   c.stream(),
+		Group:    c.group,
+		Start:    id,
+		End:      id,
+		Count:    1,
+		Consumer: c.consumer,
+	}).Result()
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	if len(pending) == 0 {
+		return c.lockLostError(message)
+	}
+
+	// Locks expire when messages stay idle for lock_timeout,
+	// so shorter locks are set by starting with a longer idle time
+	idle := time.Duration(0)
+	if lockTimeout > 0 && lockTimeout < c.lockTimeout {
+		idle = c.lockTimeout - lockTimeout
+	}
+
+	// The minimum idle time fails the claim when another consumer claimed the message after the check.
+	// Retry count is kept, so renewals are not counted as deliveries
+	ids, err := c.client.Do(ctx, "XCLAIM", c.stream(), c.group, c.consumer,
+		int64(pending[0].Idle/time.Millisecond), id,
+		"IDLE", int64(idle/time.Millisecond),
+		"RETRYCOUNT", pending[0].RetryCount,
+		"JUSTID").StringSlice()
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	if len(ids) == 0 {
+		return c.lockLostError(message)
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *RedisMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	id, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	err := c.CheckOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err = c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.XAck(ctx, c.stream(), c.group, id)
+		pipe.XDel(ctx, c.stream(), id)
+		return nil
+	})
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// Redis Streams cannot return a pending entry into the group, so the message is
+// acknowledged and appended to the end of the stream in one transaction.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *RedisMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	id, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	err := c.CheckOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err = c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.XAck(ctx, c.stream(), c.group, id)
+		pipe.XDel(ctx, c.stream(), id)
+		pipe.XAdd(ctx, &goredis.XAddArgs{Stream: c.stream(), Values: fromMessage(message)})
+		return nil
+	})
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and appends it to the dead letter stream.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *RedisMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	id, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	err := c.CheckOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	err = c.moveToDeadLetter(id, message)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *RedisMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *RedisMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads messages from the dead letter stream of the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *RedisMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	entries, err := c.client.XRange(ctx, c.deadLetterStream(), "-", "+").Result()
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	messages := []*queues.MessageEnvelope{}
+	for _, entry := range entries {
+		message := toMessage(entry)
+		message.SetReference(nil)
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// claimExpired finds a pending message with expired lock and claims it for redelivery.
+// Messages delivered more times than allowed are moved to dead letter.
+func (c *RedisMessageQueue) claimExpired(correlationId string) (*queues.MessageEnvelope, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	for {
+		pending, err := c.client.XPendingExt(ctx, &goredis.XPendingExtArgs{
+			Stream: c.stream(),
+			Group:  c.group,
+			Idle:   c.lockTimeout,
+			Start:  "-",
+			End:    "+",
+			Count:  1,
+		}).Result()
+		if err != nil {
+			return nil, c.wrapError(correlationId, err)
+		}
+		if len(pending) == 0 {
+			return nil, nil
+		}
+
+		entries, err := c.client.XClaim(ctx, &goredis.XClaimArgs{
+			Stream:   c.stream(),
+			Group:    c.group,
+			Consumer: c.consumer,
+			MinIdle:  c.lockTimeout,
+			Messages: []string{pending[0].ID},
+		}).Result()
+		if err != nil {
+			return nil, c.wrapError(correlationId, err)
+		}
+		// Claimed by another consumer in the meantime
+		if len(entries) == 0 {
+			continue
+		}
+
+		message := toMessage(entries[0])
+		// Drop entries that were deleted from the stream
+		if entries[0].Values == nil {
+			c.client.XAck(ctx, c.stream(), c.group, entries[0].ID)
+			continue
+		}
+
+		if c.maxDeliveries > 0 && pending[0].RetryCount >= c.maxDeliveries {
+			if err = c.moveToDeadLetter(entries[0].ID, message); err != nil {
+				return nil, c.wrapError(correlationId, err)
+			}
+			c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+			c.Logger.Debug(message.CorrelationId, "Moved to dead message %s at %s after %d deliveries",
+				message, c.Name(), pending[0].RetryCount)
+			continue
+		}
+
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Redelivered message %s via %s", message, c.Name())
+
+		return message, nil
+	}
+}
+
+func (c *RedisMessageQueue) moveToDeadLetter(id string, message *queues.MessageEnvelope) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.XAdd(ctx, &goredis.XAddArgs{Stream: c.deadLetterStream(), Values: fromMessage(message)})
+		pipe.XAck(ctx, c.stream(), c.group, id)
+		pipe.XDel(ctx, c.stream(), id)
+		return nil
+	})
+	return err
+}
+
+func (c *RedisMessageQueue) lockLostError(message *queues.MessageEnvelope) error {
+	return cerr.NewConflictError(message.CorrelationId, "LOCK_LOST",
+		"Lock of message "+message.MessageId+" in queue "+c.Name()+" is lost").
+		WithDetails("message_id", message.MessageId)
+}
+
+func (c *RedisMessageQueue) stream() string {
+	return c.Name()
+}
+
+func (c *RedisMessageQueue) deadLetterStream() string {
+	if c.deadLetter != "" {
+		return c.deadLetter
+	}
+	return c.Name() + ":dead"
+}
+
+func (c *RedisMessageQueue) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+func (c *RedisMessageQueue) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to access Redis stream "+c.stream()).
+		WithCause(err)
+}
+
+// composeOptions composes Redis client options from connection and credential parameters.
+func (c *RedisMessageQueue) composeOptions(correlationId string, connection *cconn.ConnectionParams,
+	credential *cauth.CredentialParams) (*goredis.Options, error) {
+
+	var options *goredis.Options
+
+	if uri := connection.Uri(); uri != "" {
+		var err error
+		options, err = goredis.ParseURL(uri)
+		if err != nil {
+			return nil, cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid Redis connection uri").
+				WithCause(err)
+		}
+	} else {
+		host := connection.Host()
+		if host == "" {
+			return nil, cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+		}
+		options = &goredis.Options{
+			Addr: host + ":" + strconv.Itoa(connection.PortWithDefault(6379)),
+			DB:   c.db,
+		}
+	}
+
+	if credential != nil {
+		if credential.Username() != "" {
+			options.Username = credential.Username()
+		}
+		if credential.Password() != "" {
+			options.Password = credential.Password()
+		}
+	}
+
+	return options, nil
+}
+
+func fromMessage(message *queues.MessageEnvelope) map[string]interface{} {
+	values := map[string]interface{}{
+		fieldMessageId:     message.MessageId,
+		fieldCorrelationId: message.CorrelationId,
+		fieldMessageType:   message.MessageType,
+		fieldSentTime:      message.SentTime.UnixMilli(),
+		fieldMessage:       message.Message,
+	}
+	if message.GroupId != "" {
+		values[fieldGroupId] = message.GroupId
+	}
+	for name, value := range message.Headers {
+		values[fieldHeaderPrefix+name] = value
+	}
+	return values
+}
+
+func toMessage(entry goredis.XMessage) *queues.MessageEnvelope {
+	message := queues.NewEmptyMessageEnvelope()
+	message.MessageId, _ = entry.Values[fieldMessageId].(string)
+	message.CorrelationId, _ = entry.Values[fieldCorrelationId].(string)
+	message.MessageType, _ = entry.Values[fieldMessageType].(string)
+	message.GroupId, _ = entry.Values[fieldGroupId].(string)
+	if value, ok := entry.Values[fieldSentTime].(string); ok {
+		if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+			message.SentTime = time.UnixMilli(millis)
+		}
+	}
+	if value, ok := entry.Values[fieldMessage].(string); ok {
+		message.Message = []byte(value)
+	}
+	for field, value := range entry.Values {
+		if name := strings.TrimPrefix(field, fieldHeaderPrefix); name != field {
+			if value, ok := value.(string); ok {
+				message.SetHeader(name, value)
+			}
+		}
+	}
+	message.SetReference(entry.ID)
+	return message
+}
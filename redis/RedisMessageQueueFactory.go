@@ -0,0 +1,53 @@
+package redis
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// RedisMessageQueueFactory are creates RedisMessageQueue components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See RedisMessageQueue
+type RedisMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewRedisMessageQueueFactory method are create a new instance of the factory.
+func NewRedisMessageQueueFactory() *RedisMessageQueueFactory {
+	c := RedisMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	redisQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "redis", "*", "1.0")
+
+	c.Register(redisQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *RedisMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewRedisMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
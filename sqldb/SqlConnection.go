@@ -0,0 +1,377 @@
+package sqldb
+
+import (
+	"database/sql"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+
+	// Database drivers for supported dialects
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+/*
+SqlConnection connection to a relational database that stores message queues.
+All queues share the same tables, messages are separated by the queue name.
+The tables are created automatically when the connection is opened.
+The connection implements IMessageQueueConnection to list, create and delete queues.
+
+Configuration parameters:
+
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  database dialect: postgres or sqlite
+    - host:                      host name or IP address (postgres)
+    - port:                      port number (default: 5432)
+    - database:                  database name (postgres) or path to the database file (sqlite)
+    - ssl_mode:                  SSL mode of postgres connection: disable, require, verify-full
+    - uri:                       connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - table_prefix:              prefix of the queue tables (default: mq_)
+    - max_pool_size:             maximum number of open connections (default: 10, always 1 for sqlite)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+
+See IMessageQueueConnection
+See SqlMessageQueue
+*/
+type SqlConnection struct {
+	Logger             *clog.CompositeLogger
+	ConnectionResolver *cconn.ConnectionResolver
+	CredentialResolver *cauth.CredentialResolver
+	tablePrefix        string
+	maxPoolSize        int
+	db                 *sql.DB
+	dialect            *sqlDialect
+	lock               sync.Mutex
+}
+
+// NewSqlConnection method are creates a new instance of the connection component.
+func NewSqlConnection() *SqlConnection {
+	c := SqlConnection{
+		Logger:             clog.NewCompositeLogger(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		CredentialResolver: cauth.NewEmptyCredentialResolver(),
+		tablePrefix:        "mq_",
+		maxPoolSize:        10,
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *SqlConnection) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+	c.CredentialResolver.Configure(config)
+
+	c.tablePrefix = config.GetAsStringWithDefault("options.table_prefix", c.tablePrefix)
+	c.maxPoolSize = config.GetAsIntegerWithDefault("options.max_pool_size", c.maxPoolSize)
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *SqlConnection) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+	c.CredentialResolver.SetReferences(references)
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SqlConnection) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.db != nil
+}
+
+// Open method are resolves connection parameters and opens the component.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlConnection) Open(correlationId string) error {
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return err
+	}
+	if len(connections) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection parameters are not set")
+	}
+
+	credential, err := c.CredentialResolver.Lookup(correlationId)
+	if err != nil {
+		return err
+	}
+
+	return c.OpenWithParams(correlationId, connections, credential)
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+// The first connection is used, and the schema is created when it does not exist.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *SqlConnection) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.db != nil {
+		return nil
+	}
+	if len(connections) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_CONNECTION", "Connection parameters are not set")
+	}
+
+	dialect, dsn, err := c.composeDataSource(correlationId, connections[0], credential)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT", "Failed to connect to "+dialect.name+" database").
+			WithCause(err)
+	}
+
+	if dialect.name == DialectSqlite {
+		// Writers are serialized anyway and in-memory databases live within a single connection
+		db.SetMaxOpenConns(1)
+	} else if c.maxPoolSize > 0 {
+		db.SetMaxOpenConns(c.maxPoolSize)
+	}
+
+	statements := dialect.schema()
+	if dialect.name == DialectSqlite {
+		statements = append([]string{"PRAGMA busy_timeout = 5000"}, statements...)
+	}
+	for _, statement := range statements {
+		if _, err = db.Exec(statement); err != nil {
+			db.Close()
+			return cerr.NewConnectionError(correlationId, "CANNOT_CONNECT", "Failed to initialize "+dialect.name+" database").
+				WithCause(err)
+		}
+	}
+
+	c.db = db
+	c.dialect = dialect
+	c.Logger.Debug(correlationId, "Connected to %s database", dialect.name)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlConnection) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.db == nil {
+		return nil
+	}
+
+	err := c.db.Close()
+	c.db = nil
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_DISCONNECT", "Failed to disconnect from "+c.dialect.name+" database").
+			WithCause(err)
+	}
+
+	c.Logger.Debug(correlationId, "Disconnected from %s database", c.dialect.name)
+	return nil
+}
+
+// GetDB method are gets the opened database or nil if the connection is closed.
+func (c *SqlConnection) GetDB() *sql.DB {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.db
+}
+
+// GetDialect method are gets the name of the database dialect: postgres or sqlite.
+func (c *SqlConnection) GetDialect() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.dialect == nil {
+		return ""
+	}
+	return c.dialect.name
+}
+
+// ReadQueueNames method are reads names of all queues stored in the database.
+// Returns: a list with queue names or error.
+func (c *SqlConnection) ReadQueueNames() ([]string, error) {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(dialect.rebind("SELECT name FROM {queues} ORDER BY name"))
+	if err != nil {
+		return nil, c.wrapError("", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, c.wrapError("", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, c.wrapError("", rows.Err())
+}
+
+// CreateQueue method are registers a queue in the database if it does not exist.
+//   - name    a name of the queue to be created.
+// Returns: error or nil for success.
+func (c *SqlConnection) CreateQueue(name string) error {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return cerr.NewBadRequestError("", "NO_QUEUE", "Queue name is not set")
+	}
+
+	_, err = db.Exec(dialect.rebind("INSERT INTO {queues} (name) VALUES (?) ON CONFLICT (name) DO NOTHING"), name)
+	return c.wrapError("", err)
+}
+
+// DeleteQueue method are deletes a queue with all its messages and dead letters.
+//   - name    a name of the queue to be deleted.
+// Returns: error or nil for success.
+func (c *SqlConnection) DeleteQueue(name string) error {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return c.wrapError("", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range []string{
+		"DELETE FROM {messages} WHERE queue=?",
+		"DELETE FROM {dead_letters} WHERE queue=?",
+		"DELETE FROM {queues} WHERE name=?",
+	} {
+		if _, err = tx.Exec(dialect.rebind(statement), name); err != nil {
+			return c.wrapError("", err)
+		}
+	}
+
+	return c.wrapError("", tx.Commit())
+}
+
+func (c *SqlConnection) checkOpen(correlationId string) (*sql.DB, *sqlDialect, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.db == nil {
+		return nil, nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "Connection to database is not opened")
+	}
+	return c.db, c.dialect, nil
+}
+
+func (c *SqlConnection) wrapError(correlationId string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*cerr.ApplicationError); ok {
+		return err
+	}
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute database operation").
+		WithCause(err)
+}
+
+// composeDataSource selects the dialect and composes the data source name for the database driver.
+func (c *SqlConnection) composeDataSource(correlationId string, connection *cconn.ConnectionParams,
+	credential *cauth.CredentialParams) (*sqlDialect, string, error) {
+
+	uri := connection.Uri()
+	protocol := strings.ToLower(connection.Protocol())
+	if protocol == "" && uri != "" {
+		if index := strings.Index(uri, "://"); index > 0 {
+			protocol = strings.ToLower(uri[:index])
+		}
+	}
+	switch protocol {
+	case "postgres", "postgresql":
+		protocol = DialectPostgres
+	case "", "sqlite", "sqlite3", "file":
+		protocol = DialectSqlite
+	}
+
+	dialect := newSqlDialect(protocol, c.tablePrefix)
+	if dialect == nil {
+		return nil, "", cerr.NewConfigError(correlationId, "WRONG_PROTOCOL", "Unsupported database protocol "+protocol).
+			WithDetails("protocol", protocol)
+	}
+
+	database := connection.GetAsString("database")
+
+	if dialect.name == DialectSqlite {
+		if uri != "" {
+			return dialect, uri, nil
+		}
+		if database == "" {
+			return nil, "", cerr.NewConfigError(correlationId, "NO_DATABASE", "Database file is not set")
+		}
+		return dialect, database, nil
+	}
+
+	if uri != "" {
+		return dialect, uri, nil
+	}
+
+	host := connection.Host()
+	if host == "" {
+		return nil, "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+	if database == "" {
+		return nil, "", cerr.NewConfigError(correlationId, "NO_DATABASE", "Connection database is not set")
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		Host:   host + ":" + strconv.Itoa(connection.PortWithDefault(5432)),
+		Path:   "/" + database,
+	}
+	if credential != nil && credential.Username() != "" {
+		if credential.Password() != "" {
+			dsn.User = url.UserPassword(credential.Username(), credential.Password())
+		} else {
+			dsn.User = url.User(credential.Username())
+		}
+	}
+	if sslMode := connection.GetAsString("ssl_mode"); sslMode != "" {
+		dsn.RawQuery = "sslmode=" + url.QueryEscape(sslMode)
+	}
+
+	return dialect, dsn.String(), nil
+}
@@ -0,0 +1,121 @@
+package sqldb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Supported SQL dialects.
+const (
+	// DialectPostgres is the PostgreSQL dialect.
+	DialectPostgres = "postgres"
+	// DialectSqlite is the SQLite dialect.
+	DialectSqlite = "sqlite"
+)
+
+// sqlDialect renders statements for a specific database.
+// Statements are written with ? placeholders and converted into the dialect syntax.
+type sqlDialect struct {
+	name        string
+	driver      string
+	prefix      string
+	idColumn    string
+	binaryType  string
+	skipLocked  string
+	placeholder func(index int) string
+}
+
+func newSqlDialect(name string, prefix string) *sqlDialect {
+	switch name {
+	case DialectPostgres:
+		return &sqlDialect{
+			name:        DialectPostgres,
+			driver:      "pgx",
+			prefix:      prefix,
+			idColumn:    "id BIGSERIAL PRIMARY KEY",
+			binaryType:  "BYTEA",
+			skipLocked:  " FOR UPDATE SKIP LOCKED",
+			placeholder: func(index int) string { return "$" + strconv.Itoa(index) },
+		}
+	case DialectSqlite:
+		// SQLite serializes writers, so a single UPDATE statement
+		// gives the same guarantees as SELECT FOR UPDATE SKIP LOCKED
+		return &sqlDialect{
+			name:        DialectSqlite,
+			driver:      "sqlite",
+			prefix:      prefix,
+			idColumn:    "id INTEGER PRIMARY KEY AUTOINCREMENT",
+			binaryType:  "BLOB",
+			skipLocked:  "",
+			placeholder: func(index int) string { return "?" },
+		}
+	default:
+		return nil
+	}
+}
+
+// table returns a full name of the table with the configured prefix.
+func (c *sqlDialect) table(name string) string {
+	return c.prefix + name
+}
+
+// rebind replaces ? placeholders and {table} references in the statement.
+func (c *sqlDialect) rebind(statement string) string {
+	for _, name := range []string{"queues", "messages", "dead_letters", "processed_messages", "outbox"} {
+		statement = strings.ReplaceAll(statement, "{"+name+"}", c.table(name))
+	}
+	statement = strings.ReplaceAll(statement, "{skip_locked}", c.skipLocked)
+
+	builder := strings.Builder{}
+	index := 0
+	for _, char := range statement {
+		if char == '?' {
+			index++
+			builder.WriteString(c.placeholder(index))
+		} else {
+			builder.WriteRune(char)
+		}
+	}
+	return builder.String()
+}
+
+// schema returns statements that create tables and indexes when they do not exist.
+func (c *sqlDialect) schema() []string {
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + c.table("queues") + " (name VARCHAR(255) NOT NULL PRIMARY KEY)",
+		"CREATE TABLE IF NOT EXISTS " + c.table("messages") + " (" + c.idColumn + ", " +
+			"queue VARCHAR(255) NOT NULL, message_id VARCHAR(50), correlation_id VARCHAR(50), " +
+			"message_type VARCHAR(255), group_id VARCHAR(255), headers TEXT, sent_time BIGINT NOT NULL, message " + c.binaryType + ", " +
+			"lock_token VARCHAR(50), lock_expiration BIGINT NOT NULL DEFAULT 0)",
+		"CREATE INDEX IF NOT EXISTS " + c.table("messages_queue") + " ON " + c.table("messages") + " (queue, id)",
+		"CREATE INDEX IF NOT EXISTS " + c.table("messages_lock") + " ON " + c.table("messages") + " (queue, lock_token)",
+		"CREATE TABLE IF NOT EXISTS " + c.table("dead_letters") + " (" + c.idColumn + ", " +
+			"queue VARCHAR(255) NOT NULL, message_id VARCHAR(50), correlation_id VARCHAR(50), " +
+			"message_type VARCHAR(255), group_id VARCHAR(255), headers TEXT, sent_time BIGINT NOT NULL, message " + c.binaryType + ", " +
+			"dead_time BIGINT NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS " + c.table("dead_letters_queue") + " ON " + c.table("dead_letters") + " (queue, id)",
+	}
+}
+
+// processedSchema returns statements that create the table of processed messages when it does not exist.
+func (c *sqlDialect) processedSchema() []string {
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + c.table("processed_messages") + " (" +
+			"message_id VARCHAR(50) NOT NULL PRIMARY KEY, expiration_time BIGINT NOT NULL, " +
+			"processed INTEGER NOT NULL DEFAULT 1)",
+		"CREATE INDEX IF NOT EXISTS " + c.table("processed_messages_expiration") + " ON " +
+			c.table("processed_messages") + " (expiration_time)",
+	}
+}
+
+// outboxSchema returns statements that create the outbox table when it does not exist.
+func (c *sqlDialect) outboxSchema() []string {
+	return []string{
+		"CREATE TABLE IF NOT EXISTS " + c.table("outbox") + " (" + c.idColumn + ", " +
+			"queue VARCHAR(255) NOT NULL, message_id VARCHAR(50), correlation_id VARCHAR(50), " +
+			"message_type VARCHAR(255), group_id VARCHAR(255), headers TEXT, message " + c.binaryType + ", " +
+			"created_time BIGINT NOT NULL, attempts INTEGER NOT NULL DEFAULT 0, " +
+			"next_attempt_time BIGINT NOT NULL DEFAULT 0, dead_time BIGINT NOT NULL DEFAULT 0)",
+		"CREATE INDEX IF NOT EXISTS " + c.table("outbox_queue") + " ON " + c.table("outbox") + " (queue, next_attempt_time)",
+	}
+}
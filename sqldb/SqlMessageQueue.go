@@ -0,0 +1,637 @@
+package sqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+SqlMessageQueue message queue that stores messages in a relational database.
+Messages are received with SELECT FOR UPDATE SKIP LOCKED on PostgreSQL,
+so several consumers can receive from the same queue without blocking each other.
+On SQLite the semantics are emulated with a single atomic UPDATE statement.
+Locks are stored in the messages table and expire by time.
+Messages moved to dead letter are kept in a separate table.
+
+Configuration parameters:
+
+  - name:                        name of the message queue
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  database dialect: postgres or sqlite
+    - host:                      host name or IP address (postgres)
+    - port:                      port number (default: 5432)
+    - database:                  database name (postgres) or path to the database file (sqlite)
+    - uri:                       connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - lock_timeout:              timeout in milliseconds to lock received messages (default: 30000)
+    - table_prefix:              prefix of the queue tables (default: mq_)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+- *:connection:sql:*:1.0     (optional)  Shared SqlConnection; when absent the queue opens its own connection
+
+See MessageQueue
+See SqlConnection
+
+Example:
+
+    queue := NewSqlMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.protocol", "postgres",
+        "connection.host", "localhost",
+        "connection.port", 5432,
+        "connection.database", "test",
+        "credential.username", "postgres",
+        "credential.password", "postgres",
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type SqlMessageQueue struct {
+	queues.MessageQueue
+	dependencyResolver *cref.DependencyResolver
+	config             *cconf.ConfigParams
+	references         cref.IReferences
+	localConnection    *SqlConnection
+
+	// The connection to the database
+	Connection *SqlConnection
+
+	lockTimeout time.Duration
+	opened      int32
+	cancel      int32
+}
+
+// NewSqlMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *SqlMessageQueue
+// See MessagingCapabilities
+func NewSqlMessageQueue(name string) *SqlMessageQueue {
+	c := SqlMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(true, true, true, true, true, true, true, true, true),
+	)
+
+	c.dependencyResolver = cref.NewDependencyResolver()
+	c.dependencyResolver.Put("connection", cref.NewDescriptor("pip-services", "connection", "sql", "*", "1.0"))
+	c.config = cconf.NewEmptyConfigParams()
+	c.lockTimeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *SqlMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.config = config
+	c.dependencyResolver.Configure(config)
+	c.lockTimeout = time.Duration(config.GetAsLongWithDefault("options.lock_timeout", int64(c.lockTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *SqlMessageQueue) SetReferences(references cref.IReferences) {
+	c.MessageQueue.SetReferences(references)
+
+	c.references = references
+	c.dependencyResolver.SetReferences(references)
+	connection, ok := c.dependencyResolver.GetOneOptional("connection").(*SqlConnection)
+	if ok {
+		c.Connection = connection
+	}
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SqlMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// Open method are opens the component.
+// When no shared connection is referenced, connection parameters are resolved
+// by the queue and a local connection is opened.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlMessageQueue) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	if c.Connection != nil && c.localConnection == nil {
+		return c.openQueue(correlationId)
+	}
+
+	return c.MessageQueue.Open(correlationId)
+}
+
+// OpenWithParams method are opens a local connection with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *SqlMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.localConnection == nil {
+		c.localConnection = NewSqlConnection()
+		c.localConnection.Configure(c.config)
+		if c.references != nil {
+			c.localConnection.SetReferences(c.references)
+		}
+		c.Connection = c.localConnection
+	}
+
+	if err := c.localConnection.OpenWithParams(correlationId, connections, credential); err != nil {
+		return err
+	}
+
+	return c.openQueue(correlationId)
+}
+
+func (c *SqlMessageQueue) openQueue(correlationId string) error {
+	if !c.Connection.IsOpen() {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to database is not opened")
+	}
+
+	if err := c.Connection.CreateQueue(c.Name()); err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+	c.Logger.Debug(correlationId, "Opened queue %s", c.Name())
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+	atomic.StoreInt32(&c.opened, 0)
+
+	if c.localConnection != nil {
+		if err := c.localConnection.Close(correlationId); err != nil {
+			return err
+		}
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+	return nil
+}
+
+// Clear method are removes all messages and dead letters of the queue.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlMessageQueue) Clear(correlationId string) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return c.Connection.wrapError(correlationId, err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range []string{
+		"DELETE FROM {messages} WHERE queue=?",
+		"DELETE FROM {dead_letters} WHERE queue=?",
+	} {
+		if _, err = tx.Exec(dialect.rebind(statement), c.Name()); err != nil {
+			return c.Connection.wrapError(correlationId, err)
+		}
+	}
+
+	return c.Connection.wrapError(correlationId, tx.Commit())
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// Returns: number of messages or error.
+func (c *SqlMessageQueue) ReadMessageCount() (count int64, err error) {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.QueryRow(
+		dialect.rebind("SELECT COUNT(*) FROM {messages} WHERE queue=? AND lock_expiration<=?"),
+		c.Name(), now(),
+	).Scan(&count)
+
+	return count, c.Connection.wrapError("", err)
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *SqlMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	headers, err := encodeHeaders(correlationId, envelope.Headers)
+	if err != nil {
+		return err
+	}
+
+	envelope.SentTime = time.Now()
+
+	_, err = db.Exec(
+		dialect.rebind("INSERT INTO {messages} (queue, message_id, correlation_id, message_type, group_id, headers, sent_time, message) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		c.Name(), envelope.MessageId, envelope.CorrelationId, envelope.MessageType, envelope.GroupId,
+		headers, envelope.SentTime.UnixMilli(), envelope.Message,
+	)
+	if err != nil {
+		return c.Connection.wrapError(correlationId, err)
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// If there are no messages available in the queue it returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *SqlMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	messages, err := c.PeekBatch(correlationId, 1)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+
+	message := messages[0]
+	c.Logger.Trace(message.CorrelationId, "Peeked message %s on %s", message, c.String())
+
+	return message, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// If there are no messages available in the queue it returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *SqlMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		dialect.rebind("SELECT message_id, correlation_id, message_type, group_id, headers, sent_time, message FROM {messages} "+
+			"WHERE queue=? AND lock_expiration<=? ORDER BY id LIMIT ?"),
+		c.Name(), now(), messageCount,
+	)
+	if err != nil {
+		return nil, c.Connection.wrapError(correlationId, err)
+	}
+	defer rows.Close()
+
+	messages := []*queues.MessageEnvelope{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+		messages = append(messages, message)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, c.Connection.wrapError(correlationId, err)
+	}
+
+	c.Logger.Trace(correlationId, "Peeked %d messages on %s", len(messages), c.Name())
+
+	return messages, nil
+}
+
+// Receive method are receives an incoming message and locks it with a single atomic statement.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *SqlMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := dialect.rebind("UPDATE {messages} SET lock_token=?, lock_expiration=? WHERE id = (" +
+		"SELECT id FROM {messages} WHERE queue=? AND lock_expiration<=? ORDER BY id LIMIT 1{skip_locked}" +
+		") RETURNING message_id, correlation_id, message_type, group_id, headers, sent_time, message")
+
+	var message *queues.MessageEnvelope
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		token := cdata.IdGenerator.NextLong()
+		current := time.Now()
+
+		row := db.QueryRow(statement, token, current.Add(c.lockTimeout).UnixMilli(), c.Name(), current.UnixMilli())
+		message, err = scanMessage(row)
+		if err == sql.ErrNoRows {
+			message, err = nil, nil
+		}
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+
+		if message != nil {
+			message.SetReference(token)
+			break
+		}
+		if !time.Now().Before(deadline) || atomic.LoadInt32(&c.cancel) != 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if message != nil {
+		c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+		c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+	}
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// This method is usually used to extend the message processing time.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *SqlMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	token, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+	if lockTimeout <= 0 {
+		lockTimeout = c.lockTimeout
+	}
+
+	db, dialect, err := c.checkOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		dialect.rebind("UPDATE {messages} SET lock_expiration=? WHERE queue=? AND lock_token=?"),
+		time.Now().Add(lockTimeout).UnixMilli(), c.Name(), token,
+	)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+
+	c.Logger.Trace(message.CorrelationId, "Renewed lock for message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *SqlMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	db, dialect, err := c.checkOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("DELETE FROM {messages} WHERE queue=? AND lock_token=?"), c.Name(), token)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *SqlMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	db, dialect, err := c.checkOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		dialect.rebind("UPDATE {messages} SET lock_token=NULL, lock_expiration=0 WHERE queue=? AND lock_token=?"),
+		c.Name(), token,
+	)
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and stores it in the dead letter table.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *SqlMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	token, ok := message.GetReference().(string)
+	if !ok {
+		return nil
+	}
+
+	db, dialect, err := c.checkOpen(message.CorrelationId)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		dialect.rebind("INSERT INTO {dead_letters} (queue, message_id, correlation_id, message_type, group_id, headers, sent_time, message, dead_time) "+
+			"SELECT queue, message_id, correlation_id, message_type, group_id, headers, sent_time, message, ? FROM {messages} "+
+			"WHERE queue=? AND lock_token=?"),
+		time.Now().UnixMilli(), c.Name(), token,
+	)
+	if err == nil {
+		_, err = tx.Exec(dialect.rebind("DELETE FROM {messages} WHERE queue=? AND lock_token=?"), c.Name(), token)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return c.Connection.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *SqlMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *SqlMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// ReadDeadLetters method are reads messages that were moved to the dead letter table for the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a list with dead messages or error.
+func (c *SqlMessageQueue) ReadDeadLetters(correlationId string) ([]*queues.MessageEnvelope, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		dialect.rebind("SELECT message_id, correlation_id, message_type, group_id, headers, sent_time, message FROM {dead_letters} "+
+			"WHERE queue=? ORDER BY id"),
+		c.Name(),
+	)
+	if err != nil {
+		return nil, c.Connection.wrapError(correlationId, err)
+	}
+	defer rows.Close()
+
+	messages := []*queues.MessageEnvelope{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, c.Connection.wrapError(correlationId, rows.Err())
+}
+
+func (c *SqlMessageQueue) checkOpen(correlationId string) (*sql.DB, *sqlDialect, error) {
+	if err := c.CheckOpen(correlationId); err != nil {
+		return nil, nil, err
+	}
+	return c.Connection.checkOpen(correlationId)
+}
+
+// scanner is implemented by sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessage(row scanner) (*queues.MessageEnvelope, error) {
+	var messageId, correlationId, messageType, groupId, headers sql.NullString
+	var sentTime int64
+	var data []byte
+
+	if err := row.Scan(&messageId, &correlationId, &messageType, &groupId, &headers, &sentTime, &data); err != nil {
+		return nil, err
+	}
+
+	message := queues.NewEmptyMessageEnvelope()
+	message.MessageId = messageId.String
+	message.CorrelationId = correlationId.String
+	message.MessageType = messageType.String
+	message.GroupId = groupId.String
+	message.SentTime = time.UnixMilli(sentTime)
+	message.Message = data
+
+	var err error
+	message.Headers, err = decodeHeaders(correlationId.String, headers.String)
+	return message, err
+}
+
+// encodeHeaders serializes message headers into JSON stored in headers columns.
+// Returns nil when the message has no headers.
+func encodeHeaders(correlationId string, headers map[string]string) (interface{}, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return nil, cerr.NewInternalError(correlationId, "CANNOT_SERIALIZE", "Failed to serialize message headers").
+			WithCause(err)
+	}
+	return string(data), nil
+}
+
+// decodeHeaders restores message headers from JSON stored in headers columns.
+func decodeHeaders(correlationId string, value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	headers := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &headers); err != nil {
+		return nil, cerr.NewInternalError(correlationId, "CANNOT_DESERIALIZE", "Failed to deserialize message headers").
+			WithCause(err)
+	}
+	return headers, nil
+}
+
+func now() int64 {
+	return time.Now().UnixMilli()
+}
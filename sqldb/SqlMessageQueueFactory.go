@@ -0,0 +1,56 @@
+package sqldb
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// SqlMessageQueueFactory are creates SqlMessageQueue and SqlConnection components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See SqlMessageQueue
+// See SqlConnection
+type SqlMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewSqlMessageQueueFactory method are create a new instance of the factory.
+func NewSqlMessageQueueFactory() *SqlMessageQueueFactory {
+	c := SqlMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	sqlQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "sql", "*", "1.0")
+	sqlConnectionDescriptor := cref.NewDescriptor("pip-services", "connection", "sql", "*", "1.0")
+
+	c.Register(sqlQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(sqlConnectionDescriptor, NewSqlConnection)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *SqlMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewSqlMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
@@ -0,0 +1,364 @@
+package sqldb
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// outboxEntry is a message stored in the outbox.
+type outboxEntry struct {
+	id              int64
+	queue           string
+	envelope        *queues.MessageEnvelope
+	attempts        int
+	nextAttemptTime int64
+}
+
+/*
+SqlOutbox Transactional outbox that stores messages in the database of a service
+within the same transaction as business data, so messages are not lost
+when the service fails between writing data and publishing messages.
+Stored messages are sent to message queues by SqlOutboxRelay.
+Messages that the relay failed to send too many times are kept in the outbox as dead letters
+until they are returned with RedriveDeadLetters method.
+
+The outbox shall use the same database as the service. The service gets the database
+from the connection, begins a transaction and adds messages with Add method before it commits.
+
+Configuration parameters:
+
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  database dialect: postgres or sqlite
+    - host:                      host name or IP address (postgres)
+    - port:                      port number (default: 5432)
+    - database:                  database name (postgres) or path to the database file (sqlite)
+    - uri:                       connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - table_prefix:              prefix of the tables (default: mq_)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+- *:connection:sql:*:1.0     (optional)  Shared SqlConnection; when absent the outbox opens its own connection
+
+See SqlOutboxRelay
+See SqlConnection
+
+Example:
+
+    outbox := NewSqlOutbox()
+    outbox.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.protocol", "sqlite",
+        "connection.database", "./data/service.db",
+    ))
+    outbox.Open("123")
+
+    tx, _ := outbox.Connection.GetDB().Begin()
+    tx.Exec("INSERT INTO orders (id, status) VALUES (?, ?)", "1", "accepted")
+    outbox.Add("123", tx, "orders", queues.NewMessageEnvelope("123", "order.accepted", []byte("1")))
+    tx.Commit()
+*/
+type SqlOutbox struct {
+	dependencyResolver *cref.DependencyResolver
+	config             *cconf.ConfigParams
+	references         cref.IReferences
+	localConnection    *SqlConnection
+
+	// The connection to the database
+	Connection *SqlConnection
+
+	opened bool
+	lock   sync.Mutex
+}
+
+// NewSqlOutbox method are creates a new instance of the outbox.
+// Returns: *SqlOutbox
+func NewSqlOutbox() *SqlOutbox {
+	c := SqlOutbox{
+		dependencyResolver: cref.NewDependencyResolver(),
+		config:             cconf.NewEmptyConfigParams(),
+	}
+	c.dependencyResolver.Put("connection", cref.NewDescriptor("pip-services", "connection", "sql", "*", "1.0"))
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *SqlOutbox) Configure(config *cconf.ConfigParams) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.config = config
+	c.dependencyResolver.Configure(config)
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *SqlOutbox) SetReferences(references cref.IReferences) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.references = references
+	c.dependencyResolver.SetReferences(references)
+	connection, ok := c.dependencyResolver.GetOneOptional("connection").(*SqlConnection)
+	if ok {
+		c.Connection = connection
+	}
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SqlOutbox) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.opened
+}
+
+// Open method are opens the component and creates the outbox table.
+// When no shared connection is referenced, a local connection is opened.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlOutbox) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil || c.localConnection != nil {
+		if c.localConnection == nil {
+			c.localConnection = NewSqlConnection()
+			c.localConnection.Configure(c.config)
+			if c.references != nil {
+				c.localConnection.SetReferences(c.references)
+			}
+			c.Connection = c.localConnection
+		}
+		if err := c.localConnection.Open(correlationId); err != nil {
+			return err
+		}
+	}
+
+	db, dialect, err := c.Connection.checkOpen(correlationId)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to database is not opened")
+	}
+	for _, statement := range dialect.outboxSchema() {
+		if _, err = db.Exec(statement); err != nil {
+			return c.Connection.wrapError(correlationId, err)
+		}
+	}
+
+	c.opened = true
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlOutbox) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.opened {
+		return nil
+	}
+	c.opened = false
+
+	if c.localConnection != nil {
+		return c.localConnection.Close(correlationId)
+	}
+	return nil
+}
+
+// Clear method are removes all messages from the outbox.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlOutbox) Clear(correlationId string) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("DELETE FROM {outbox}"))
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// Add method are stores a message in the outbox within a business transaction.
+// The message is sent to the queue by the relay after the transaction is committed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - tx                a database transaction of the service, when it is nil the message is stored immediately.
+//   - queue             a name of the queue to send the message to.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *SqlOutbox) Add(correlationId string, tx *sql.Tx, queue string, envelope *queues.MessageEnvelope) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	headers, err := encodeHeaders(correlationId, envelope.Headers)
+	if err != nil {
+		return err
+	}
+
+	statement := dialect.rebind("INSERT INTO {outbox} (queue, message_id, correlation_id, message_type, group_id, " +
+		"headers, message, created_time) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	args := []interface{}{queue, envelope.MessageId, envelope.CorrelationId, envelope.MessageType,
+		envelope.GroupId, headers, envelope.Message, now()}
+	if tx != nil {
+		_, err = tx.Exec(statement, args...)
+	} else {
+		_, err = db.Exec(statement, args...)
+	}
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// ReadMessageCount method are reads the number of messages waiting in the outbox.
+// Returns: number of messages or error.
+func (c *SqlOutbox) ReadMessageCount() (count int64, err error) {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.QueryRow(dialect.rebind("SELECT COUNT(*) FROM {outbox} WHERE dead_time=0")).Scan(&count)
+	return count, c.Connection.wrapError("", err)
+}
+
+// ReadDeadLetterCount method are reads the number of messages the relay gave up to send.
+// Returns: number of messages or error.
+func (c *SqlOutbox) ReadDeadLetterCount() (count int64, err error) {
+	db, dialect, err := c.checkOpen("")
+	if err != nil {
+		return 0, err
+	}
+
+	err = db.QueryRow(dialect.rebind("SELECT COUNT(*) FROM {outbox} WHERE dead_time>0")).Scan(&count)
+	return count, c.Connection.wrapError("", err)
+}
+
+// RedriveDeadLetters method are returns messages the relay gave up to send back to the outbox,
+// so they are sent again with a new number of attempts.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: the number of returned messages or error.
+func (c *SqlOutbox) RedriveDeadLetters(correlationId string) (int64, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := db.Exec(dialect.rebind("UPDATE {outbox} SET attempts=0, next_attempt_time=0, dead_time=0 WHERE dead_time>0"))
+	if err != nil {
+		return 0, c.Connection.wrapError(correlationId, err)
+	}
+	count, err := result.RowsAffected()
+	return count, c.Connection.wrapError(correlationId, err)
+}
+
+// readEntries reads the oldest messages that are due to be sent in the order they were added.
+// Messages to queues that wait for a retry are skipped, so they do not block other queues.
+func (c *SqlOutbox) readEntries(correlationId string, limit int) ([]*outboxEntry, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		dialect.rebind("SELECT id, queue, message_id, correlation_id, message_type, group_id, headers, message, "+
+			"created_time, attempts, next_attempt_time FROM {outbox} WHERE dead_time=0 AND queue NOT IN "+
+			"(SELECT queue FROM {outbox} WHERE dead_time=0 AND next_attempt_time>?) ORDER BY id LIMIT ?"),
+		now(), limit,
+	)
+	if err != nil {
+		return nil, c.Connection.wrapError(correlationId, err)
+	}
+	defer rows.Close()
+
+	entries := []*outboxEntry{}
+	for rows.Next() {
+		var messageId, messageCorrelationId, messageType, groupId, headers sql.NullString
+		var createdTime int64
+		entry := &outboxEntry{envelope: queues.NewEmptyMessageEnvelope()}
+
+		err = rows.Scan(&entry.id, &entry.queue, &messageId, &messageCorrelationId, &messageType, &groupId,
+			&headers, &entry.envelope.Message, &createdTime, &entry.attempts, &entry.nextAttemptTime)
+		if err != nil {
+			return nil, c.Connection.wrapError(correlationId, err)
+		}
+
+		entry.envelope.MessageId = messageId.String
+		entry.envelope.CorrelationId = messageCorrelationId.String
+		entry.envelope.MessageType = messageType.String
+		entry.envelope.GroupId = groupId.String
+		entry.envelope.SentTime = time.UnixMilli(createdTime)
+		entry.envelope.Headers, err = decodeHeaders(correlationId, headers.String)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, c.Connection.wrapError(correlationId, rows.Err())
+}
+
+// removeEntry removes a sent message from the outbox.
+func (c *SqlOutbox) removeEntry(correlationId string, id int64) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("DELETE FROM {outbox} WHERE id=?"), id)
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// retryEntry records a failed attempt to send a message and the time of the next attempt.
+func (c *SqlOutbox) retryEntry(correlationId string, id int64, attempts int, nextAttemptTime int64) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("UPDATE {outbox} SET attempts=?, next_attempt_time=? WHERE id=?"),
+		attempts, nextAttemptTime, id)
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// deadEntry keeps a message the relay gave up to send as a dead letter.
+func (c *SqlOutbox) deadEntry(correlationId string, id int64, attempts int) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("UPDATE {outbox} SET attempts=?, next_attempt_time=0, dead_time=? WHERE id=?"),
+		attempts, now(), id)
+	return c.Connection.wrapError(correlationId, err)
+}
+
+func (c *SqlOutbox) checkOpen(correlationId string) (*sql.DB, *sqlDialect, error) {
+	c.lock.Lock()
+	opened := c.opened
+	c.lock.Unlock()
+
+	if !opened {
+		return nil, nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The outbox is not opened")
+	}
+	return c.Connection.checkOpen(correlationId)
+}
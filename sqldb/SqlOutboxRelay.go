@@ -0,0 +1,315 @@
+package sqldb
+
+import (
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+SqlOutboxRelay Sends messages stored in SqlOutbox to message queues.
+Messages are sent in the order they were added to the outbox and removed after they are sent,
+so every message is delivered at least once. A message can be delivered twice
+when the relay fails right after sending it, so consumers shall be idempotent.
+
+A message that cannot be sent is retried with exponentially growing intervals.
+Until it is sent, later messages to the same queue wait, so their order is kept.
+Messages to other queues are not blocked. When the number of attempts is limited,
+a message that is not sent after the last attempt is kept in the outbox as a dead letter
+and later messages to its queue are sent.
+
+Queues are found by their names among referenced message queues or registered with SetQueue method.
+Only one relay shall drain an outbox at a time to keep the order of messages.
+
+Configuration parameters:
+
+  - options:
+    - interval:                  interval in milliseconds to check the outbox for new messages (default: 1000)
+    - batch_size:                maximum number of messages read from the outbox at once (default: 100)
+    - retry_timeout:             initial interval in milliseconds between attempts to send a message (default: 1000)
+    - max_retry_timeout:         maximum interval in milliseconds between attempts to send a message (default: 60000)
+    - max_attempts:              maximum number of attempts to send a message, 0 for no limit (default: 0)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:outbox:sql:*:1.0         SqlOutbox to read messages from
+- *:message-queue:*:*:1.0    (optional)  IMessageQueue components to send messages to
+
+See SqlOutbox
+
+Example:
+
+    relay := NewSqlOutboxRelay()
+    relay.SetOutbox(outbox)
+    relay.SetQueue(queues.NewMemoryMessageQueue("orders"))
+    relay.Open("123")
+    ...
+    relay.Close("123")
+*/
+type SqlOutboxRelay struct {
+	Logger             *clog.CompositeLogger
+	Counters           *ccount.CompositeCounters
+	dependencyResolver *cref.DependencyResolver
+	outbox             *SqlOutbox
+	queues             map[string]queues.IMessageQueue
+	interval           time.Duration
+	batchSize          int
+	retryTimeout       time.Duration
+	maxRetryTimeout    time.Duration
+	maxAttempts        int
+	stop               chan struct{}
+	stopped            sync.WaitGroup
+	lock               sync.Mutex
+	relayLock          sync.Mutex
+}
+
+// NewSqlOutboxRelay method are creates a new instance of the relay.
+// Returns: *SqlOutboxRelay
+func NewSqlOutboxRelay() *SqlOutboxRelay {
+	c := SqlOutboxRelay{
+		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
+		dependencyResolver: cref.NewDependencyResolver(),
+		queues:             map[string]queues.IMessageQueue{},
+		interval:           1000 * time.Millisecond,
+		batchSize:          100,
+		retryTimeout:       1000 * time.Millisecond,
+		maxRetryTimeout:    60000 * time.Millisecond,
+	}
+	c.dependencyResolver.Put("outbox", cref.NewDescriptor("*", "outbox", "sql", "*", "1.0"))
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *SqlOutboxRelay) Configure(config *cconf.ConfigParams) {
+	c.Logger.Configure(config)
+	c.dependencyResolver.Configure(config)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.interval = time.Duration(config.GetAsLongWithDefault("options.interval", int64(c.interval/time.Millisecond))) * time.Millisecond
+	c.batchSize = config.GetAsIntegerWithDefault("options.batch_size", c.batchSize)
+	c.retryTimeout = time.Duration(config.GetAsLongWithDefault("options.retry_timeout", int64(c.retryTimeout/time.Millisecond))) * time.Millisecond
+	c.maxRetryTimeout = time.Duration(config.GetAsLongWithDefault("options.max_retry_timeout", int64(c.maxRetryTimeout/time.Millisecond))) * time.Millisecond
+	c.maxAttempts = config.GetAsIntegerWithDefault("options.max_attempts", c.maxAttempts)
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *SqlOutboxRelay) SetReferences(references cref.IReferences) {
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+	c.dependencyResolver.SetReferences(references)
+
+	if outbox, ok := c.dependencyResolver.GetOneOptional("outbox").(*SqlOutbox); ok {
+		c.SetOutbox(outbox)
+	}
+	for _, component := range references.GetOptional(cref.NewDescriptor("*", "message-queue", "*", "*", "1.0")) {
+		if queue, ok := component.(queues.IMessageQueue); ok {
+			c.SetQueue(queue)
+		}
+	}
+}
+
+// SetOutbox method are sets the outbox to read messages from.
+//   - outbox    an outbox with messages.
+func (c *SqlOutboxRelay) SetOutbox(outbox *SqlOutbox) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.outbox = outbox
+}
+
+// SetQueue method are registers a queue to send messages to by its name.
+//   - queue     a message queue.
+func (c *SqlOutboxRelay) SetQueue(queue queues.IMessageQueue) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.queues[queue.Name()] = queue
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SqlOutboxRelay) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.stop != nil
+}
+
+// Open method are starts relaying messages from the outbox in background.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlOutboxRelay) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.stop != nil {
+		return nil
+	}
+	if c.outbox == nil {
+		return cerr.NewConfigError(correlationId, "NO_OUTBOX", "Outbox is not set")
+	}
+
+	c.stop = make(chan struct{})
+	c.stopped.Add(1)
+	go c.run(c.stop, c.interval, c.batchSize)
+
+	return nil
+}
+
+// Close method are stops relaying messages and waits until the current attempt is over.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlOutboxRelay) Close(correlationId string) error {
+	c.lock.Lock()
+	stop := c.stop
+	c.stop = nil
+	c.lock.Unlock()
+
+	if stop != nil {
+		close(stop)
+		c.stopped.Wait()
+	}
+	return nil
+}
+
+// Relay method are sends messages that are ready to be sent from the outbox to queues.
+// It is called periodically when the relay is opened and can be called directly.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: the number of sent messages or error.
+func (c *SqlOutboxRelay) Relay(correlationId string) (int64, error) {
+	c.relayLock.Lock()
+	defer c.relayLock.Unlock()
+
+	c.lock.Lock()
+	outbox := c.outbox
+	batchSize := c.batchSize
+	maxAttempts := c.maxAttempts
+	c.lock.Unlock()
+
+	if outbox == nil {
+		return 0, cerr.NewConfigError(correlationId, "NO_OUTBOX", "Outbox is not set")
+	}
+
+	entries, err := outbox.readEntries(correlationId, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := int64(0)
+	blocked := map[string]bool{}
+	for _, entry := range entries {
+		if blocked[entry.queue] {
+			continue
+		}
+		if entry.nextAttemptTime > now() {
+			blocked[entry.queue] = true
+			continue
+		}
+
+		if err = c.send(correlationId, entry); err != nil {
+			c.Counters.IncrementOne("outbox." + entry.queue + ".failed_messages")
+			c.Logger.Warn(correlationId, "Failed to relay message %s to %s: %s", entry.envelope.MessageId, entry.queue, err.Error())
+
+			entry.attempts++
+			if maxAttempts > 0 && entry.attempts >= maxAttempts {
+				// The message gives way to later messages to the queue
+				c.Counters.IncrementOne("outbox." + entry.queue + ".dead_messages")
+				c.Logger.Error(correlationId, err, "Gave up to relay message %s to %s after %d attempts",
+					entry.envelope.MessageId, entry.queue, entry.attempts)
+				if err = outbox.deadEntry(correlationId, entry.id, entry.attempts); err != nil {
+					return sent, err
+				}
+				continue
+			}
+
+			// Later messages to the queue wait for this one to keep the order
+			blocked[entry.queue] = true
+			if err = outbox.retryEntry(correlationId, entry.id, entry.attempts, now()+c.retryDelay(entry.attempts)); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err = outbox.removeEntry(correlationId, entry.id); err != nil {
+			return sent, err
+		}
+		sent++
+		c.Counters.IncrementOne("outbox." + entry.queue + ".relayed_messages")
+	}
+
+	if sent > 0 {
+		c.Logger.Debug(correlationId, "Relayed %d messages from outbox", sent)
+	}
+	return sent, nil
+}
+
+func (c *SqlOutboxRelay) send(correlationId string, entry *outboxEntry) error {
+	c.lock.Lock()
+	queue, ok := c.queues[entry.queue]
+	c.lock.Unlock()
+
+	if !ok {
+		return cerr.NewNotFoundError(correlationId, "QUEUE_NOT_FOUND", "Queue "+entry.queue+" is not found").
+			WithDetails("queue", entry.queue)
+	}
+	return queue.Send(correlationId, entry.envelope)
+}
+
+// retryDelay calculates the delay in milliseconds before the next attempt to send a message.
+func (c *SqlOutboxRelay) retryDelay(attempts int) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delay := c.retryTimeout
+	for i := 1; i < attempts && delay < c.maxRetryTimeout; i++ {
+		delay *= 2
+	}
+	if delay > c.maxRetryTimeout {
+		delay = c.maxRetryTimeout
+	}
+	return int64(delay / time.Millisecond)
+}
+
+// run periodically relays messages until the relay is closed.
+func (c *SqlOutboxRelay) run(stop chan struct{}, interval time.Duration, batchSize int) {
+	defer c.stopped.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// Full batches are followed by the next ones without waiting
+			for {
+				sent, err := c.Relay("")
+				if err != nil {
+					c.Logger.Error("", err, "Failed to relay messages from outbox")
+				}
+				if err != nil || sent < int64(batchSize) {
+					break
+				}
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package sqldb
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+)
+
+/*
+SqlProcessedMessageStore Store that keeps ids of processed messages in a relational database,
+so several instances of a consumer share them and they survive restarts.
+Ids are forgotten when their time to live passes. Expired rows are deleted periodically.
+Messages are claimed with a single upsert, so consumers in different processes
+that receive the same message at the same time do not process it twice.
+
+Configuration parameters:
+
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - protocol:                  database dialect: postgres or sqlite
+    - host:                      host name or IP address (postgres)
+    - port:                      port number (default: 5432)
+    - database:                  database name (postgres) or path to the database file (sqlite)
+    - uri:                       connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  user name
+    - password:                  user password
+  - options:
+    - ttl:                       time in milliseconds to remember processed messages (default: 86400000)
+    - claim_timeout:             time in milliseconds to keep claims of messages that are being processed (default: 60000)
+    - table_prefix:              prefix of the tables (default: mq_)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  ICredentialStore componetns to lookup credential(s)
+- *:connection:sql:*:1.0     (optional)  Shared SqlConnection; when absent the store opens its own connection
+
+See IProcessedMessageStore
+See SqlConnection
+
+Example:
+
+    store := NewSqlProcessedMessageStore()
+    store.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.protocol", "sqlite",
+        "connection.database", "./data/processed.db",
+    ))
+    store.Open("123")
+
+    receiver := queues.NewIdempotentMessageReceiver(NewMyMessageReceiver(), store)
+    queue.BeginListen("123", receiver)
+*/
+type SqlProcessedMessageStore struct {
+	dependencyResolver *cref.DependencyResolver
+	config             *cconf.ConfigParams
+	references         cref.IReferences
+	localConnection    *SqlConnection
+
+	// The connection to the database
+	Connection *SqlConnection
+
+	ttl          time.Duration
+	claimTimeout time.Duration
+	opened       bool
+	lastClean    time.Time
+	lock         sync.Mutex
+}
+
+// NewSqlProcessedMessageStore method are creates a new instance of the store.
+// Returns: *SqlProcessedMessageStore
+func NewSqlProcessedMessageStore() *SqlProcessedMessageStore {
+	c := SqlProcessedMessageStore{
+		dependencyResolver: cref.NewDependencyResolver(),
+		config:             cconf.NewEmptyConfigParams(),
+		ttl:                24 * time.Hour,
+		claimTimeout:       60 * time.Second,
+	}
+	c.dependencyResolver.Put("connection", cref.NewDescriptor("pip-services", "connection", "sql", "*", "1.0"))
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *SqlProcessedMessageStore) Configure(config *cconf.ConfigParams) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.config = config
+	c.dependencyResolver.Configure(config)
+	c.ttl = time.Duration(config.GetAsLongWithDefault("options.ttl", int64(c.ttl/time.Millisecond))) * time.Millisecond
+	c.claimTimeout = time.Duration(config.GetAsLongWithDefault("options.claim_timeout", int64(c.claimTimeout/time.Millisecond))) * time.Millisecond
+}
+
+// SetReferences method are sets references to dependent components.
+//   - references 	references to locate the component dependencies.
+func (c *SqlProcessedMessageStore) SetReferences(references cref.IReferences) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.references = references
+	c.dependencyResolver.SetReferences(references)
+	connection, ok := c.dependencyResolver.GetOneOptional("connection").(*SqlConnection)
+	if ok {
+		c.Connection = connection
+	}
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *SqlProcessedMessageStore) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.opened
+}
+
+// Open method are opens the component and creates the table of processed messages.
+// When no shared connection is referenced, a local connection is opened.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlProcessedMessageStore) Open(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.opened {
+		return nil
+	}
+
+	if c.Connection == nil || c.localConnection != nil {
+		if c.localConnection == nil {
+			c.localConnection = NewSqlConnection()
+			c.localConnection.Configure(c.config)
+			if c.references != nil {
+				c.localConnection.SetReferences(c.references)
+			}
+			c.Connection = c.localConnection
+		}
+		if err := c.localConnection.Open(correlationId); err != nil {
+			return err
+		}
+	}
+
+	db, dialect, err := c.Connection.checkOpen(correlationId)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Connection to database is not opened")
+	}
+	for _, statement := range dialect.processedSchema() {
+		if _, err = db.Exec(statement); err != nil {
+			return c.Connection.wrapError(correlationId, err)
+		}
+	}
+
+	c.opened = true
+	c.lastClean = time.Now()
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlProcessedMessageStore) Close(correlationId string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.opened {
+		return nil
+	}
+	c.opened = false
+
+	if c.localConnection != nil {
+		return c.localConnection.Close(correlationId)
+	}
+	return nil
+}
+
+// Clear method are forgets all processed messages.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *SqlProcessedMessageStore) Clear(correlationId string) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("DELETE FROM {processed_messages}"))
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// IsProcessed method are checks if a message with the given id was processed within the time to live.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was processed or error.
+func (c *SqlProcessedMessageStore) IsProcessed(correlationId string, messageId string) (bool, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = db.QueryRow(
+		dialect.rebind("SELECT COUNT(*) FROM {processed_messages} WHERE message_id=? AND processed=1 AND expiration_time>?"),
+		messageId, now(),
+	).Scan(&count)
+	if err != nil {
+		return false, c.Connection.wrapError(correlationId, err)
+	}
+	return count > 0, nil
+}
+
+// TryMarkProcessing method are atomically claims a message with the given id for processing.
+// The claim fails when the message was processed or is claimed by another consumer within the claim timeout.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: true if the message was claimed or error.
+func (c *SqlProcessedMessageStore) TryMarkProcessing(correlationId string, messageId string) (bool, error) {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return false, err
+	}
+
+	c.lock.Lock()
+	expiration := time.Now().Add(c.claimTimeout).UnixMilli()
+	c.lock.Unlock()
+
+	// Rows that are not expired are not updated, so only one consumer gets the claim
+	result, err := db.Exec(
+		dialect.rebind("INSERT INTO {processed_messages} (message_id, expiration_time, processed) VALUES (?, ?, 0) "+
+			"ON CONFLICT (message_id) DO UPDATE SET expiration_time=?, processed=0 "+
+			"WHERE {processed_messages}.expiration_time<=?"),
+		messageId, expiration, expiration, now(),
+	)
+	if err != nil {
+		return false, c.Connection.wrapError(correlationId, err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, c.Connection.wrapError(correlationId, err)
+	}
+	return count > 0, nil
+}
+
+// UnmarkProcessing method are releases a claim of a message that failed to be processed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *SqlProcessedMessageStore) UnmarkProcessing(correlationId string, messageId string) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(dialect.rebind("DELETE FROM {processed_messages} WHERE message_id=? AND processed=0"), messageId)
+	return c.Connection.wrapError(correlationId, err)
+}
+
+// MarkProcessed method are remembers that a message with the given id was processed and releases its claim.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageId         an id of the message.
+// Returns: error or nil for success.
+func (c *SqlProcessedMessageStore) MarkProcessed(correlationId string, messageId string) error {
+	db, dialect, err := c.checkOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	expiration := time.Now().Add(c.ttl).UnixMilli()
+	clean := time.Since(c.lastClean) >= c.ttl
+	if clean {
+		c.lastClean = time.Now()
+	}
+	c.lock.Unlock()
+
+	// Expired rows are deleted at most once per time to live
+	if clean {
+		_, err = db.Exec(dialect.rebind("DELETE FROM {processed_messages} WHERE expiration_time<=?"), now())
+		if err != nil {
+			return c.Connection.wrapError(correlationId, err)
+		}
+	}
+
+	_, err = db.Exec(
+		dialect.rebind("INSERT INTO {processed_messages} (message_id, expiration_time, processed) VALUES (?, ?, 1) "+
+			"ON CONFLICT (message_id) DO UPDATE SET expiration_time=?, processed=1"),
+		messageId, expiration, expiration,
+	)
+	return c.Connection.wrapError(correlationId, err)
+}
+
+func (c *SqlProcessedMessageStore) checkOpen(correlationId string) (*sql.DB, *sqlDialect, error) {
+	c.lock.Lock()
+	opened := c.opened
+	c.lock.Unlock()
+
+	if !opened {
+		return nil, nil, cerr.NewInvalidStateError(correlationId, "NOT_OPENED", "The store is not opened")
+	}
+	return c.Connection.checkOpen(correlationId)
+}
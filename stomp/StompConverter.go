@@ -0,0 +1,93 @@
+package stomp
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-stomp/stomp/v3/frame"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// Names of frame headers that carry envelope fields
+const (
+	headerMessageId     = "pip-message-id"
+	headerCorrelationId = "pip-correlation-id"
+	headerMessageType   = "pip-message-type"
+	headerSentTime      = "pip-sent-time"
+	headerGroupId       = "pip-group-id"
+	headerPrefix        = "pip-"
+	headerPrefetchCount = "prefetch-count"
+)
+
+// Standard STOMP headers that are not copied into envelope headers
+var stompHeaders = map[string]bool{
+	frame.ContentLength: true,
+	frame.ContentType:   true,
+	frame.Receipt:       true,
+	frame.Destination:   true,
+	frame.Id:            true,
+	frame.Ack:           true,
+	frame.Transaction:   true,
+	frame.Subscription:  true,
+	frame.MessageId:     true,
+	headerPrefetchCount: true,
+}
+
+// queuePrefix is a prefix of destinations that address queues
+const queuePrefix = "/queue/"
+
+// fromEnvelope converts envelope fields into frame headers.
+func fromEnvelope(envelope *queues.MessageEnvelope) []string {
+	headers := []string{
+		headerMessageId, envelope.MessageId,
+		headerCorrelationId, envelope.CorrelationId,
+		headerMessageType, envelope.MessageType,
+	}
+	if !envelope.SentTime.IsZero() {
+		headers = append(headers, headerSentTime, strconv.FormatInt(envelope.SentTime.UnixMilli(), 10))
+	}
+	if envelope.GroupId != "" {
+		headers = append(headers, headerGroupId, envelope.GroupId)
+	}
+	for key, value := range envelope.Headers {
+		if !stompHeaders[key] && !strings.HasPrefix(key, headerPrefix) {
+			headers = append(headers, key, value)
+		}
+	}
+	return headers
+}
+
+// toEnvelope restores a message envelope from frame headers and body.
+// Frames sent by clients that do not set envelope headers get new message ids.
+func toEnvelope(header *frame.Header, body []byte) *queues.MessageEnvelope {
+	envelope := queues.NewMessageEnvelope(header.Get(headerCorrelationId), header.Get(headerMessageType), body)
+	if messageId := header.Get(headerMessageId); messageId != "" {
+		envelope.MessageId = messageId
+	}
+	if sentTime, err := strconv.ParseInt(header.Get(headerSentTime), 10, 64); err == nil {
+		envelope.SentTime = time.UnixMilli(sentTime)
+	}
+	envelope.GroupId = header.Get(headerGroupId)
+	for i := 0; i < header.Len(); i++ {
+		key, value := header.GetAt(i)
+		if !stompHeaders[key] && !strings.HasPrefix(key, headerPrefix) && envelope.GetHeader(key) == "" {
+			envelope.SetHeader(key, value)
+		}
+	}
+	return envelope
+}
+
+// toDestination converts a queue name into a STOMP destination.
+// Names that already look like destinations are left as they are.
+func toDestination(name string) string {
+	if strings.HasPrefix(name, "/") {
+		return name
+	}
+	return queuePrefix + name
+}
+
+// toQueueName converts a STOMP destination into a queue name.
+func toQueueName(destination string) string {
+	return strings.TrimPrefix(destination, queuePrefix)
+}
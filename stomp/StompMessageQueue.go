@@ -0,0 +1,495 @@
+package stomp
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-stomp/stomp/v3"
+	"github.com/go-stomp/stomp/v3/frame"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+StompMessageQueue message queue that sends and receives messages via STOMP 1.2 message broker,
+like ActiveMQ, RabbitMQ with STOMP plugin or StompServer.
+
+Messages are sent to the queue destination with receipts. They are received through
+a subscription with client-individual acknowledgements that is created on the first
+Receive or Listen call, so queues used only to send messages do not take them from other consumers.
+Complete sends ACK and Abandon sends NACK. MoveToDeadLetter sends a copy of the message
+to the dead letter destination and acknowledges the original.
+
+STOMP has no means to browse or count messages, so Peek, PeekBatch and ReadMessageCount
+are not supported, and RenewLock does nothing.
+
+Configuration parameters:
+
+  - name:                        name of the message queue, it is converted to "/queue/{name}" destination unless it starts with "/"
+  - connection(s):
+    - discovery_key:             key to retrieve parameters from discovery service
+    - host:                      host name or IP address
+    - port:                      port number (default: 61613)
+    - uri:                       resource URI or connection string with all parameters in it
+  - credential(s):
+    - store_key:                 key to retrieve parameters from credential store
+    - username:                  login to the broker
+    - password:                  passcode to the broker
+  - options:
+    - dead_letter:               dead letter destination (default: queue destination with ".dead" suffix)
+    - prefetch:                  maximum number of unacknowledged messages sent to the subscription (default: 100)
+    - timeout:                   timeout in milliseconds of send receipts (default: 30000)
+
+References:
+
+- *:logger:*:*:1.0             (optional) ILogger components to pass log messages
+- *:counters:*:*:1.0           (optional) ICounters components to pass collected measurements
+- *:discovery:*:*:1.0          (optional) IDiscovery services to resolve connections
+- *:credential-store:*:*:1.0   (optional) Credential stores to resolve credentials
+
+See MessageQueue
+See StompServer
+
+Example:
+
+    queue := NewStompMessageQueue("myqueue")
+    queue.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.host", "localhost",
+        "connection.port", 61613,
+    ))
+    queue.Open("123")
+
+    queue.Send("123", queues.NewMessageEnvelope("", "mymessage", []byte("ABC")))
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    if message != nil {
+        ...
+        queue.Complete(message)
+    }
+*/
+type StompMessageQueue struct {
+	queues.MessageQueue
+	destination  string
+	deadLetter   string
+	prefetch     int
+	timeout      time.Duration
+	address      string
+	credential   *cauth.CredentialParams
+	conn         *stomp.Conn
+	subscription *stomp.Subscription
+	opened       int32
+	cancel       int32
+}
+
+// NewStompMessageQueue method are creates a new instance of the message queue.
+//   - name  (optional) a queue name.
+// Returns: *StompMessageQueue
+// See MessagingCapabilities
+func NewStompMessageQueue(name string) *StompMessageQueue {
+	c := StompMessageQueue{}
+
+	c.MessageQueue = *queues.InheritMessageQueue(
+		&c, name, queues.NewMessagingCapabilities(false, true, true, false, false, false, true, true, false),
+	)
+
+	c.prefetch = 100
+	c.timeout = 30000 * time.Millisecond
+
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *StompMessageQueue) Configure(config *cconf.ConfigParams) {
+	c.MessageQueue.Configure(config)
+
+	c.deadLetter = config.GetAsStringWithDefault("options.dead_letter", c.deadLetter)
+	c.prefetch = config.GetAsIntegerWithDefault("options.prefetch", c.prefetch)
+	c.timeout = time.Duration(config.GetAsLongWithDefault("options.timeout", int64(c.timeout/time.Millisecond))) * time.Millisecond
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *StompMessageQueue) IsOpen() bool {
+	return atomic.LoadInt32(&c.opened) != 0
+}
+
+// OpenWithParams method are opens the component with given connection and credential parameters.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - connections       connection parameters
+//   - credential        credential parameters
+// Returns: error or nil no errors occured.
+func (c *StompMessageQueue) OpenWithParams(correlationId string, connections []*cconn.ConnectionParams,
+	credential *cauth.CredentialParams) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	address, err := c.composeAddress(correlationId, connections[0])
+	if err != nil {
+		return err
+	}
+
+	c.address = address
+	c.credential = credential
+	c.destination = toDestination(c.Name())
+	if c.deadLetter == "" {
+		c.deadLetter = c.destination + ".dead"
+	}
+
+	c.Lock.Lock()
+	_, err = c.connect(correlationId)
+	c.Lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	atomic.StoreInt32(&c.cancel, 0)
+	atomic.StoreInt32(&c.opened, 1)
+
+	c.Logger.Debug(correlationId, "Opened queue %s at %s", c.Name(), address)
+
+	return nil
+}
+
+// Close method are closes component and frees used resources.
+// Messages that were received and not acknowledged return into the queue on the broker.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *StompMessageQueue) Close(correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	atomic.StoreInt32(&c.cancel, 1)
+	atomic.StoreInt32(&c.opened, 0)
+
+	c.Lock.Lock()
+	conn := c.conn
+	c.conn = nil
+	c.subscription = nil
+	c.Lock.Unlock()
+
+	if conn != nil {
+		conn.Disconnect()
+	}
+
+	c.Logger.Debug(correlationId, "Closed queue %s", c.Name())
+
+	return nil
+}
+
+// Clear method are clears component state.
+// STOMP does not support purging of queues, so the method does nothing.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *StompMessageQueue) Clear(correlationId string) error {
+	return nil
+}
+
+// ReadMessageCount method are reads the current number of messages in the queue to be delivered.
+// STOMP does not support counting of messages, so the method always returns 0.
+// Returns: number of messages or error.
+func (c *StompMessageQueue) ReadMessageCount() (int64, error) {
+	return 0, nil
+}
+
+// Send method are sends a message into the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - envelope          a message envelop to be sent.
+// Returns: error or nil for success.
+func (c *StompMessageQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	envelope.SentTime = time.Now()
+
+	err := c.send(correlationId, c.destination, envelope)
+	if err != nil {
+		return err
+	}
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".sent_messages")
+	c.Logger.Debug(envelope.CorrelationId, "Sent message %s via %s", envelope.String(), c.Name())
+
+	return nil
+}
+
+// Peek meethod are peeks a single incoming message from the queue without removing it.
+// STOMP does not support browsing of messages, so the method always returns nil.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+// Returns: a message or error.
+func (c *StompMessageQueue) Peek(correlationId string) (*queues.MessageEnvelope, error) {
+	return nil, nil
+}
+
+// PeekBatch method are peeks multiple incoming messages from the queue without removing them.
+// STOMP does not support browsing of messages, so the method always returns an empty list.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - messageCount      a maximum number of messages to peek.
+// Returns: a list with messages or error.
+func (c *StompMessageQueue) PeekBatch(correlationId string, messageCount int64) ([]*queues.MessageEnvelope, error) {
+	return []*queues.MessageEnvelope{}, nil
+}
+
+// Receive method are receives an incoming message and removes it from the queue.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - waitTimeout       a timeout in milliseconds to wait for a message to come.
+// Returns: a message or error.
+func (c *StompMessageQueue) Receive(correlationId string, waitTimeout time.Duration) (*queues.MessageEnvelope, error) {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock.Lock()
+	subscription, err := c.subscribe(correlationId)
+	conn := c.conn
+	c.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(waitTimeout)
+	defer timer.Stop()
+
+	var msg *stomp.Message
+	select {
+	case msg = <-subscription.C:
+	case <-timer.C:
+		return nil, nil
+	}
+
+	if msg == nil || msg.Err != nil {
+		c.disconnect(conn)
+		if msg == nil {
+			return nil, cerr.NewConnectionError(correlationId, "CONNECTION_LOST", "Connection to STOMP broker was lost")
+		}
+		return nil, c.wrapError(correlationId, msg.Err)
+	}
+
+	message := toEnvelope(msg.Header, msg.Body)
+	message.SetReference(msg)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".received_messages")
+	c.Logger.Debug(message.CorrelationId, "Received message %s via %s", message, c.Name())
+
+	return message, nil
+}
+
+// RenewLock method are renews a lock on a message that makes it invisible from other receivers in the queue.
+// Messages stay locked by STOMP brokers until they are acknowledged, so the method does nothing.
+//   - message       a message to extend its lock.
+//   - lockTimeout   a locking timeout in milliseconds.
+// Returns:  error or nil for success.
+func (c *StompMessageQueue) RenewLock(message *queues.MessageEnvelope, lockTimeout time.Duration) error {
+	return nil
+}
+
+// Complete method are permanently removes a message from the queue.
+// This method is usually used to remove the message after successful processing.
+//   - message   a message to remove.
+// Returns: error or nil for success.
+func (c *StompMessageQueue) Complete(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(*stomp.Message)
+	if !ok {
+		return nil
+	}
+
+	err := msg.Conn.Ack(msg)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Completed message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Abandon method are returnes message into the queue and makes it available for all subscribers to receive it again.
+// This method is usually used to return a message which could not be processed at the moment
+// to repeat the attempt. Messages that cause unrecoverable errors shall be removed permanently
+// or/and send to dead letter queue.
+//   - message   a message to return.
+// Returns: error or nil for success.
+func (c *StompMessageQueue) Abandon(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(*stomp.Message)
+	if !ok {
+		return nil
+	}
+
+	err := msg.Conn.Nack(msg)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Logger.Trace(message.CorrelationId, "Abandoned message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// MoveToDeadLetter method are permanently removes a message from the queue and sends it to dead letter queue.
+//   - message   a message to be removed.
+// Returns: error or nil for success.
+func (c *StompMessageQueue) MoveToDeadLetter(message *queues.MessageEnvelope) error {
+	msg, ok := message.GetReference().(*stomp.Message)
+	if !ok {
+		return nil
+	}
+
+	err := c.send(message.CorrelationId, c.deadLetter, message)
+	if err != nil {
+		return err
+	}
+
+	err = msg.Conn.Ack(msg)
+	if err != nil {
+		return c.wrapError(message.CorrelationId, err)
+	}
+	message.SetReference(nil)
+
+	c.Counters.IncrementOne("queue." + c.Name() + ".dead_messages")
+	c.Logger.Trace(message.CorrelationId, "Moved to dead message %s at %s", message, c.Name())
+
+	return nil
+}
+
+// Listen method are listens for incoming messages and blocks the current thread until queue is closed.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+//   - receiver          a receiver to receive incoming messages.
+// See IMessageReceiver
+// See Receive
+func (c *StompMessageQueue) Listen(correlationId string, receiver queues.IMessageReceiver) error {
+	return c.ListenMessages(correlationId, receiver, &c.cancel)
+}
+
+// EndListen method are ends listening for incoming messages.
+// When c method is call listen unblocks the thread and execution continues.
+//   - correlationId     (optional) transaction id to trace execution through call chain.
+func (c *StompMessageQueue) EndListen(correlationId string) {
+	atomic.StoreInt32(&c.cancel, 1)
+}
+
+// send sends a message to the destination and waits for the broker receipt.
+func (c *StompMessageQueue) send(correlationId string, destination string, envelope *queues.MessageEnvelope) error {
+	err := c.CheckOpen(correlationId)
+	if err != nil {
+		return err
+	}
+
+	c.Lock.Lock()
+	conn, err := c.connect(correlationId)
+	c.Lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	options := []func(*frame.Frame) error{stomp.SendOpt.Receipt}
+	headers := fromEnvelope(envelope)
+	for i := 0; i < len(headers); i += 2 {
+		options = append(options, stomp.SendOpt.Header(headers[i], headers[i+1]))
+	}
+
+	err = conn.Send(destination, "", envelope.Message, options...)
+	if err != nil {
+		c.disconnect(conn)
+		return c.wrapError(correlationId, err)
+	}
+	return nil
+}
+
+// subscribe subscribes to the queue destination if it is not subscribed.
+// It must be called under the queue lock.
+func (c *StompMessageQueue) subscribe(correlationId string) (*stomp.Subscription, error) {
+	if c.subscription != nil {
+		return c.subscription, nil
+	}
+
+	conn, err := c.connect(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := conn.Subscribe(c.destination, stomp.AckClientIndividual,
+		stomp.SubscribeOpt.Header(headerPrefetchCount, strconv.Itoa(c.prefetch)))
+	if err != nil {
+		return nil, c.wrapError(correlationId, err)
+	}
+
+	c.subscription = subscription
+	return subscription, nil
+}
+
+// connect establishes a connection to the broker if it is not connected.
+// It must be called under the queue lock.
+func (c *StompMessageQueue) connect(correlationId string) (*stomp.Conn, error) {
+	if c.conn != nil {
+		return c.conn, nil
+	}
+
+	options := []func(*stomp.Conn) error{
+		stomp.ConnOpt.AcceptVersion(stomp.V12),
+		stomp.ConnOpt.HeartBeat(0, 0),
+		stomp.ConnOpt.MsgSendTimeout(c.timeout),
+		stomp.ConnOpt.RcvReceiptTimeout(c.timeout),
+	}
+	if c.credential != nil && c.credential.Username() != "" {
+		options = append(options, stomp.ConnOpt.Login(c.credential.Username(), c.credential.Password()))
+	}
+
+	conn, err := stomp.Dial("tcp", c.address, options...)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to connect to STOMP broker at "+c.address).
+			WithCause(err)
+	}
+
+	c.conn = conn
+	return conn, nil
+}
+
+// disconnect drops a failed connection, so the next call establishes a new one.
+func (c *StompMessageQueue) disconnect(conn *stomp.Conn) {
+	c.Lock.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		c.subscription = nil
+	}
+	c.Lock.Unlock()
+
+	if conn != nil {
+		conn.MustDisconnect()
+	}
+}
+
+func (c *StompMessageQueue) wrapError(correlationId string, err error) error {
+	return cerr.NewConnectionError(correlationId, "OPERATION_FAILED", "Failed to execute STOMP operation").
+		WithCause(err)
+}
+
+// composeAddress composes broker address from connection parameters.
+func (c *StompMessageQueue) composeAddress(correlationId string, connection *cconn.ConnectionParams) (string, error) {
+	host := connection.Host()
+	port := connection.PortWithDefault(61613)
+
+	if uri := connection.Uri(); uri != "" {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Hostname() == "" {
+			return "", cerr.NewConfigError(correlationId, "WRONG_URI", "Invalid STOMP connection uri").
+				WithCause(err)
+		}
+		host = parsed.Hostname()
+		if parsed.Port() != "" {
+			port, _ = strconv.Atoi(parsed.Port())
+		}
+	}
+
+	if host == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_HOST", "Connection host is not set")
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
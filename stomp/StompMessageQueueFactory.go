@@ -0,0 +1,56 @@
+package stomp
+
+import (
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+// StompMessageQueueFactory are creates StompMessageQueue and StompServer components by their descriptors.
+// Name of created message queue is taken from its descriptor.
+//
+// See Factory
+// See StompMessageQueue
+// See StompServer
+type StompMessageQueueFactory struct {
+	build.MessageQueueFactory
+}
+
+// NewStompMessageQueueFactory method are create a new instance of the factory.
+func NewStompMessageQueueFactory() *StompMessageQueueFactory {
+	c := StompMessageQueueFactory{
+		MessageQueueFactory: *build.InheritMessageQueueFactory(),
+	}
+
+	stompQueueDescriptor := cref.NewDescriptor("pip-services", "message-queue", "stomp", "*", "1.0")
+	stompServerDescriptor := cref.NewDescriptor("pip-services", "message-broker", "stomp", "*", "1.0")
+
+	c.Register(stompQueueDescriptor, func(locator interface{}) interface{} {
+		name := ""
+		descriptor, ok := locator.(*cref.Descriptor)
+		if ok {
+			name = descriptor.Name()
+		}
+		return c.CreateQueue(name)
+	})
+	c.RegisterType(stompServerDescriptor, NewStompServer)
+
+	return &c
+}
+
+// Creates a message queue component and assigns its name.
+//
+// Parameters:
+//   - name: a name of the created message queue.
+func (c *StompMessageQueueFactory) CreateQueue(name string) queues.IMessageQueue {
+	queue := NewStompMessageQueue(name)
+
+	if c.Config != nil {
+		queue.Configure(c.Config)
+	}
+	if c.References != nil {
+		queue.SetReferences(c.References)
+	}
+
+	return queue
+}
@@ -0,0 +1,679 @@
+package stomp
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-stomp/stomp/v3/frame"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	cauth "github.com/pip-services3-go/pip-services3-components-go/auth"
+	cconn "github.com/pip-services3-go/pip-services3-components-go/connect"
+	ccount "github.com/pip-services3-go/pip-services3-components-go/count"
+	clog "github.com/pip-services3-go/pip-services3-components-go/log"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+)
+
+/*
+StompServer STOMP 1.2 server front-end for MemoryMessageQueue instances.
+It lets components that speak STOMP exchange messages with Go services
+that use the same memory queues in the process.
+
+Destinations "/queue/{name}" address queues by their names, other destinations
+are used as queue names as they are. Queues are taken from references
+or created on the first request to them.
+
+Subscriptions support auto, client and client-individual acknowledgement modes.
+ACK completes messages, NACK abandons them. In client modes no more than
+"prefetch-count" subscription header (default: options.prefetch) messages
+are sent until earlier ones are acknowledged. When a subscription or a connection
+is closed, its unacknowledged messages are abandoned and delivered again.
+Transactions are not supported.
+
+Configuration parameters:
+
+  - connection(s):
+    - host:                      host name or IP address to listen on (default: 0.0.0.0)
+    - port:                      port number to listen on, 0 to pick a free port (default: 61613)
+  - credential(s):
+    - username:                  login required from clients (default: no authentication)
+    - password:                  passcode required from clients
+  - options:
+    - prefetch:                  default maximum number of unacknowledged messages per subscription (default: 100)
+
+References:
+
+- *:logger:*:*:1.0           (optional)  ILogger components to pass log messages
+- *:counters:*:*:1.0         (optional)  ICounters components to pass collected measurements
+- *:discovery:*:*:1.0        (optional)  IDiscovery components to discover connection(s)
+- *:credential-store:*:*:1.0 (optional)  Credential stores to resolve credential(s)
+- *:message-queue:memory:*:1.0 (optional) MemoryMessageQueue components or SharedMemoryMessageQueue handles to expose
+
+See StompMessageQueue
+See MemoryMessageQueue
+
+Example:
+
+    server := NewStompServer()
+    server.Configure(cconf.NewConfigParamsFromTuples(
+        "connection.port", 61613,
+    ))
+    server.Open("123")
+
+    queue := server.GetQueue("orders")
+    message, err := queue.Receive("123", 10000*time.Millisecond)
+    ...
+    server.Close("123")
+*/
+type StompServer struct {
+	Logger             *clog.CompositeLogger
+	Counters           *ccount.CompositeCounters
+	ConnectionResolver *cconn.ConnectionResolver
+	CredentialResolver *cauth.CredentialResolver
+	prefetch           int
+	references         cref.IReferences
+	credential         *cauth.CredentialParams
+	listener           net.Listener
+	queues             map[string]*queues.MemoryMessageQueue
+	connections        map[*stompConnection]bool
+	wait               sync.WaitGroup
+	lock               sync.Mutex
+}
+
+// stompConnection is a client connection served by the server.
+type stompConnection struct {
+	server        *StompServer
+	conn          net.Conn
+	writer        *frame.Writer
+	writeLock     sync.Mutex
+	subscriptions map[string]*stompSubscription
+	lock          sync.Mutex
+}
+
+// stompSubscription delivers messages of a queue to a client subscription.
+type stompSubscription struct {
+	id      string
+	ack     string
+	queue   *queues.MemoryMessageQueue
+	credits chan struct{}
+	done    chan struct{}
+	stopped sync.WaitGroup
+	acks    []string
+	locks   map[string]*queues.MessageEnvelope
+	lock    sync.Mutex
+}
+
+// NewStompServer method are creates a new instance of the STOMP server.
+func NewStompServer() *StompServer {
+	c := StompServer{
+		Logger:             clog.NewCompositeLogger(),
+		Counters:           ccount.NewCompositeCounters(),
+		ConnectionResolver: cconn.NewEmptyConnectionResolver(),
+		CredentialResolver: cauth.NewEmptyCredentialResolver(),
+		prefetch:           100,
+		queues:             map[string]*queues.MemoryMessageQueue{},
+		connections:        map[*stompConnection]bool{},
+	}
+	return &c
+}
+
+// Configure method are configures component by passing configuration parameters.
+//   - config    configuration parameters to be set.
+func (c *StompServer) Configure(config *cconf.ConfigParams) {
+	c.ConnectionResolver.Configure(config)
+	c.CredentialResolver.Configure(config)
+
+	c.prefetch = config.GetAsIntegerWithDefault("options.prefetch", c.prefetch)
+}
+
+// SetReferences method are sets references to dependent components and collects memory queues to expose.
+// The references are also passed to created queues.
+//   - references 	references to locate the component dependencies.
+func (c *StompServer) SetReferences(references cref.IReferences) {
+	c.references = references
+	c.Logger.SetReferences(references)
+	c.Counters.SetReferences(references)
+	c.ConnectionResolver.SetReferences(references)
+	c.CredentialResolver.SetReferences(references)
+
+	components := references.GetOptional(cref.NewDescriptor("*", "message-queue", "memory", "*", "1.0"))
+	for _, component := range components {
+		switch queue := component.(type) {
+		case *queues.MemoryMessageQueue:
+			c.AddQueue(queue)
+		case *connect.SharedMemoryMessageQueue:
+			c.AddQueue(queue.Queue())
+		}
+	}
+}
+
+// AddQueue method are exposes a memory queue through the server by its name.
+//   - queue    a queue to expose.
+func (c *StompServer) AddQueue(queue *queues.MemoryMessageQueue) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.queues[queue.Name()] = queue
+}
+
+// IsOpen method are checks if the component is opened.
+// Returns: true if the component has been opened and false otherwise.
+func (c *StompServer) IsOpen() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.listener != nil
+}
+
+// Open method are starts listening for client connections.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *StompServer) Open(correlationId string) error {
+	if c.IsOpen() {
+		return nil
+	}
+
+	connection, err := c.ConnectionResolver.Resolve(correlationId)
+	if err != nil {
+		return err
+	}
+	credential, err := c.CredentialResolver.Lookup(correlationId)
+	if err != nil {
+		return err
+	}
+
+	host := "0.0.0.0"
+	port := 61613
+	if connection != nil {
+		if connection.Host() != "" {
+			host = connection.Host()
+		}
+		port = connection.PortWithDefault(port)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CONNECT_FAILED", "Failed to listen on "+host+":"+strconv.Itoa(port)).
+			WithCause(err)
+	}
+
+	c.lock.Lock()
+	c.listener = listener
+	c.credential = credential
+	c.lock.Unlock()
+
+	c.wait.Add(1)
+	go c.accept(listener)
+
+	c.Logger.Info(correlationId, "Opened STOMP server at %s", listener.Addr().String())
+
+	return nil
+}
+
+// Close method are stops the server, closes client connections and frees used resources.
+//   - correlationId 	(optional) transaction id to trace execution through call chain.
+// Returns: error or nil no errors occured.
+func (c *StompServer) Close(correlationId string) error {
+	c.lock.Lock()
+	listener := c.listener
+	c.listener = nil
+	connections := []*stompConnection{}
+	for connection := range c.connections {
+		connections = append(connections, connection)
+	}
+	c.lock.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	listener.Close()
+	for _, connection := range connections {
+		connection.conn.Close()
+	}
+	c.wait.Wait()
+
+	c.Logger.Info(correlationId, "Closed STOMP server")
+
+	return nil
+}
+
+// GetAddress method are gets the address the server listens on.
+// Returns: the host:port address or empty string if the server is not opened.
+func (c *StompServer) GetAddress() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.listener == nil {
+		return ""
+	}
+	return c.listener.Addr().String()
+}
+
+// GetQueue method are gets an exposed queue by its name and creates it if it does not exist.
+//   - name    a name of the queue.
+// Returns: the memory message queue.
+func (c *StompServer) GetQueue(name string) *queues.MemoryMessageQueue {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	queue, ok := c.queues[name]
+	if !ok {
+		queue = queues.NewMemoryMessageQueue(name)
+		if c.references != nil {
+			queue.SetReferences(c.references)
+		}
+		queue.Open("")
+		c.queues[name] = queue
+	}
+	return queue
+}
+
+// GetQueueNames method are gets names of the exposed queues.
+// Returns: a sorted list with queue names.
+func (c *StompServer) GetQueueNames() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name := range c.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *StompServer) accept(listener net.Listener) {
+	defer c.wait.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		connection := &stompConnection{
+			server:        c,
+			conn:          conn,
+			writer:        frame.NewWriter(conn),
+			subscriptions: map[string]*stompSubscription{},
+		}
+
+		c.lock.Lock()
+		if c.listener == nil {
+			c.lock.Unlock()
+			conn.Close()
+			return
+		}
+		c.connections[connection] = true
+		c.lock.Unlock()
+
+		c.Counters.IncrementOne("stomp.connections")
+		c.wait.Add(1)
+		go connection.serve()
+	}
+}
+
+// serve processes frames from the client until the connection is closed.
+func (c *stompConnection) serve() {
+	defer c.server.wait.Done()
+
+	reader := frame.NewReader(c.conn)
+	connected := false
+	for {
+		f, err := reader.Read()
+		if err != nil {
+			break
+		}
+		// Heart-beat
+		if f == nil {
+			continue
+		}
+
+		if !connected {
+			if f.Command != frame.CONNECT && f.Command != frame.STOMP {
+				c.sendError(f, "Expected CONNECT frame")
+				break
+			}
+			if !c.connect(f) {
+				break
+			}
+			connected = true
+			continue
+		}
+
+		if f.Command == frame.DISCONNECT {
+			c.sendReceipt(f)
+			break
+		}
+		if err := c.handle(f); err != nil {
+			c.sendError(f, err.Error())
+			break
+		}
+		c.sendReceipt(f)
+	}
+
+	c.conn.Close()
+
+	// Return messages locked by the client into their queues
+	c.lock.Lock()
+	subscriptions := c.subscriptions
+	c.subscriptions = map[string]*stompSubscription{}
+	c.lock.Unlock()
+
+	for _, subscription := range subscriptions {
+		subscription.stop()
+	}
+
+	c.server.lock.Lock()
+	delete(c.server.connections, c)
+	c.server.lock.Unlock()
+}
+
+// connect checks protocol version and credentials of the client.
+func (c *stompConnection) connect(f *frame.Frame) bool {
+	versions := strings.Split(f.Header.Get(frame.AcceptVersion), ",")
+	supported := false
+	for _, version := range versions {
+		supported = supported || strings.TrimSpace(version) == "1.2"
+	}
+	if !supported {
+		c.write(frame.New(frame.ERROR,
+			frame.Version, "1.2",
+			frame.Message, "Supported protocol version is 1.2",
+		))
+		return false
+	}
+
+	c.server.lock.Lock()
+	credential := c.server.credential
+	c.server.lock.Unlock()
+
+	if credential != nil && credential.Username() != "" {
+		if f.Header.Get(frame.Login) != credential.Username() || f.Header.Get(frame.Passcode) != credential.Password() {
+			c.sendError(f, "Invalid login or passcode")
+			return false
+		}
+	}
+
+	c.write(frame.New(frame.CONNECTED,
+		frame.Version, "1.2",
+		frame.HeartBeat, "0,0",
+		frame.Server, "pip-services-stomp",
+		frame.Session, cdata.IdGenerator.NextLong(),
+	))
+	return true
+}
+
+// handle executes a client frame.
+func (c *stompConnection) handle(f *frame.Frame) error {
+	switch f.Command {
+	case frame.SEND:
+		destination := f.Header.Get(frame.Destination)
+		if destination == "" {
+			return cerr.NewBadRequestError("", "NO_DESTINATION", "Destination header is missing")
+		}
+		queue := c.server.GetQueue(toQueueName(destination))
+		return queue.Send("", toEnvelope(f.Header, f.Body))
+	case frame.SUBSCRIBE:
+		return c.subscribe(f)
+	case frame.UNSUBSCRIBE:
+		id := f.Header.Get(frame.Id)
+		c.lock.Lock()
+		subscription, ok := c.subscriptions[id]
+		delete(c.subscriptions, id)
+		c.lock.Unlock()
+		if !ok {
+			return cerr.NewNotFoundError("", "SUBSCRIPTION_NOT_FOUND", "Subscription "+id+" is not found")
+		}
+		subscription.stop()
+		return nil
+	case frame.ACK, frame.NACK:
+		return c.acknowledge(f)
+	case frame.BEGIN, frame.COMMIT, frame.ABORT:
+		return cerr.NewUnsupportedError("", "NOT_SUPPORTED", "Transactions are not supported")
+	default:
+		return cerr.NewBadRequestError("", "UNKNOWN_COMMAND", "Unknown command "+f.Command)
+	}
+}
+
+// subscribe starts delivering messages of the destination queue to the client.
+func (c *stompConnection) subscribe(f *frame.Frame) error {
+	id := f.Header.Get(frame.Id)
+	destination := f.Header.Get(frame.Destination)
+	if id == "" || destination == "" {
+		return cerr.NewBadRequestError("", "NO_SUBSCRIPTION", "Subscription id or destination header is missing")
+	}
+
+	ack := f.Header.Get(frame.Ack)
+	if ack == "" {
+		ack = frame.AckAuto
+	}
+	if ack != frame.AckAuto && ack != frame.AckClient && ack != frame.AckClientIndividual {
+		return cerr.NewBadRequestError("", "WRONG_ACK", "Invalid ack mode "+ack)
+	}
+
+	prefetch := c.server.prefetch
+	if value, err := strconv.Atoi(f.Header.Get(headerPrefetchCount)); err == nil && value > 0 {
+		prefetch = value
+	}
+	if prefetch < 1 {
+		prefetch = 1
+	}
+
+	subscription := &stompSubscription{
+		id:      id,
+		ack:     ack,
+		queue:   c.server.GetQueue(toQueueName(destination)),
+		credits: make(chan struct{}, prefetch),
+		done:    make(chan struct{}),
+		locks:   map[string]*queues.MessageEnvelope{},
+	}
+	for i := 0; i < prefetch; i++ {
+		subscription.credits <- struct{}{}
+	}
+
+	c.lock.Lock()
+	if _, ok := c.subscriptions[id]; ok {
+		c.lock.Unlock()
+		return cerr.NewConflictError("", "DUPLICATE_SUBSCRIPTION", "Subscription "+id+" already exists")
+	}
+	c.subscriptions[id] = subscription
+	c.lock.Unlock()
+
+	subscription.stopped.Add(2)
+	go c.deliver(subscription, destination)
+	go subscription.renew()
+	return nil
+}
+
+// renew keeps locks of unacknowledged messages in the memory queue
+// until the client acknowledges them or the subscription is stopped.
+func (c *stompSubscription) renew() {
+	defer c.stopped.Done()
+
+	ticker := time.NewTicker(time.Duration(500) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		c.lock.Lock()
+		messages := []*queues.MessageEnvelope{}
+		for _, message := range c.locks {
+			messages = append(messages, message)
+		}
+		c.lock.Unlock()
+
+		for _, message := range messages {
+			c.queue.RenewLock(message, time.Duration(1000)*time.Millisecond)
+		}
+	}
+}
+
+// deliver receives messages from the subscription queue and sends them to the client.
+func (c *stompConnection) deliver(subscription *stompSubscription, destination string) {
+	defer subscription.stopped.Done()
+
+	for {
+		if subscription.ack != frame.AckAuto {
+			select {
+			case <-subscription.done:
+				return
+			case <-subscription.credits:
+			}
+		}
+
+		var message *queues.MessageEnvelope
+		for message == nil {
+			select {
+			case <-subscription.done:
+				return
+			default:
+			}
+
+			var err error
+			message, err = subscription.queue.Receive("", time.Duration(1000)*time.Millisecond)
+			if err != nil {
+				c.server.Logger.Error("", err, "Failed to receive the message")
+				time.Sleep(time.Duration(1000) * time.Millisecond)
+			}
+		}
+
+		headers := []string{
+			frame.Destination, destination,
+			frame.MessageId, message.MessageId,
+			frame.Subscription, subscription.id,
+			frame.ContentLength, strconv.Itoa(len(message.Message)),
+		}
+
+		if subscription.ack == frame.AckAuto {
+			subscription.queue.Complete(message)
+		} else {
+			token := cdata.IdGenerator.NextLong()
+			subscription.lock.Lock()
+			select {
+			case <-subscription.done:
+				subscription.lock.Unlock()
+				subscription.queue.Abandon(message)
+				return
+			default:
+			}
+			subscription.locks[token] = message
+			subscription.acks = append(subscription.acks, token)
+			subscription.lock.Unlock()
+			headers = append(headers, frame.Ack, token)
+		}
+
+		f := frame.New(frame.MESSAGE, append(headers, fromEnvelope(message)...)...)
+		f.Body = message.Message
+		c.server.Counters.IncrementOne("stomp." + subscription.queue.Name() + ".delivered_messages")
+		if err := c.write(f); err != nil {
+			return
+		}
+	}
+}
+
+// acknowledge completes or abandons messages by the ack id.
+// In client mode all earlier messages of the subscription are acknowledged as well.
+func (c *stompConnection) acknowledge(f *frame.Frame) error {
+	id := f.Header.Get(frame.Id)
+
+	c.lock.Lock()
+	var subscription *stompSubscription
+	for _, s := range c.subscriptions {
+		s.lock.Lock()
+		_, ok := s.locks[id]
+		s.lock.Unlock()
+		if ok {
+			subscription = s
+			break
+		}
+	}
+	c.lock.Unlock()
+
+	if subscription == nil {
+		return cerr.NewNotFoundError("", "MESSAGE_NOT_FOUND", "Message with ack "+id+" is not found")
+	}
+
+	messages := subscription.release(id)
+	for _, message := range messages {
+		if f.Command == frame.ACK {
+			subscription.queue.Complete(message)
+		} else {
+			subscription.queue.Abandon(message)
+		}
+		subscription.credits <- struct{}{}
+	}
+	return nil
+}
+
+// release removes acknowledged messages from the subscription.
+func (c *stompSubscription) release(id string) []*queues.MessageEnvelope {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	messages := []*queues.MessageEnvelope{}
+	acks := []string{}
+	found := false
+	for _, token := range c.acks {
+		if found || (c.ack == frame.AckClientIndividual && token != id) {
+			acks = append(acks, token)
+			continue
+		}
+		messages = append(messages, c.locks[token])
+		delete(c.locks, token)
+		found = token == id
+	}
+	c.acks = acks
+	return messages
+}
+
+// stop stops the delivery and abandons unacknowledged messages.
+func (c *stompSubscription) stop() {
+	c.lock.Lock()
+	close(c.done)
+	locks := c.locks
+	c.locks = map[string]*queues.MessageEnvelope{}
+	c.acks = nil
+	c.lock.Unlock()
+
+	// Locks are abandoned before waiting for a pending receive,
+	// otherwise they could expire in the memory queue
+	for _, message := range locks {
+		c.queue.Abandon(message)
+	}
+	c.stopped.Wait()
+}
+
+func (c *stompConnection) sendReceipt(f *frame.Frame) {
+	if receipt := f.Header.Get(frame.Receipt); receipt != "" {
+		c.write(frame.New(frame.RECEIPT, frame.ReceiptId, receipt))
+	}
+}
+
+func (c *stompConnection) sendError(f *frame.Frame, message string) {
+	headers := []string{frame.Message, message}
+	if receipt := f.Header.Get(frame.Receipt); receipt != "" {
+		headers = append(headers, frame.ReceiptId, receipt)
+	}
+	c.write(frame.New(frame.ERROR, headers...))
+}
+
+func (c *stompConnection) write(f *frame.Frame) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	return c.writer.Write(f)
+}
@@ -0,0 +1,184 @@
+package test_amqp
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/amqp"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startBroker(t *testing.T) *cconf.ConfigParams {
+	broker, err := NewAmqpTestBroker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { broker.Close() })
+
+	host, port := broker.Address()
+	return cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+		"credential.username", "guest",
+		"credential.password", "guest",
+	)
+}
+
+func TestAmqpMessageQueue(t *testing.T) {
+	queue := amqp.NewAmqpMessageQueue("TestQueue")
+	queue.Configure(startBroker(t))
+
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("AmqpMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("AmqpMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("AmqpMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("AmqpMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("AmqpMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("AmqpMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("AmqpMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("AmqpMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("AmqpMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("AmqpMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("AmqpMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("AmqpMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestAmqpMessageQueueDeadLetters(t *testing.T) {
+	queue := amqp.NewAmqpMessageQueue("TestQueue")
+	queue.Configure(startBroker(t))
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+
+	err = queue.MoveToDeadLetter(envelope)
+	assert.Nil(t, err)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, envelope.MessageId, messages[0].MessageId)
+	assert.Equal(t, "Test", messages[0].MessageType)
+	assert.Equal(t, "Test message", messages[0].GetMessageAsString())
+
+	// Reading dead letters keeps them in the dead letter queue
+	messages, err = queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+
+	err = queue.Clear("")
+	assert.Nil(t, err)
+
+	messages, err = queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 0)
+}
+
+func TestAmqpMessageQueueRedelivery(t *testing.T) {
+	config := startBroker(t)
+
+	queue := amqp.NewAmqpMessageQueue("TestQueue")
+	queue.Configure(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+
+	// Unacknowledged message returns into the queue on close
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	queue = amqp.NewAmqpMessageQueue("TestQueue")
+	queue.Configure(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	envelope, err = queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "Test message", envelope.GetMessageAsString())
+
+	err = queue.Complete(envelope)
+	assert.Nil(t, err)
+}
+
+func TestAmqpConnection(t *testing.T) {
+	connection := amqp.NewAmqpConnection()
+	connection.Configure(startBroker(t))
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	references := cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "connection", "amqp", "default", "1.0"), connection,
+	)
+
+	queue1 := amqp.NewAmqpMessageQueue("queue1")
+	queue1.SetReferences(references)
+	err = queue1.Open("")
+	assert.Nil(t, err)
+	defer queue1.Close("")
+
+	queue2 := amqp.NewAmqpMessageQueue("queue2")
+	queue2.SetReferences(references)
+	err = queue2.Open("")
+	assert.Nil(t, err)
+	defer queue2.Close("")
+
+	err = connection.CreateQueue("queue3")
+	assert.Nil(t, err)
+
+	names, err := connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue1", "queue2", "queue3"}, names)
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	count, err := queue1.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = queue2.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	err = connection.DeleteQueue("queue1")
+	assert.Nil(t, err)
+
+	names, err = connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue2", "queue3"}, names)
+
+	// Closing the queue keeps the shared connection open
+	err = queue2.Close("")
+	assert.Nil(t, err)
+	assert.True(t, connection.IsOpen())
+}
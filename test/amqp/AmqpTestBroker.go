@@ -0,0 +1,727 @@
+package test_amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// AmqpTestBroker is a minimal in-process AMQP 0-9-1 broker to run tests without RabbitMQ.
+// It supports the subset of the protocol used by AmqpMessageQueue: direct, fanout and default exchanges,
+// durable queues with dead letter exchanges, basic.get, acks, nacks, rejects and publisher confirms.
+// Messages are kept in memory and are lost when the broker stops.
+type AmqpTestBroker struct {
+	listener  net.Listener
+	lock      sync.Mutex
+	exchanges map[string]*testExchange
+	queues    map[string]*testQueue
+	sequence  int
+}
+
+type testExchange struct {
+	kind     string
+	bindings []testBinding
+}
+
+type testBinding struct {
+	queue string
+	key   string
+}
+
+type testQueue struct {
+	name       string
+	arguments  map[string]interface{}
+	messages   []*testMessage
+	deadLetter string
+}
+
+type testMessage struct {
+	exchange    string
+	routingKey  string
+	properties  []byte
+	body        []byte
+	redelivered bool
+}
+
+type testDelivery struct {
+	queue   *testQueue
+	message *testMessage
+}
+
+type testChannel struct {
+	id          uint16
+	confirm     bool
+	publishSeq  uint64
+	deliveryTag uint64
+	unacked     map[uint64]*testDelivery
+	publish     *testPublish
+}
+
+type testPublish struct {
+	exchange   string
+	routingKey string
+	properties []byte
+	size       uint64
+	body       []byte
+}
+
+type testConnection struct {
+	broker    *AmqpTestBroker
+	conn      net.Conn
+	reader    *bufio.Reader
+	writeLock sync.Mutex
+	channels  map[uint16]*testChannel
+	frameMax  uint32
+}
+
+// Frame types and end marker
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+)
+
+// NewAmqpTestBroker starts a broker on a random local port.
+func NewAmqpTestBroker() (*AmqpTestBroker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	broker := &AmqpTestBroker{
+		listener:  listener,
+		exchanges: map[string]*testExchange{"": {kind: "direct"}},
+		queues:    map[string]*testQueue{},
+	}
+	go broker.accept()
+	return broker, nil
+}
+
+// Address returns host and port where the broker listens for connections.
+func (c *AmqpTestBroker) Address() (string, int) {
+	address := c.listener.Addr().(*net.TCPAddr)
+	return address.IP.String(), address.Port
+}
+
+// Close stops the broker.
+func (c *AmqpTestBroker) Close() error {
+	return c.listener.Close()
+}
+
+func (c *AmqpTestBroker) accept() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+		connection := &testConnection{
+			broker:   c,
+			conn:     conn,
+			reader:   bufio.NewReader(conn),
+			channels: map[uint16]*testChannel{},
+			frameMax: 131072,
+		}
+		go connection.serve()
+	}
+}
+
+func (c *testConnection) serve() {
+	defer c.close()
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.reader, header); err != nil || !bytes.Equal(header, []byte("AMQP\x00\x00\x09\x01")) {
+		return
+	}
+
+	// connection.start
+	start := newArgs()
+	start.octet(0).octet(9).emptyTable().longstr("PLAIN AMQPLAIN").longstr("en_US")
+	if c.sendMethod(0, 10, 10, start) != nil {
+		return
+	}
+
+	for {
+		frameType, channel, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+
+		switch frameType {
+		case frameHeartbeat:
+			c.writeFrame(frameHeartbeat, 0, nil)
+		case frameMethod:
+			if !c.handleMethod(channel, payload) {
+				return
+			}
+		case frameHeader:
+			c.handleHeader(channel, payload)
+		case frameBody:
+			c.handleBody(channel, payload)
+		}
+	}
+}
+
+func (c *testConnection) close() {
+	c.broker.lock.Lock()
+	for _, channel := range c.channels {
+		c.broker.requeueAll(channel)
+	}
+	c.broker.lock.Unlock()
+
+	c.conn.Close()
+}
+
+func (c *testConnection) readFrame() (byte, uint16, []byte, error) {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return 0, 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header[3:7])
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return 0, 0, nil, err
+	}
+	if payload[size] != frameEnd {
+		return 0, 0, nil, errors.New("invalid frame end")
+	}
+	return header[0], binary.BigEndian.Uint16(header[1:3]), payload[:size], nil
+}
+
+func (c *testConnection) writeFrame(frameType byte, channel uint16, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_, err := c.conn.Write(encodeFrame(frameType, channel, payload))
+	return err
+}
+
+func (c *testConnection) sendMethod(channel uint16, classId uint16, methodId uint16, args *testArgs) error {
+	payload := newArgs().short(classId).short(methodId)
+	if args != nil {
+		payload.buffer.Write(args.buffer.Bytes())
+	}
+	return c.writeFrame(frameMethod, channel, payload.buffer.Bytes())
+}
+
+func (c *testConnection) sendContent(channel uint16, classId uint16, methodId uint16, args *testArgs, message *testMessage) {
+	payload := newArgs().short(classId).short(methodId)
+	payload.buffer.Write(args.buffer.Bytes())
+
+	header := newArgs().short(60).short(0).longlong(uint64(len(message.body)))
+	header.buffer.Write(message.properties)
+
+	// Content frames must follow the method frame without interleaving
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	frames := [][]byte{encodeFrame(frameMethod, channel, payload.buffer.Bytes()), encodeFrame(frameHeader, channel, header.buffer.Bytes())}
+	maxBody := int(c.frameMax) - 8
+	for body := message.body; len(body) > 0; {
+		size := len(body)
+		if size > maxBody {
+			size = maxBody
+		}
+		frames = append(frames, encodeFrame(frameBody, channel, body[:size]))
+		body = body[size:]
+	}
+	for _, frame := range frames {
+		if _, err := c.conn.Write(frame); err != nil {
+			return
+		}
+	}
+}
+
+func encodeFrame(frameType byte, channel uint16, payload []byte) []byte {
+	frame := make([]byte, 7, len(payload)+8)
+	frame[0] = frameType
+	binary.BigEndian.PutUint16(frame[1:3], channel)
+	binary.BigEndian.PutUint32(frame[3:7], uint32(len(payload)))
+	frame = append(frame, payload...)
+	return append(frame, frameEnd)
+}
+
+// handleMethod processes a method frame and returns false when the connection must be closed.
+func (c *testConnection) handleMethod(channelId uint16, payload []byte) bool {
+	r := &testReader{data: payload}
+	classId := r.short()
+	methodId := r.short()
+
+	broker := c.broker
+	channel := c.channels[channelId]
+	if channel == nil && classId != 10 && classId != 20 {
+		return true
+	}
+
+	switch uint32(classId)<<16 | uint32(methodId) {
+	case 10<<16 | 11: // connection.start-ok
+		tune := newArgs().short(2047).long(c.frameMax).short(0)
+		c.sendMethod(0, 10, 30, tune)
+	case 10<<16 | 31: // connection.tune-ok
+		r.short()
+		if frameMax := r.long(); frameMax > 0 && frameMax < c.frameMax {
+			c.frameMax = frameMax
+		}
+	case 10<<16 | 40: // connection.open
+		c.sendMethod(0, 10, 41, newArgs().shortstr(""))
+	case 10<<16 | 50: // connection.close
+		c.sendMethod(0, 10, 51, nil)
+		return false
+	case 10<<16 | 51: // connection.close-ok
+		return false
+
+	case 20<<16 | 10: // channel.open
+		c.channels[channelId] = &testChannel{id: channelId, unacked: map[uint64]*testDelivery{}}
+		c.sendMethod(channelId, 20, 11, newArgs().longstr(""))
+	case 20<<16 | 40: // channel.close
+		if channel != nil {
+			broker.lock.Lock()
+			broker.requeueAll(channel)
+			broker.lock.Unlock()
+			delete(c.channels, channelId)
+		}
+		c.sendMethod(channelId, 20, 41, nil)
+	case 20<<16 | 41: // channel.close-ok
+		delete(c.channels, channelId)
+
+	case 85<<16 | 10: // confirm.select
+		channel.confirm = true
+		if r.octet()&1 == 0 {
+			c.sendMethod(channelId, 85, 11, nil)
+		}
+
+	case 40<<16 | 10: // exchange.declare
+		r.short()
+		name := r.shortstr()
+		kind := r.shortstr()
+		bits := r.octet()
+		broker.lock.Lock()
+		if _, ok := broker.exchanges[name]; !ok {
+			broker.exchanges[name] = &testExchange{kind: kind}
+		}
+		broker.lock.Unlock()
+		if bits&16 == 0 {
+			c.sendMethod(channelId, 40, 11, nil)
+		}
+
+	case 50<<16 | 10: // queue.declare
+		r.short()
+		name := r.shortstr()
+		bits := r.octet()
+		arguments := r.table()
+		passive := bits&1 != 0
+
+		broker.lock.Lock()
+		if name == "" {
+			broker.sequence++
+			name = "amq.gen-" + strconv.Itoa(broker.sequence)
+		}
+		queue, ok := broker.queues[name]
+		if !ok && passive {
+			broker.lock.Unlock()
+			c.closeChannel(channelId, 404, "NOT_FOUND - no queue '"+name+"'", classId, methodId)
+			return true
+		}
+		if !ok {
+			queue = &testQueue{name: name, arguments: arguments}
+			queue.deadLetter, _ = arguments["x-dead-letter-exchange"].(string)
+			broker.queues[name] = queue
+		}
+		count := len(queue.messages)
+		broker.lock.Unlock()
+
+		if bits&16 == 0 {
+			c.sendMethod(channelId, 50, 11, newArgs().shortstr(name).long(uint32(count)).long(0))
+		}
+
+	case 50<<16 | 20: // queue.bind
+		r.short()
+		queue := r.shortstr()
+		exchange := r.shortstr()
+		key := r.shortstr()
+		bits := r.octet()
+		broker.lock.Lock()
+		target, ok := broker.exchanges[exchange]
+		if ok {
+			target.bindings = append(target.bindings, testBinding{queue: queue, key: key})
+		}
+		broker.lock.Unlock()
+		if !ok {
+			c.closeChannel(channelId, 404, "NOT_FOUND - no exchange '"+exchange+"'", classId, methodId)
+			return true
+		}
+		if bits&1 == 0 {
+			c.sendMethod(channelId, 50, 21, nil)
+		}
+
+	case 50<<16 | 30: // queue.purge
+		r.short()
+		name := r.shortstr()
+		bits := r.octet()
+		count := 0
+		broker.lock.Lock()
+		if queue, ok := broker.queues[name]; ok {
+			count = len(queue.messages)
+			queue.messages = nil
+		}
+		broker.lock.Unlock()
+		if bits&1 == 0 {
+			c.sendMethod(channelId, 50, 31, newArgs().long(uint32(count)))
+		}
+
+	case 50<<16 | 40: // queue.delete
+		r.short()
+		name := r.shortstr()
+		bits := r.octet()
+		count := 0
+		broker.lock.Lock()
+		if queue, ok := broker.queues[name]; ok {
+			count = len(queue.messages)
+			delete(broker.queues, name)
+			for _, exchange := range broker.exchanges {
+				bindings := exchange.bindings[:0]
+				for _, binding := range exchange.bindings {
+					if binding.queue != name {
+						bindings = append(bindings, binding)
+					}
+				}
+				exchange.bindings = bindings
+			}
+		}
+		broker.lock.Unlock()
+		if bits&4 == 0 {
+			c.sendMethod(channelId, 50, 41, newArgs().long(uint32(count)))
+		}
+
+	case 60<<16 | 10: // basic.qos
+		c.sendMethod(channelId, 60, 11, nil)
+
+	case 60<<16 | 40: // basic.publish
+		r.short()
+		channel.publish = &testPublish{exchange: r.shortstr(), routingKey: r.shortstr()}
+
+	case 60<<16 | 70: // basic.get
+		r.short()
+		name := r.shortstr()
+		noAck := r.octet()&1 != 0
+
+		broker.lock.Lock()
+		queue, ok := broker.queues[name]
+		if !ok {
+			broker.lock.Unlock()
+			c.closeChannel(channelId, 404, "NOT_FOUND - no queue '"+name+"'", classId, methodId)
+			return true
+		}
+		if len(queue.messages) == 0 {
+			broker.lock.Unlock()
+			c.sendMethod(channelId, 60, 72, newArgs().shortstr(""))
+			return true
+		}
+		message := queue.messages[0]
+		queue.messages = queue.messages[1:]
+		channel.deliveryTag++
+		tag := channel.deliveryTag
+		if !noAck {
+			channel.unacked[tag] = &testDelivery{queue: queue, message: message}
+		}
+		count := len(queue.messages)
+		broker.lock.Unlock()
+
+		redelivered := byte(0)
+		if message.redelivered {
+			redelivered = 1
+		}
+		getOk := newArgs().longlong(tag).octet(redelivered).shortstr(message.exchange).shortstr(message.routingKey).long(uint32(count))
+		c.sendContent(channelId, 60, 71, getOk, message)
+
+	case 60<<16 | 80: // basic.ack
+		tag := r.longlong()
+		multiple := r.octet()&1 != 0
+		broker.lock.Lock()
+		channel.take(tag, multiple)
+		broker.lock.Unlock()
+
+	case 60<<16 | 90: // basic.reject
+		tag := r.longlong()
+		requeue := r.octet()&1 != 0
+		broker.lock.Lock()
+		broker.settle(channel.take(tag, false), requeue)
+		broker.lock.Unlock()
+
+	case 60<<16 | 120: // basic.nack
+		tag := r.longlong()
+		bits := r.octet()
+		broker.lock.Lock()
+		broker.settle(channel.take(tag, bits&1 != 0), bits&2 != 0)
+		broker.lock.Unlock()
+
+	default:
+		c.closeChannel(channelId, 540, "NOT_IMPLEMENTED", classId, methodId)
+	}
+
+	return true
+}
+
+func (c *testConnection) handleHeader(channelId uint16, payload []byte) {
+	channel := c.channels[channelId]
+	if channel == nil || channel.publish == nil {
+		return
+	}
+
+	r := &testReader{data: payload}
+	r.short()
+	r.short()
+	channel.publish.size = r.longlong()
+	channel.publish.properties = append([]byte{}, payload[r.offset:]...)
+	if channel.publish.size == 0 {
+		c.completePublish(channel)
+	}
+}
+
+func (c *testConnection) handleBody(channelId uint16, payload []byte) {
+	channel := c.channels[channelId]
+	if channel == nil || channel.publish == nil {
+		return
+	}
+
+	channel.publish.body = append(channel.publish.body, payload...)
+	if uint64(len(channel.publish.body)) >= channel.publish.size {
+		c.completePublish(channel)
+	}
+}
+
+func (c *testConnection) completePublish(channel *testChannel) {
+	publish := channel.publish
+	channel.publish = nil
+
+	c.broker.lock.Lock()
+	c.broker.route(publish.exchange, publish.routingKey, &testMessage{
+		exchange:   publish.exchange,
+		routingKey: publish.routingKey,
+		properties: publish.properties,
+		body:       publish.body,
+	})
+	c.broker.lock.Unlock()
+
+	if channel.confirm {
+		channel.publishSeq++
+		c.sendMethod(channel.id, 60, 80, newArgs().longlong(channel.publishSeq).octet(0))
+	}
+}
+
+func (c *testConnection) closeChannel(channelId uint16, code uint16, text string, classId uint16, methodId uint16) {
+	if channel := c.channels[channelId]; channel != nil {
+		c.broker.lock.Lock()
+		c.broker.requeueAll(channel)
+		c.broker.lock.Unlock()
+	}
+	c.sendMethod(channelId, 20, 40, newArgs().short(code).shortstr(text).short(classId).short(methodId))
+}
+
+// take removes deliveries acknowledged by the tag in ascending order of tags.
+func (c *testChannel) take(tag uint64, multiple bool) []*testDelivery {
+	deliveries := []*testDelivery{}
+	for current := uint64(1); current <= tag; current++ {
+		if !multiple && current != tag {
+			continue
+		}
+		if delivery, ok := c.unacked[current]; ok {
+			deliveries = append(deliveries, delivery)
+			delete(c.unacked, current)
+		}
+	}
+	return deliveries
+}
+
+// route delivers a message into queues bound to the exchange. Must be called under lock.
+func (c *AmqpTestBroker) route(exchangeName string, routingKey string, message *testMessage) {
+	if exchangeName == "" {
+		if queue, ok := c.queues[routingKey]; ok {
+			queue.messages = append(queue.messages, message)
+		}
+		return
+	}
+
+	exchange, ok := c.exchanges[exchangeName]
+	if !ok {
+		return
+	}
+	for _, binding := range exchange.bindings {
+		if exchange.kind == "fanout" || binding.key == routingKey {
+			if queue, ok := c.queues[binding.queue]; ok {
+				queue.messages = append(queue.messages, message)
+			}
+		}
+	}
+}
+
+// settle returns rejected deliveries into their queues or dead letters them. Must be called under lock.
+func (c *AmqpTestBroker) settle(deliveries []*testDelivery, requeue bool) {
+	if requeue {
+		// Requeue in reverse order to keep the original order at the head of queues
+		for index := len(deliveries) - 1; index >= 0; index-- {
+			delivery := deliveries[index]
+			delivery.message.redelivered = true
+			delivery.queue.messages = append([]*testMessage{delivery.message}, delivery.queue.messages...)
+		}
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.queue.deadLetter == "" {
+			continue
+		}
+		routingKey := delivery.message.routingKey
+		if key, ok := delivery.queue.arguments["x-dead-letter-routing-key"].(string); ok {
+			routingKey = key
+		}
+		message := *delivery.message
+		message.redelivered = false
+		c.route(delivery.queue.deadLetter, routingKey, &message)
+	}
+}
+
+// requeueAll returns all unacknowledged deliveries of a closed channel. Must be called under lock.
+func (c *AmqpTestBroker) requeueAll(channel *testChannel) {
+	tag := uint64(0)
+	for current := range channel.unacked {
+		if current > tag {
+			tag = current
+		}
+	}
+	c.settle(channel.take(tag, true), true)
+}
+
+// testArgs writes method arguments.
+type testArgs struct {
+	buffer bytes.Buffer
+}
+
+func newArgs() *testArgs {
+	return &testArgs{}
+}
+
+func (c *testArgs) octet(value byte) *testArgs {
+	c.buffer.WriteByte(value)
+	return c
+}
+
+func (c *testArgs) short(value uint16) *testArgs {
+	binary.Write(&c.buffer, binary.BigEndian, value)
+	return c
+}
+
+func (c *testArgs) long(value uint32) *testArgs {
+	binary.Write(&c.buffer, binary.BigEndian, value)
+	return c
+}
+
+func (c *testArgs) longlong(value uint64) *testArgs {
+	binary.Write(&c.buffer, binary.BigEndian, value)
+	return c
+}
+
+func (c *testArgs) shortstr(value string) *testArgs {
+	c.buffer.WriteByte(byte(len(value)))
+	c.buffer.WriteString(value)
+	return c
+}
+
+func (c *testArgs) longstr(value string) *testArgs {
+	c.long(uint32(len(value)))
+	c.buffer.WriteString(value)
+	return c
+}
+
+// emptyTable writes an empty field table, the broker never sends table values.
+func (c *testArgs) emptyTable() *testArgs {
+	return c.long(0)
+}
+
+// testReader reads method arguments.
+type testReader struct {
+	data   []byte
+	offset int
+}
+
+func (c *testReader) next(size int) []byte {
+	if c.offset+size > len(c.data) {
+		c.offset = len(c.data)
+		return make([]byte, size)
+	}
+	value := c.data[c.offset : c.offset+size]
+	c.offset += size
+	return value
+}
+
+func (c *testReader) octet() byte {
+	return c.next(1)[0]
+}
+
+func (c *testReader) short() uint16 {
+	return binary.BigEndian.Uint16(c.next(2))
+}
+
+func (c *testReader) long() uint32 {
+	return binary.BigEndian.Uint32(c.next(4))
+}
+
+func (c *testReader) longlong() uint64 {
+	return binary.BigEndian.Uint64(c.next(8))
+}
+
+func (c *testReader) shortstr() string {
+	return string(c.next(int(c.octet())))
+}
+
+func (c *testReader) longstr() string {
+	return string(c.next(int(c.long())))
+}
+
+func (c *testReader) table() map[string]interface{} {
+	data := c.next(int(c.long()))
+	r := &testReader{data: data}
+	table := map[string]interface{}{}
+	for r.offset < len(r.data) {
+		key := r.shortstr()
+		table[key] = r.field()
+	}
+	return table
+}
+
+func (c *testReader) field() interface{} {
+	switch c.octet() {
+	case 't', 'b', 'B':
+		return c.octet()
+	case 's', 'u':
+		return c.short()
+	case 'I', 'i', 'f':
+		return c.long()
+	case 'l', 'd', 'T':
+		return c.longlong()
+	case 'D':
+		c.next(5)
+		return nil
+	case 'S':
+		return c.longstr()
+	case 'x':
+		return c.next(int(c.long()))
+	case 'A':
+		data := c.next(int(c.long()))
+		r := &testReader{data: data}
+		values := []interface{}{}
+		for r.offset < len(r.data) {
+			values = append(values, r.field())
+		}
+		return values
+	case 'F':
+		return c.table()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,210 @@
+package test_boltdb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/boltdb"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+	bbolt "go.etcd.io/bbolt"
+)
+
+func TestBoltMessageQueue(t *testing.T) {
+	queue := boltdb.NewBoltMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"path", filepath.Join(t.TempDir(), "queues.db"),
+	))
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("BoltMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("BoltMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("BoltMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("BoltMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("BoltMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("BoltMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("BoltMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("BoltMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("BoltMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("BoltMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("BoltMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("BoltMessageQueue:On Message", fixture.TestOnMessage)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestBoltMessageQueueLockRecovery(t *testing.T) {
+	config := cconf.NewConfigParamsFromTuples(
+		"path", filepath.Join(t.TempDir(), "queues.db"),
+		"options.lock_timeout", 500,
+	)
+
+	queue := boltdb.NewBoltMessageQueue("TestQueue")
+	queue.Configure(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+
+	// Crash without completing the message
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	queue = boltdb.NewBoltMessageQueue("TestQueue")
+	queue.Configure(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope)
+
+	envelope, err = queue.Receive("", 1000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "Test message", envelope.GetMessageAsString())
+
+	err = queue.Complete(envelope)
+	assert.Nil(t, err)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestBoltMessageQueueIndexUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queues.db")
+
+	// Messages stored without indexes, one of them with an expired lock
+	db, err := bbolt.Open(path, 0600, nil)
+	assert.Nil(t, err)
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte("queues"))
+		if err != nil {
+			return err
+		}
+		bucket, err = bucket.CreateBucket([]byte("TestQueue"))
+		if err != nil {
+			return err
+		}
+		messages, err := bucket.CreateBucket([]byte("messages"))
+		if err != nil {
+			return err
+		}
+		locks, err := bucket.CreateBucket([]byte("locks"))
+		if err != nil {
+			return err
+		}
+		if _, err = bucket.CreateBucket([]byte("dead")); err != nil {
+			return err
+		}
+
+		for i, text := range []string{"Message 1", "Message 2"} {
+			record := map[string]interface{}{"msg": queues.NewMessageEnvelope("123", "Test", []byte(text))}
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, uint64(i+1))
+			if i == 0 {
+				record["token"] = 1
+				record["exp"] = 1
+				if err = locks.Put(key, key); err != nil {
+					return err
+				}
+			}
+			value, _ := json.Marshal(record)
+			if err = messages.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Close())
+
+	queue := boltdb.NewBoltMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples("path", path))
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	for _, text := range []string{"Message 1", "Message 2"} {
+		envelope, err := queue.Receive("", 0)
+		assert.Nil(t, err)
+		assert.NotNil(t, envelope)
+		assert.Equal(t, text, envelope.GetMessageAsString())
+		assert.Nil(t, queue.Complete(envelope))
+	}
+
+	envelope, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope)
+}
+
+func TestBoltConnection(t *testing.T) {
+	connection := boltdb.NewBoltConnection()
+	connection.Configure(cconf.NewConfigParamsFromTuples(
+		"path", filepath.Join(t.TempDir(), "queues.db"),
+	))
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	references := cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "connection", "bolt", "default", "1.0"), connection,
+	)
+
+	queue1 := boltdb.NewBoltMessageQueue("queue1")
+	queue1.SetReferences(references)
+	err = queue1.Open("")
+	assert.Nil(t, err)
+	defer queue1.Close("")
+
+	queue2 := boltdb.NewBoltMessageQueue("queue2")
+	queue2.SetReferences(references)
+	err = queue2.Open("")
+	assert.Nil(t, err)
+	defer queue2.Close("")
+
+	err = connection.CreateQueue("queue3")
+	assert.Nil(t, err)
+
+	names, err := connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue1", "queue2", "queue3"}, names)
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	count, err := queue2.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	err = connection.DeleteQueue("queue1")
+	assert.Nil(t, err)
+
+	names, err = connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue2", "queue3"}, names)
+
+	count, err = queue1.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
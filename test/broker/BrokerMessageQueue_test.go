@@ -0,0 +1,138 @@
+package test_broker
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/broker"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T) *broker.BrokerServer {
+	server := broker.NewBrokerServer()
+	server.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.host", "127.0.0.1",
+		"connection.port", 0,
+	))
+	err := server.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close("") })
+	return server
+}
+
+func newTestQueue(server *broker.BrokerServer, name string) *broker.BrokerMessageQueue {
+	queue := broker.NewBrokerMessageQueue(name)
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", "tcp://"+server.GetAddress(),
+	))
+	return queue
+}
+
+func TestBrokerMessageQueue(t *testing.T) {
+	queue := newTestQueue(startServer(t), "TestQueue")
+
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("BrokerMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("BrokerMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("BrokerMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("BrokerMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("BrokerMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("BrokerMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("BrokerMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("BrokerMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("BrokerMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("BrokerMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("BrokerMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("BrokerMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestBrokerSharedQueue(t *testing.T) {
+	server := startServer(t)
+
+	producer := newTestQueue(server, "SharedQueue")
+	err := producer.Open("")
+	assert.Nil(t, err)
+	defer producer.Close("")
+
+	consumer := newTestQueue(server, "SharedQueue")
+	err = consumer.Open("")
+	assert.Nil(t, err)
+
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope.SetHeader("tenant", "tenant1")
+	err = producer.Send("", envelope)
+	assert.Nil(t, err)
+
+	envelope1, err := consumer.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+	assert.Equal(t, envelope.MessageId, envelope1.MessageId)
+	assert.Equal(t, "tenant1", envelope1.GetHeader("tenant"))
+
+	err = consumer.RenewLock(envelope1, 10000*time.Millisecond)
+	assert.Nil(t, err)
+
+	count, err := producer.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// Messages locked by a closed connection return into the queue
+	err = consumer.Close("")
+	assert.Nil(t, err)
+
+	envelope2, err := producer.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+	assert.Equal(t, envelope.MessageId, envelope2.MessageId)
+
+	err = producer.Complete(envelope2)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"SharedQueue"}, server.GetQueueNames())
+}
+
+func TestBrokerReconnect(t *testing.T) {
+	server := startServer(t)
+	address := server.GetAddress()
+
+	queue := newTestQueue(server, "TestQueue")
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	err = server.Close("")
+	assert.Nil(t, err)
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.NotNil(t, err)
+
+	// The queue reconnects when the broker is back
+	host, port, _ := net.SplitHostPort(address)
+	server = broker.NewBrokerServer()
+	server.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+	))
+	err = server.Open("")
+	assert.Nil(t, err)
+	defer server.Close("")
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
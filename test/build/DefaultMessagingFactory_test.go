@@ -0,0 +1,34 @@
+package test_build
+
+import (
+	"testing"
+
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	build "github.com/pip-services3-go/pip-services3-messaging-go/build"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
+	queues "github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultMessagingFactory(t *testing.T) {
+	factory := build.NewDefaultMessagingFactory()
+
+	comp, err := factory.Create(cref.NewDescriptor("pip-services", "message-queue", "memory", "test", "1.0"))
+	assert.Nil(t, err)
+	queue := comp.(*connect.SharedMemoryMessageQueue)
+	assert.Equal(t, "test", queue.Name())
+
+	// Memory queues are shared through the connection of the factory
+	comp, err = factory.Create(cref.NewDescriptor("pip-services", "queue-connection", "memory", "default", "1.0"))
+	assert.Nil(t, err)
+	assert.Same(t, queue.Queue(), comp.(*connect.MemoryMessageQueueConnection).FindQueue("test"))
+
+	comp, err = factory.Create(cref.NewDescriptor("pip-services", "message-queue", "file", "test", "1.0"))
+	assert.Nil(t, err)
+	assert.Equal(t, "test", comp.(*queues.FileMessageQueue).Name())
+
+	comp, err = factory.Create(cref.NewDescriptor("pip-services", "queue-factory", "file", "default", "1.0"))
+	assert.Nil(t, err)
+	_, ok := comp.(*build.FileMessageQueueFactory)
+	assert.True(t, ok)
+}
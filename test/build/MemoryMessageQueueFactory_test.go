@@ -5,7 +5,7 @@ import (
 
 	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
 	build "github.com/pip-services3-go/pip-services3-messaging-go/build"
-	queues "github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +20,19 @@ func TestMemoryMessageQueueFactory(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, comp)
 
-	queue := comp.(*queues.MemoryMessageQueue)
+	queue := comp.(*connect.SharedMemoryMessageQueue)
 	assert.Equal(t, "test", queue.Name())
+
+	// Queues with the same name are shared through separate handles
+	comp, err = factory.Create(descriptor)
+	assert.Nil(t, err)
+	assert.NotSame(t, queue, comp)
+	assert.Same(t, queue.Queue(), comp.(*connect.SharedMemoryMessageQueue).Queue())
+
+	comp, err = factory.Create(cref.NewDescriptor("pip-services", "queue-connection", "memory", "default", "1.0"))
+	assert.Nil(t, err)
+	connection := comp.(*connect.MemoryMessageQueueConnection)
+	names, err := connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test"}, names)
 }
@@ -0,0 +1,188 @@
+package test_connect
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdmin(t *testing.T) (*connect.MemoryMessageQueueAdmin, *queues.MemoryMessageQueue) {
+	connection := connect.NewMemoryMessageQueueConnection()
+	t.Cleanup(func() { connection.Close("") })
+
+	queue := connection.GetQueue("orders")
+	for i := 0; i < 5; i++ {
+		envelope := queues.NewMessageEnvelope("123", "order", []byte("ABC"))
+		if i%2 == 0 {
+			envelope.SetHeader("tenant", "tenant1")
+		}
+		queue.Send("", envelope)
+	}
+	queue.Send("", queues.NewMessageEnvelope("123", "invoice", []byte("DEF")))
+
+	return connect.NewMemoryMessageQueueAdmin(connection), queue
+}
+
+func TestMemoryMessageQueueAdminStats(t *testing.T) {
+	admin, queue := newTestAdmin(t)
+
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	message2, _ := queue.Receive("", 1000*time.Millisecond)
+	queue.MoveToDeadLetter(message2)
+	time.Sleep(10 * time.Millisecond)
+
+	stats, err := admin.ReadQueueStats("")
+	assert.Nil(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "orders", stats[0].Name)
+	assert.Equal(t, int64(4), stats[0].MessageCount)
+	assert.Equal(t, int64(1), stats[0].LockedCount)
+	assert.Equal(t, int64(1), stats[0].DeadLetterCount)
+	assert.True(t, stats[0].OldestMessageAge >= 10*time.Millisecond)
+
+	queue.Complete(message)
+	queueStats, err := admin.GetQueueStats("", "orders")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), queueStats.LockedCount)
+
+	_, err = admin.GetQueueStats("", "unknown")
+	assert.NotNil(t, err)
+	assert.Equal(t, "QUEUE_NOT_FOUND", err.(*cerr.ApplicationError).Code)
+}
+
+func TestMemoryMessageQueueAdminBrowse(t *testing.T) {
+	admin, queue := newTestAdmin(t)
+
+	page, err := admin.BrowseMessages("", "orders", nil, cdata.NewPagingParams(1, 2, true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), *page.Total)
+	assert.Len(t, page.Data, 2)
+
+	page, err = admin.BrowseMessages("", "orders", cdata.NewFilterParamsFromTuples(
+		"message_type", "order",
+		"header.tenant", "tenant1",
+	), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, page.Total)
+	assert.Len(t, page.Data, 3)
+	assert.Equal(t, "tenant1", page.Data[0].GetHeader("tenant"))
+
+	page, err = admin.BrowseMessages("", "orders", cdata.NewFilterParamsFromTuples(
+		"message_type", "order,invoice",
+		"min_age", 60000,
+	), nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 0)
+
+	// Changes in browsed messages do not change messages in the queue
+	page, _ = admin.BrowseMessages("", "orders", nil, cdata.NewPagingParams(0, 1, false))
+	page.Data[0].SetHeader("tenant", "tenant2")
+	page.Data[0].Message[0] = 'X'
+	page, _ = admin.BrowseMessages("", "orders", nil, cdata.NewPagingParams(0, 1, false))
+	assert.Equal(t, "tenant1", page.Data[0].GetHeader("tenant"))
+	assert.Equal(t, "ABC", page.Data[0].GetMessageAsString())
+
+	// Browsing does not remove messages
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(6), count)
+}
+
+func TestMemoryMessageQueueAdminPurge(t *testing.T) {
+	admin, queue := newTestAdmin(t)
+
+	count, err := admin.PurgeQueue("", "orders", cdata.NewFilterParamsFromTuples("message_type", "invoice"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	queue.MoveToDeadLetter(message)
+
+	count, err = admin.PurgeQueue("", "orders", cdata.NewFilterParamsFromTuples("dead_letter", true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = admin.PurgeQueue("", "orders", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+
+	stats, _ := admin.GetQueueStats("", "orders")
+	assert.Equal(t, int64(0), stats.MessageCount)
+	assert.Equal(t, int64(0), stats.DeadLetterCount)
+}
+
+func TestMemoryMessageQueueAdminMoveCopy(t *testing.T) {
+	admin, queue := newTestAdmin(t)
+
+	count, err := admin.CopyMessages("", "orders", "archive", cdata.NewFilterParamsFromTuples("message_type", "order"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), count)
+
+	count, err = admin.MoveMessages("", "orders", "invoices", cdata.NewFilterParamsFromTuples("message_type", "invoice"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = admin.MoveMessages("", "orders", "orders", nil)
+	assert.NotNil(t, err)
+
+	stats, err := admin.ReadQueueStats("")
+	assert.Nil(t, err)
+	assert.Len(t, stats, 3)
+	assert.Equal(t, "archive", stats[0].Name)
+	assert.Equal(t, int64(5), stats[0].MessageCount)
+	assert.Equal(t, "invoices", stats[1].Name)
+	assert.Equal(t, int64(1), stats[1].MessageCount)
+	assert.Equal(t, int64(5), stats[2].MessageCount)
+
+	// Dead letters are moved back into the same queue
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	queue.MoveToDeadLetter(message)
+	count, err = admin.MoveMessages("", "orders", "orders", cdata.NewFilterParamsFromTuples("dead_letter", true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	queueStats, _ := admin.GetQueueStats("", "orders")
+	assert.Equal(t, int64(5), queueStats.MessageCount)
+	assert.Equal(t, int64(0), queueStats.DeadLetterCount)
+}
+
+func TestMemoryMessageQueueAdminMoveDuplicates(t *testing.T) {
+	admin, queue := newTestAdmin(t)
+	queue.Configure(cconf.NewConfigParamsFromTuples("options.dedup_window", 60000))
+
+	queue.RemoveMessages("", nil)
+	queue.Send("", queues.NewMessageEnvelope("123", "order", []byte("XYZ")))
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	queue.MoveToDeadLetter(message)
+
+	// Moved messages are not dropped by deduplication of the target queue
+	count, err := admin.MoveMessages("", "orders", "orders", cdata.NewFilterParamsFromTuples("dead_letter", true))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	queueStats, _ := admin.GetQueueStats("", "orders")
+	assert.Equal(t, int64(1), queueStats.MessageCount)
+	assert.Equal(t, int64(0), queueStats.DeadLetterCount)
+}
+
+func TestMemoryMessageQueueAdminMoveKeepsAge(t *testing.T) {
+	admin, _ := newTestAdmin(t)
+	time.Sleep(50 * time.Millisecond)
+
+	count, err := admin.MoveMessages("", "orders", "invoices", cdata.NewFilterParamsFromTuples(
+		"message_type", "invoice",
+		"min_age", 20,
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Moved messages keep their age
+	page, err := admin.BrowseMessages("", "invoices", cdata.NewFilterParamsFromTuples("min_age", 20), nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 1)
+}
@@ -0,0 +1,51 @@
+package test_connect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryMessageQueueConnection(t *testing.T) {
+	connection := connect.NewMemoryMessageQueueConnection()
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	err = connection.CreateQueue("queue2")
+	assert.Nil(t, err)
+	err = connection.CreateQueue("queue1")
+	assert.Nil(t, err)
+	err = connection.CreateQueue("queue1")
+	assert.Nil(t, err)
+
+	names, err := connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue1", "queue2"}, names)
+
+	// Queues are shared by name
+	queue1 := connection.GetQueue("queue1")
+	assert.True(t, queue1.IsOpen())
+	assert.Same(t, queue1, connection.GetQueue("queue1"))
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+	message, err := connection.GetQueue("queue1").Receive("", 1000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, message)
+
+	err = connection.DeleteQueue("queue1")
+	assert.Nil(t, err)
+	err = connection.DeleteQueue("queue3")
+	assert.Nil(t, err)
+	assert.False(t, queue1.IsOpen())
+	assert.NotSame(t, queue1, connection.GetQueue("queue1"))
+
+	connection.Close("")
+	names, err = connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Len(t, names, 0)
+}
@@ -0,0 +1,62 @@
+package test_connect
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pip-services3-go/pip-services3-messaging-go/connect"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedMemoryMessageQueue(t *testing.T) {
+	connection := connect.NewMemoryMessageQueueConnection()
+	defer connection.Close("")
+
+	queue1 := connect.NewSharedMemoryMessageQueue(connection.GetQueue("queue"))
+	queue2 := connect.NewSharedMemoryMessageQueue(connection.GetQueue("queue"))
+	assert.Nil(t, queue1.Open(""))
+	assert.Nil(t, queue2.Open(""))
+	assert.Same(t, queue1.Queue(), queue2.Queue())
+
+	receiver := &testCountingReceiver{}
+	queue1.BeginListen("", receiver)
+
+	// Closing one handle does not stop listening through another one
+	assert.Nil(t, queue2.Close(""))
+	assert.False(t, queue2.IsOpen())
+	assert.True(t, queue1.IsOpen())
+
+	err := queue2.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return receiver.Count() == 1 }, 2000*time.Millisecond, 10*time.Millisecond)
+
+	// Deleting the shared queue stops listening through all handles
+	assert.Nil(t, connection.DeleteQueue("queue"))
+	assert.False(t, queue1.IsOpen())
+	time.Sleep(200 * time.Millisecond)
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, 1, receiver.Count())
+}
+
+type testCountingReceiver struct {
+	count int
+	lock  sync.Mutex
+}
+
+func (c *testCountingReceiver) ReceiveMessage(envelope *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+	c.lock.Lock()
+	c.count++
+	c.lock.Unlock()
+	return queue.Complete(envelope)
+}
+
+func (c *testCountingReceiver) Count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.count
+}
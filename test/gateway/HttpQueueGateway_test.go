@@ -0,0 +1,200 @@
+package test_gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/gateway"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMessage struct {
+	MessageId     string            `json:"message_id"`
+	CorrelationId string            `json:"correlation_id"`
+	MessageType   string            `json:"message_type"`
+	Headers       map[string]string `json:"headers"`
+	GroupId       string            `json:"group_id"`
+	Message       []byte            `json:"message"`
+	LockToken     string            `json:"lock_token"`
+}
+
+func startGateway(t *testing.T, tuples ...interface{}) (*queues.MemoryMessageQueue, string) {
+	queue1 := queues.NewMemoryMessageQueue("queue1")
+	queue1.Open("")
+	queue2 := queues.NewMemoryMessageQueue("queue2")
+	queue2.Open("")
+
+	gw := gateway.NewHttpQueueGateway()
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.host", "127.0.0.1",
+		"connection.port", 0,
+	)
+	gw.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	gw.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "message-queue", "memory", "queue1", "1.0"), queue1,
+		cref.NewDescriptor("pip-services", "message-queue", "memory", "queue2", "1.0"), queue2,
+	))
+	err := gw.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gw.Close("") })
+
+	return queue1, "http://" + gw.GetAddress()
+}
+
+func invoke(t *testing.T, method string, url string, body interface{}, result interface{}) int {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader([]byte{})
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if result != nil && res.StatusCode < 300 && res.StatusCode != http.StatusNoContent {
+		err = json.NewDecoder(res.Body).Decode(result)
+		assert.Nil(t, err)
+	}
+	return res.StatusCode
+}
+
+func TestHttpQueueGateway(t *testing.T) {
+	queue, url := startGateway(t)
+
+	var names []string
+	status := invoke(t, "GET", url+"/queues", nil, &names)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"queue1", "queue2"}, names)
+
+	// Send
+	message := &testMessage{
+		CorrelationId: "123",
+		MessageType:   "Test",
+		Headers:       map[string]string{"tenant": "tenant1"},
+		GroupId:       "group1",
+		Message:       []byte("Test message"),
+	}
+	sent := &testMessage{}
+	status = invoke(t, "POST", url+"/queues/queue1/messages", message, sent)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.NotEqual(t, "", sent.MessageId)
+
+	var count map[string]int64
+	status = invoke(t, "GET", url+"/queues/queue1/count", nil, &count)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(1), count["count"])
+
+	// Peek
+	var peeked []*testMessage
+	status = invoke(t, "GET", url+"/queues/queue1/peek?count=5", nil, &peeked)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, peeked, 1)
+	assert.Equal(t, sent.MessageId, peeked[0].MessageId)
+
+	// Receive and abandon
+	received := &testMessage{}
+	status = invoke(t, "GET", url+"/queues/queue1/messages?wait=1000", nil, received)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, sent.MessageId, received.MessageId)
+	assert.Equal(t, "Test", received.MessageType)
+	assert.Equal(t, "tenant1", received.Headers["tenant"])
+	assert.Equal(t, "group1", received.GroupId)
+	assert.Equal(t, []byte("Test message"), received.Message)
+	assert.NotEqual(t, "", received.LockToken)
+
+	status = invoke(t, "PUT", url+"/queues/queue1/messages/"+received.LockToken+"/renew?timeout=10000", nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status = invoke(t, "PUT", url+"/queues/queue1/messages/"+received.LockToken+"/abandon", nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	// Receive and complete
+	received = &testMessage{}
+	status = invoke(t, "GET", url+"/queues/queue1/messages?wait=1000", nil, received)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, sent.MessageId, received.MessageId)
+
+	status = invoke(t, "DELETE", url+"/queues/queue1/messages/"+received.LockToken, nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	// Token cannot be used twice
+	status = invoke(t, "DELETE", url+"/queues/queue1/messages/"+received.LockToken, nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	count2, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count2)
+
+	// Long polling returns no content after the wait
+	start := time.Now()
+	status = invoke(t, "GET", url+"/queues/queue1/messages?wait=300", nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+
+	status = invoke(t, "GET", url+"/queues/unknown/count", nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestHttpQueueGatewayLongPolling(t *testing.T) {
+	queue, url := startGateway(t)
+
+	time.AfterFunc(300*time.Millisecond, func() {
+		queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	})
+
+	received := &testMessage{}
+	status := invoke(t, "GET", url+"/queues/queue1/messages?wait=5000", nil, received)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []byte("Test message"), received.Message)
+
+	status = invoke(t, "PUT", url+"/queues/queue1/messages/"+received.LockToken+"/dead", nil, nil)
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestHttpQueueGatewayConfiguredQueues(t *testing.T) {
+	queue, url := startGateway(t,
+		"options.base_route", "/api/v1/queues",
+		"options.queues", "queue1",
+		"options.lock_timeout", 200,
+	)
+
+	var names []string
+	status := invoke(t, "GET", url+"/api/v1/queues", nil, &names)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"queue1"}, names)
+
+	status = invoke(t, "GET", url+"/api/v1/queues/queue2/count", nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+
+	received := &testMessage{}
+	status = invoke(t, "GET", url+"/api/v1/queues/queue1/messages?wait=1000", nil, received)
+	assert.Equal(t, http.StatusOK, status)
+
+	// Expired lock is abandoned by the gateway
+	time.Sleep(300 * time.Millisecond)
+	status = invoke(t, "DELETE", url+"/api/v1/queues/queue1/messages/"+received.LockToken, nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
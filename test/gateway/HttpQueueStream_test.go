@@ -0,0 +1,171 @@
+package test_gateway
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFrame struct {
+	Type      string       `json:"type"`
+	Message   *testMessage `json:"message,omitempty"`
+	LockToken string       `json:"lock_token,omitempty"`
+	Dropped   int64        `json:"dropped,omitempty"`
+}
+
+type testStream struct {
+	*websocket.Conn
+	frames chan *testFrame
+}
+
+func dialStream(t *testing.T, url string) *testStream {
+	socket, _, err := websocket.DefaultDialer.Dial(strings.Replace(url, "http://", "ws://", 1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { socket.Close() })
+
+	// Reads are done in background, because a timed out read breaks the socket
+	stream := &testStream{Conn: socket, frames: make(chan *testFrame, 100)}
+	go func() {
+		for {
+			frame := &testFrame{}
+			if err := socket.ReadJSON(frame); err != nil {
+				close(stream.frames)
+				return
+			}
+			stream.frames <- frame
+		}
+	}()
+	return stream
+}
+
+func readFrame(t *testing.T, stream *testStream, timeout time.Duration) *testFrame {
+	select {
+	case frame := <-stream.frames:
+		return frame
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func sendMessages(queue *queues.MemoryMessageQueue, messageType string, count int) {
+	for i := 0; i < count; i++ {
+		queue.Send("", queues.NewMessageEnvelope("", messageType, []byte(messageType)))
+	}
+}
+
+func TestHttpQueueStreamAck(t *testing.T) {
+	queue, url := startGateway(t)
+	sendMessages(queue, "order", 3)
+	sendMessages(queue, "invoice", 1)
+
+	socket := dialStream(t, url+"/queues/queue1/stream?types=order&prefetch=2")
+
+	// Only prefetch messages are sent until they are acknowledged
+	frame1 := readFrame(t, socket, 5*time.Second)
+	frame2 := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame1)
+	assert.NotNil(t, frame2)
+	assert.Equal(t, "message", frame1.Type)
+	assert.Equal(t, "order", frame1.Message.MessageType)
+	assert.NotEqual(t, "", frame1.Message.LockToken)
+	assert.Nil(t, readFrame(t, socket, 500*time.Millisecond))
+
+	socket.WriteJSON(&testFrame{Type: "complete", LockToken: frame1.Message.LockToken})
+	frame3 := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame3)
+	assert.Equal(t, "order", frame3.Message.MessageType)
+
+	// Unknown locks are reported
+	socket.WriteJSON(&testFrame{Type: "complete", LockToken: "unknown"})
+	frame := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame)
+	assert.Equal(t, "error", frame.Type)
+
+	socket.WriteJSON(&testFrame{Type: "complete", LockToken: frame2.Message.LockToken})
+	socket.WriteJSON(&testFrame{Type: "complete", LockToken: frame3.Message.LockToken})
+
+	// Messages of other types stay in the queue
+	assert.Nil(t, readFrame(t, socket, 500*time.Millisecond))
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+}
+
+func TestHttpQueueStreamAbandonOnClose(t *testing.T) {
+	queue, url := startGateway(t)
+	sendMessages(queue, "order", 2)
+
+	socket := dialStream(t, url+"/queues/queue1/stream?prefetch=1")
+	frame := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame)
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+
+	socket.Close()
+
+	// Unacknowledged message is returned into the queue
+	for i := 0; i < 50 && count < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+		count, _ = queue.ReadMessageCount()
+	}
+	assert.Equal(t, int64(2), count)
+}
+
+func TestHttpQueueStreamTap(t *testing.T) {
+	queue, url := startGateway(t)
+	sendMessages(queue, "order", 2)
+	sendMessages(queue, "invoice", 1)
+
+	socket := dialStream(t, url+"/queues/queue1/stream?mode=tap&types=order")
+
+	frame1 := readFrame(t, socket, 5*time.Second)
+	frame2 := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame1)
+	assert.NotNil(t, frame2)
+	assert.Equal(t, "order", frame1.Message.MessageType)
+	assert.Equal(t, "", frame1.Message.LockToken)
+
+	// Observed messages are not sent again and not removed
+	assert.Nil(t, readFrame(t, socket, time.Second))
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(3), count)
+
+	sendMessages(queue, "order", 1)
+	frame3 := readFrame(t, socket, 5*time.Second)
+	assert.NotNil(t, frame3)
+	assert.Equal(t, "order", frame3.Message.MessageType)
+}
+
+func TestHttpQueueStreamTapOverflow(t *testing.T) {
+	queue, url := startGateway(t)
+	sendMessages(queue, "order", 5)
+
+	socket := dialStream(t, url+"/queues/queue1/stream?mode=tap&prefetch=2")
+	time.Sleep(time.Second)
+
+	// Frames sent over the socket are buffered by the network,
+	// so only messages that did not fit into the stream buffer are dropped
+	received := 0
+	var dropped int64
+	for frame := readFrame(t, socket, time.Second); frame != nil; frame = readFrame(t, socket, time.Second) {
+		if frame.Type == "message" {
+			received++
+		} else if frame.Type == "overflow" {
+			dropped += frame.Dropped
+		}
+	}
+	assert.Equal(t, int64(5), int64(received)+dropped)
+}
+
+func TestHttpQueueStreamWrongMode(t *testing.T) {
+	_, url := startGateway(t)
+
+	_, res, err := websocket.DefaultDialer.Dial(strings.Replace(url, "http://", "ws://", 1)+"/queues/queue1/stream?mode=wrong", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, 400, res.StatusCode)
+}
@@ -0,0 +1,172 @@
+package test_grpc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/grpc"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, tuples ...interface{}) (*grpc.GrpcMessageQueueServer, *queues.MemoryMessageQueue) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+
+	server := grpc.NewGrpcMessageQueueServer()
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.host", "127.0.0.1",
+		"connection.port", 0,
+	)
+	server.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	server.AddQueue(queue)
+	err := server.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close("") })
+	return server, queue
+}
+
+func newTestQueue(t *testing.T, server *grpc.GrpcMessageQueueServer, name string, tuples ...interface{}) *grpc.GrpcMessageQueue {
+	queue := grpc.NewGrpcMessageQueue(name)
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.uri", "grpc://"+server.GetAddress(),
+	)
+	queue.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	err := queue.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { queue.Close("") })
+	return queue
+}
+
+type testReceiver struct {
+	messages chan *queues.MessageEnvelope
+}
+
+func (c *testReceiver) ReceiveMessage(envelope *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+	c.messages <- envelope
+	return nil
+}
+
+func TestGrpcMessageQueue(t *testing.T) {
+	server, _ := startServer(t)
+	queue := newTestQueue(t, server, "TestQueue")
+
+	fixture := test_queues.NewMessageQueueFixture(queue)
+	queue.Clear("")
+
+	t.Run("GrpcMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("GrpcMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("GrpcMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("GrpcMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("GrpcMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("GrpcMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("GrpcMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("GrpcMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("GrpcMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("GrpcMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("GrpcMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("GrpcMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestGrpcListenAcks(t *testing.T) {
+	server, memoryQueue := startServer(t)
+	queue := newTestQueue(t, server, "TestQueue", "options.prefetch", 2)
+
+	for i := 0; i < 3; i++ {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+		envelope.SetHeader("tenant", "tenant1")
+		memoryQueue.Send("", envelope)
+	}
+
+	receiver := &testReceiver{messages: make(chan *queues.MessageEnvelope, 10)}
+	var wait sync.WaitGroup
+	wait.Add(1)
+	go func() {
+		defer wait.Done()
+		queue.Listen("", receiver)
+	}()
+
+	// Only prefetch messages are streamed until they are acknowledged
+	received := []*queues.MessageEnvelope{}
+	for len(received) < 2 {
+		select {
+		case message := <-receiver.messages:
+			received = append(received, message)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Messages were not streamed")
+		}
+	}
+	assert.Equal(t, "tenant1", received[0].GetHeader("tenant"))
+	select {
+	case <-receiver.messages:
+		t.Fatal("Unacknowledged messages exceed prefetch")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	err := queue.Complete(received[0])
+	assert.Nil(t, err)
+	select {
+	case message := <-receiver.messages:
+		received = append(received, message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Message was not streamed after acknowledgement")
+	}
+
+	// Unacknowledged messages return into the queue when the stream is closed
+	queue.EndListen("")
+	wait.Wait()
+
+	count := int64(0)
+	for i := 0; i < 50 && count < 2; i++ {
+		count, _ = memoryQueue.ReadMessageCount()
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, int64(2), count)
+
+	err = queue.Complete(received[1])
+	assert.NotNil(t, err)
+}
+
+func TestGrpcErrors(t *testing.T) {
+	server, _ := startServer(t)
+	queue := newTestQueue(t, server, "UnknownQueue")
+
+	_, err := queue.ReadMessageCount()
+	assert.NotNil(t, err)
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	assert.Equal(t, "QUEUE_NOT_FOUND", appErr.Code)
+	assert.Equal(t, cerr.NotFound, appErr.Category)
+	assert.Equal(t, "UnknownQueue", appErr.Details["queue"])
+}
+
+func TestGrpcLockExpiration(t *testing.T) {
+	server, memoryQueue := startServer(t, "options.lock_timeout", 500)
+	queue := newTestQueue(t, server, "TestQueue")
+
+	err := queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	message, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, message)
+
+	// Expired lock is abandoned by the server
+	count := int64(0)
+	for i := 0; i < 50 && count == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+		count, _ = memoryQueue.ReadMessageCount()
+	}
+	assert.Equal(t, int64(1), count)
+
+	err = queue.Complete(message)
+	assert.NotNil(t, err)
+}
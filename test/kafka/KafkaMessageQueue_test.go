@@ -0,0 +1,186 @@
+package test_kafka
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/kafka"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+func startCluster(t *testing.T) *cconf.ConfigParams {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Close)
+
+	host, port, _ := net.SplitHostPort(cluster.ListenAddrs()[0])
+	return cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+	)
+}
+
+func newTestQueue(config *cconf.ConfigParams, tuples ...interface{}) *kafka.KafkaMessageQueue {
+	queue := kafka.NewKafkaMessageQueue("TestQueue")
+	queue.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	return queue
+}
+
+func TestKafkaMessageQueue(t *testing.T) {
+	queue := newTestQueue(startCluster(t))
+
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("KafkaMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("KafkaMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("KafkaMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("KafkaMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("KafkaMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("KafkaMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("KafkaMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("KafkaMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("KafkaMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("KafkaMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("KafkaMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("KafkaMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestKafkaMessageQueueCompleteOffsets(t *testing.T) {
+	config := startCluster(t)
+
+	queue := newTestQueue(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	for _, text := range []string{"message1", "message2", "message3"} {
+		err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte(text)))
+		assert.Nil(t, err)
+	}
+
+	envelope1, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+	envelope2, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+
+	// Completing the second message cannot move the offset past the first one
+	err = queue.Complete(envelope2)
+	assert.Nil(t, err)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	// The first message is delivered again after restart
+	queue = newTestQueue(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	count, err = queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	envelope1, err = queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+	assert.Equal(t, "message1", envelope1.GetMessageAsString())
+
+	err = queue.Complete(envelope1)
+	assert.Nil(t, err)
+}
+
+func TestKafkaMessageQueueRetries(t *testing.T) {
+	queue := newTestQueue(startCluster(t), "options.max_retries", 1)
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope.SetHeader("tenant", "tenant1")
+	err = queue.Send("", envelope)
+	assert.Nil(t, err)
+
+	envelope1, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+
+	err = queue.Abandon(envelope1)
+	assert.Nil(t, err)
+
+	// Abandoned message comes back from the retry topic
+	envelope2, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+	assert.Equal(t, envelope.MessageId, envelope2.MessageId)
+	assert.Equal(t, "tenant1", envelope2.GetHeader("tenant"))
+
+	// Second abandon exceeds max retries
+	err = queue.Abandon(envelope2)
+	assert.Nil(t, err)
+
+	envelope3, err := queue.Receive("", 500*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope3)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, envelope.MessageId, messages[0].MessageId)
+	assert.Equal(t, "tenant1", messages[0].GetHeader("tenant"))
+
+	err = queue.Clear("")
+	assert.Nil(t, err)
+
+	messages, err = queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 0)
+}
+
+func TestKafkaMessageQueuePartitionKey(t *testing.T) {
+	queue := newTestQueue(startCluster(t), "options.partitions", 4)
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	// Messages with the same key keep their order across partitions
+	for i := 0; i < 10; i++ {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte{byte('0' + i)})
+		envelope.SetHeader("partition_key", "key1")
+		err = queue.Send("", envelope)
+		assert.Nil(t, err)
+	}
+
+	messages, err := queue.PeekBatch("", 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 10)
+
+	for i := 0; i < 10; i++ {
+		envelope, err := queue.Receive("", 5000*time.Millisecond)
+		assert.Nil(t, err)
+		if assert.NotNil(t, envelope) {
+			assert.Equal(t, string([]byte{byte('0' + i)}), envelope.GetMessageAsString())
+			assert.Nil(t, queue.Complete(envelope))
+		}
+	}
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,254 @@
+package test_mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/mqtt"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startBroker(t *testing.T, tlsConfig *tls.Config) (string, string) {
+	broker := mochi.New(&mochi.Options{
+		InlineClient: false,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	err := broker.AddHook(new(auth.AllowHook), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := listeners.NewTCP(listeners.Config{
+		ID:        "test",
+		Address:   "127.0.0.1:0",
+		TLSConfig: tlsConfig,
+	})
+	err = broker.AddListener(listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go broker.Serve()
+	t.Cleanup(func() { broker.Close() })
+
+	host, port, _ := net.SplitHostPort(listener.Address())
+	return host, port
+}
+
+func newTestQueue(host string, port string, tuples ...interface{}) *mqtt.MqttMessageQueue {
+	queue := mqtt.NewMqttMessageQueue("test/queue")
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+	)
+	config = config.Override(cconf.NewConfigParamsFromTuples(tuples...))
+	queue.Configure(config)
+	return queue
+}
+
+func testQueue(t *testing.T, name string, queue *mqtt.MqttMessageQueue) {
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run(name+":Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run(name+":Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run(name+":Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run(name+":Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run(name+":Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run(name+":Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run(name+":Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run(name+":Peek No Message", fixture.TestPeekNoMessage)
+	t.Run(name+":Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run(name+":Send Batch", fixture.TestSendBatch)
+	t.Run(name+":Receive Batch", fixture.TestReceiveBatch)
+	t.Run(name+":On Message", fixture.TestOnMessage)
+}
+
+func TestMqttMessageQueue(t *testing.T) {
+	host, port := startBroker(t, nil)
+
+	testQueue(t, "MqttMessageQueue", newTestQueue(host, port))
+}
+
+func TestMqttMessageQueueV5(t *testing.T) {
+	host, port := startBroker(t, nil)
+
+	testQueue(t, "MqttMessageQueue V5", newTestQueue(host, port, "options.protocol_version", 5))
+}
+
+func TestMqttMessageQueueTls(t *testing.T) {
+	crtFile, keyFile := createCertificate(t)
+	certificate, err := tls.LoadX509KeyPair(crtFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port := startBroker(t, &tls.Config{Certificates: []tls.Certificate{certificate}})
+
+	for _, version := range []int{4, 5} {
+		queue := newTestQueue(host, port,
+			"connection.protocol", "mqtts",
+			"credential.ssl_ca_file", crtFile,
+			"options.protocol_version", version,
+		)
+		err := queue.Open("")
+		assert.Nil(t, err)
+
+		err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+		assert.Nil(t, err)
+
+		envelope, err := queue.Receive("", 5000*time.Millisecond)
+		assert.Nil(t, err)
+		assert.NotNil(t, envelope)
+		assert.Equal(t, "Test message", envelope.GetMessageAsString())
+
+		err = queue.Complete(envelope)
+		assert.Nil(t, err)
+
+		err = queue.Close("")
+		assert.Nil(t, err)
+	}
+
+	// Connection without trusted CA fails
+	queue := newTestQueue(host, port, "connection.protocol", "mqtts", "options.timeout", 1000)
+	err = queue.Open("")
+	assert.NotNil(t, err)
+}
+
+func TestMqttMessageQueueTopicFilter(t *testing.T) {
+	host, port := startBroker(t, nil)
+
+	consumer := mqtt.NewMqttMessageQueue("devices/+/telemetry")
+	consumer.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+	))
+	err := consumer.Open("")
+	assert.Nil(t, err)
+	defer consumer.Close("")
+
+	// Topic filters cannot be published to
+	err = consumer.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.NotNil(t, err)
+
+	producer := mqtt.NewMqttMessageQueue("devices/1/telemetry")
+	producer.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.host", host,
+		"connection.port", port,
+	))
+	err = producer.Open("")
+	assert.Nil(t, err)
+	defer producer.Close("")
+
+	err = producer.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope, err := consumer.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "Test", envelope.MessageType)
+	assert.Equal(t, "Test message", envelope.GetMessageAsString())
+
+	err = consumer.Complete(envelope)
+	assert.Nil(t, err)
+}
+
+func TestMqttMessageQueueRedelivery(t *testing.T) {
+	host, port := startBroker(t, nil)
+
+	for _, version := range []int{4, 5} {
+		config := []interface{}{
+			"options.client_id", "redelivery",
+			"options.clean_session", false,
+			"options.protocol_version", version,
+		}
+
+		queue := newTestQueue(host, port, config...)
+		err := queue.Open("")
+		assert.Nil(t, err)
+
+		err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+		assert.Nil(t, err)
+
+		envelope, err := queue.Receive("", 5000*time.Millisecond)
+		assert.Nil(t, err)
+		assert.NotNil(t, envelope)
+
+		// Disconnect without acknowledgement
+		err = queue.Close("")
+		assert.Nil(t, err)
+
+		queue = newTestQueue(host, port, config...)
+		err = queue.Open("")
+		assert.Nil(t, err)
+
+		envelope, err = queue.Receive("", 5000*time.Millisecond)
+		assert.Nil(t, err)
+		assert.NotNil(t, envelope)
+		assert.Equal(t, "Test message", envelope.GetMessageAsString())
+
+		err = queue.Complete(envelope)
+		assert.Nil(t, err)
+
+		err = queue.Close("")
+		assert.Nil(t, err)
+	}
+}
+
+// createCertificate writes self-signed certificate for 127.0.0.1 and returns paths to certificate and key files.
+func createCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	crtFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	os.WriteFile(crtFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	return crtFile, keyFile
+}
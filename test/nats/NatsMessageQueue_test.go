@@ -0,0 +1,119 @@
+package test_nats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/nats"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T) *server.Server {
+	natsServer, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go natsServer.Start()
+	if !natsServer.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server is not ready for connections")
+	}
+	t.Cleanup(natsServer.Shutdown)
+
+	return natsServer
+}
+
+func newTestQueue(natsServer *server.Server, tuples ...interface{}) *nats.NatsMessageQueue {
+	queue := nats.NewNatsMessageQueue("TestQueue")
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.uri", natsServer.ClientURL(),
+	)
+	config = config.Override(cconf.NewConfigParamsFromTuples(tuples...))
+	queue.Configure(config)
+	return queue
+}
+
+func TestNatsMessageQueue(t *testing.T) {
+	natsServer := startServer(t)
+
+	queue := newTestQueue(natsServer)
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("NatsMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("NatsMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("NatsMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("NatsMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("NatsMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("NatsMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("NatsMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("NatsMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("NatsMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("NatsMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("NatsMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("NatsMessageQueue:On Message", fixture.TestOnMessage)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestNatsMessageQueueRedelivery(t *testing.T) {
+	natsServer := startServer(t)
+
+	queue1 := newTestQueue(natsServer, "options.lock_timeout", 500)
+	err := queue1.Open("")
+	assert.Nil(t, err)
+	defer queue1.Close("")
+
+	// Second process shares the same durable consumer
+	queue2 := newTestQueue(natsServer, "options.lock_timeout", 500)
+	err = queue2.Open("")
+	assert.Nil(t, err)
+	defer queue2.Close("")
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope1, err := queue1.Receive("", 1000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+
+	// In-progress ack keeps the message invisible
+	time.Sleep(300 * time.Millisecond)
+	err = queue1.RenewLock(envelope1, 0)
+	assert.Nil(t, err)
+
+	envelope2, err := queue2.Receive("", 400*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope2)
+
+	// Expired ack wait makes the message available again
+	envelope2, err = queue2.Receive("", 1000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+	assert.Equal(t, envelope1.MessageId, envelope2.MessageId)
+	assert.Equal(t, "Test message", envelope2.GetMessageAsString())
+
+	err = queue2.Complete(envelope2)
+	assert.Nil(t, err)
+
+	count, err := queue2.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,178 @@
+package test_queues
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+// deadLetterQueue hides inspection of a queue.
+type deadLetterQueue struct {
+	queues.IMessageQueue
+}
+
+func newDeadLetterQueue(t *testing.T, name string) *queues.MemoryMessageQueue {
+	queue := queues.NewMemoryMessageQueue(name)
+	queue.Open("")
+	t.Cleanup(func() { queue.Close("") })
+
+	for i := 0; i < 4; i++ {
+		envelope := queues.NewMessageEnvelope("123", "order", []byte("ABC"))
+		if i%2 == 0 {
+			envelope.SetHeader("tenant", "tenant1")
+		}
+		queue.Send("", envelope)
+	}
+	queue.Send("", queues.NewMessageEnvelope("123", "invoice", []byte("DEF")))
+	return queue
+}
+
+func TestDeadLetterRedriverDeadLetters(t *testing.T) {
+	queue := newDeadLetterQueue(t, "orders")
+	for i := 0; i < 5; i++ {
+		message, _ := queue.Receive("", 1000*time.Millisecond)
+		queue.MoveToDeadLetter(message)
+	}
+
+	redriver := queues.NewDeadLetterRedriver()
+	redriver.SetTransform(func(message *queues.MessageEnvelope) (*queues.MessageEnvelope, error) {
+		if message.GetHeader("tenant") == "" {
+			return nil, nil
+		}
+		message.SetHeader("redriven", "true")
+		return message, nil
+	})
+
+	result, err := redriver.Redrive("", queue, queue, cdata.NewFilterParamsFromTuples(
+		"message_type", "order",
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Redriven)
+	assert.Equal(t, int64(2), result.Skipped)
+
+	messages, _ := queue.PeekBatch("", 10)
+	assert.Len(t, messages, 2)
+	for _, message := range messages {
+		assert.Equal(t, "true", message.GetHeader("redriven"))
+	}
+
+	stats, _ := queue.ReadStats("")
+	assert.Equal(t, int64(3), stats.DeadLetterCount)
+}
+
+func TestDeadLetterRedriverSameIds(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("orders")
+	queue.Open("")
+	defer queue.Close("")
+
+	// Dead letters with the same and empty ids
+	for i := 0; i < 4; i++ {
+		envelope := queues.NewMessageEnvelope("123", "order", []byte{byte('0' + i)})
+		if i < 2 {
+			envelope.MessageId = "1"
+		} else {
+			envelope.MessageId = ""
+		}
+		queue.Send("", envelope)
+		message, _ := queue.Receive("", 1000*time.Millisecond)
+		queue.MoveToDeadLetter(message)
+	}
+
+	redriver := queues.NewDeadLetterRedriver()
+	redriver.SetTransform(func(message *queues.MessageEnvelope) (*queues.MessageEnvelope, error) {
+		if message.GetMessageAsString() == "0" || message.GetMessageAsString() == "2" {
+			return nil, nil
+		}
+		return message, nil
+	})
+
+	result, err := redriver.Redrive("", queue, queue, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Redriven)
+	assert.Equal(t, int64(2), result.Skipped)
+
+	page, _ := queue.BrowseMessages("", cdata.NewFilterParamsFromTuples("dead_letter", true), nil)
+	if assert.Len(t, page.Data, 2) {
+		assert.Equal(t, "0", page.Data[0].GetMessageAsString())
+		assert.Equal(t, "2", page.Data[1].GetMessageAsString())
+	}
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(2), count)
+}
+
+func TestDeadLetterRedriverDeduplication(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("orders")
+	queue.Configure(cconf.NewConfigParamsFromTuples("options.dedup_window", 60000))
+	queue.Open("")
+	defer queue.Close("")
+
+	queue.Send("", queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	queue.MoveToDeadLetter(message)
+
+	// Redriven messages are not dropped as duplicates
+	redriver := queues.NewDeadLetterRedriver()
+	result, err := redriver.Redrive("", queue, queue, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), result.Redriven)
+
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+	stats, _ := queue.ReadStats("")
+	assert.Equal(t, int64(0), stats.DeadLetterCount)
+}
+
+func TestDeadLetterRedriverQueue(t *testing.T) {
+	source := newDeadLetterQueue(t, "orders.dead")
+	target := queues.NewMemoryMessageQueue("orders")
+	target.Open("")
+	defer target.Close("")
+
+	redriver := queues.NewDeadLetterRedriver()
+	redriver.Configure(cconf.NewConfigParamsFromTuples(
+		"options.max_messages", 3,
+		"options.wait_timeout", 100,
+	))
+
+	// Queues that cannot be inspected are not drained by Redrive
+	_, err := redriver.Redrive("", &deadLetterQueue{source}, target, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "NOT_INSPECTED", err.(*cerr.ApplicationError).Code)
+	count, _ := source.ReadMessageCount()
+	assert.Equal(t, int64(5), count)
+
+	result, err := redriver.RedriveQueue("", &deadLetterQueue{source}, target, cdata.NewFilterParamsFromTuples(
+		"message_type", "order",
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), result.Redriven)
+
+	messages, _ := target.PeekBatch("", 10)
+	assert.Len(t, messages, 3)
+
+	count, _ = source.ReadMessageCount()
+	assert.Equal(t, int64(2), count)
+}
+
+func TestDeadLetterRedriverThrottle(t *testing.T) {
+	queue := newDeadLetterQueue(t, "orders")
+	for i := 0; i < 5; i++ {
+		message, _ := queue.Receive("", 1000*time.Millisecond)
+		queue.MoveToDeadLetter(message)
+	}
+
+	redriver := queues.NewDeadLetterRedriver()
+	redriver.SetMessagesPerSecond(20)
+	assert.Equal(t, float64(20), redriver.GetMessagesPerSecond())
+
+	start := time.Now()
+	result, err := redriver.Redrive("", queue, queue, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), result.Redriven)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
@@ -0,0 +1,232 @@
+package test_queues
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cdata "github.com/pip-services3-go/pip-services3-commons-go/data"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMessageQueue(t *testing.T) {
+	queue := queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"path", filepath.Join(t.TempDir(), "TestQueue.log"),
+	))
+	fixture := NewMessageQueueFixture(queue)
+	assert.False(t, queue.Capabilities().CanSendAtomicBatch())
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("FileMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("FileMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("FileMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("FileMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("FileMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("FileMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("FileMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("FileMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("FileMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("FileMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("FileMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("FileMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestFileMessageQueueRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestQueue.log")
+	config := cconf.NewConfigParamsFromTuples(
+		"path", path,
+		"options.sync", "always",
+		"options.lock_timeout", 500,
+		"options.compact_threshold", 1,
+	)
+
+	queue := queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	for _, text := range []string{"Message 1", "Message 2", "Message 3"} {
+		err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte(text)))
+		assert.Nil(t, err)
+	}
+
+	envelope, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Message 1", envelope.GetMessageAsString())
+	err = queue.Complete(envelope)
+	assert.Nil(t, err)
+
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Message 2", envelope.GetMessageAsString())
+
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	// Simulate a torn write left by a crash
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	file.WriteString("{\"op\":\"send\",\"seq\":")
+	file.Close()
+
+	queue = queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	// Message 2 is still locked after restart
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	envelope, err = queue.Peek("")
+	assert.Nil(t, err)
+	assert.Equal(t, "Message 3", envelope.GetMessageAsString())
+
+	// When the lock expires the message is delivered again
+	time.Sleep(600 * time.Millisecond)
+
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "Message 3", envelope.GetMessageAsString())
+
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+	assert.Equal(t, "Message 2", envelope.GetMessageAsString())
+
+	err = queue.Compact("")
+	assert.Nil(t, err)
+	count, err = queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestFileMessageQueueDeadLetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestQueue.log")
+	config := cconf.NewConfigParamsFromTuples(
+		"path", path,
+		"options.sync", "always",
+		"options.compact_threshold", 1000,
+	)
+
+	queue := queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	for _, messageType := range []string{"order", "invoice", "order"} {
+		err = queue.Send("", queues.NewMessageEnvelope("123", messageType, []byte("ABC")))
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		envelope, err := queue.Receive("", 0)
+		assert.Nil(t, err)
+		err = queue.MoveToDeadLetter(envelope)
+		assert.Nil(t, err)
+	}
+
+	stats, err := queue.ReadStats("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), stats.MessageCount)
+	assert.Equal(t, int64(0), stats.LockedCount)
+	assert.Equal(t, int64(2), stats.DeadLetterCount)
+
+	page, err := queue.BrowseMessages("", cdata.NewFilterParamsFromTuples("dead_letter", true), nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	assert.Equal(t, "order", page.Data[0].MessageType)
+	assert.Equal(t, "invoice", page.Data[1].MessageType)
+
+	removed, err := queue.RemoveMessages("", cdata.NewFilterParamsFromTuples(
+		"dead_letter", true,
+		"message_type", "invoice",
+	))
+	assert.Nil(t, err)
+	assert.Len(t, removed, 1)
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	// Dead letters are restored from the log and survive compaction
+	for i := 0; i < 2; i++ {
+		queue = queues.NewFileMessageQueue("TestQueue")
+		queue.Configure(config)
+		err = queue.Open("")
+		assert.Nil(t, err)
+
+		stats, err = queue.ReadStats("")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), stats.MessageCount)
+		assert.Equal(t, int64(1), stats.DeadLetterCount)
+
+		err = queue.Compact("")
+		assert.Nil(t, err)
+		err = queue.Close("")
+		assert.Nil(t, err)
+	}
+
+	queue = queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	removed, err = queue.RemoveMessages("", nil)
+	assert.Nil(t, err)
+	assert.Len(t, removed, 1)
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestFileMessageQueueRemoveMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestQueue.log")
+	config := cconf.NewConfigParamsFromTuples("path", path)
+
+	queue := queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err := queue.Open("")
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		envelope := queues.NewMessageEnvelope("123", "order", []byte{byte('0' + i)})
+		envelope.MessageId = "1"
+		err = queue.Send("", envelope)
+		assert.Nil(t, err)
+	}
+
+	// Only the browsed message is removed
+	page, err := queue.BrowseMessages("", nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, page.Data, 2)
+	removed, err := queue.RemoveMessage("", page.Data[1], false)
+	assert.Nil(t, err)
+	assert.True(t, removed)
+	removed, err = queue.RemoveMessage("", page.Data[1], false)
+	assert.Nil(t, err)
+	assert.False(t, removed)
+	err = queue.Close("")
+	assert.Nil(t, err)
+
+	queue = queues.NewFileMessageQueue("TestQueue")
+	queue.Configure(config)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	page, err = queue.BrowseMessages("", nil, nil)
+	assert.Nil(t, err)
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, "0", page.Data[0].GetMessageAsString())
+	}
+}
@@ -0,0 +1,229 @@
+package test_queues
+
+import (
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentMessageReceiver(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	var processed int32
+	receiver := queues.NewIdempotentMessageReceiver(queues.NewCallbackMessageReceiver(
+		func(message *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+			atomic.AddInt32(&processed, 1)
+			return queue.Complete(message)
+		},
+	), nil)
+
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	for i := 0; i < 3; i++ {
+		duplicate := *envelope
+		queue.Send("", &duplicate)
+	}
+	queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+
+	queue.BeginListen("", receiver)
+	defer queue.EndListen("")
+
+	assert.Eventually(t, func() bool {
+		count, _ := queue.ReadMessageCount()
+		return count == 0 && receiver.SkippedCount() == 2
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&processed))
+
+	stats, _ := queue.ReadStats("")
+	assert.Equal(t, int64(0), stats.LockedCount)
+}
+
+func TestIdempotentMessageReceiverConcurrent(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	var processed int32
+	release := make(chan bool)
+	receiver := queues.NewIdempotentMessageReceiver(queues.NewCallbackMessageReceiver(
+		func(message *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+			atomic.AddInt32(&processed, 1)
+			<-release
+			return queue.Complete(message)
+		},
+	), nil)
+	receiver.SetPostponeDelay(100 * time.Millisecond)
+
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	duplicate := *envelope
+	queue.Send("", envelope)
+	queue.Send("", &duplicate)
+
+	// Two consumers receive the same message at the same time
+	message1, _ := queue.Receive("", 100*time.Millisecond)
+	message2, _ := queue.Receive("", 100*time.Millisecond)
+	done := make(chan error)
+	go func() { done <- receiver.ReceiveMessage(message1, queue) }()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&processed) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The duplicate is postponed while the first consumer processes the message
+	assert.Nil(t, receiver.ReceiveMessage(message2, queue))
+	close(release)
+	assert.Nil(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&processed))
+
+	// When the duplicate comes again it is skipped
+	message2, _ = queue.Receive("", 1000*time.Millisecond)
+	assert.NotNil(t, message2)
+	assert.Nil(t, receiver.ReceiveMessage(message2, queue))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&processed))
+	assert.Equal(t, int64(1), receiver.SkippedCount())
+
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+}
+
+func TestIdempotentMessageReceiverPostponed(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	var processed int32
+	store := queues.NewMemoryProcessedMessageStore()
+	receiver := queues.NewIdempotentMessageReceiver(queues.NewCallbackMessageReceiver(
+		func(message *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+			atomic.AddInt32(&processed, 1)
+			return queue.Complete(message)
+		},
+	), store)
+	receiver.SetPostponeDelay(100 * time.Millisecond)
+
+	// Another consumer claims the message
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	claimed, _ := store.TryMarkProcessing("", envelope.MessageId)
+	assert.True(t, claimed)
+	queue.Send("", envelope)
+
+	queue.BeginListen("", receiver)
+	defer queue.EndListen("")
+
+	// Postponed message is returned into the queue and received again
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&processed))
+
+	// When the other consumer fails, the message is processed
+	assert.Nil(t, store.UnmarkProcessing("", envelope.MessageId))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&processed) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+}
+
+func TestIdempotentMessageReceiverPanic(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	store := queues.NewMemoryProcessedMessageStore()
+	receiver := queues.NewIdempotentMessageReceiver(queues.NewCallbackMessageReceiver(
+		func(message *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+			panic("failed")
+		},
+	), store)
+
+	envelope := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	queue.Send("", envelope)
+	message, _ := queue.Receive("", 100*time.Millisecond)
+	assert.Panics(t, func() { receiver.ReceiveMessage(message, queue) })
+
+	// The claim is released when the inner receiver panics
+	claimed, _ := store.TryMarkProcessing("", envelope.MessageId)
+	assert.True(t, claimed)
+}
+
+func TestMemoryProcessedMessageStore(t *testing.T) {
+	store := queues.NewMemoryProcessedMessageStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"options.ttl", 200,
+	))
+
+	processed, err := store.IsProcessed("", "message1")
+	assert.Nil(t, err)
+	assert.False(t, processed)
+
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	processed, _ = store.IsProcessed("", "message1")
+	assert.True(t, processed)
+
+	time.Sleep(250 * time.Millisecond)
+	processed, _ = store.IsProcessed("", "message1")
+	assert.False(t, processed)
+}
+
+func TestMemoryProcessedMessageStoreClaim(t *testing.T) {
+	store := queues.NewMemoryProcessedMessageStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"options.claim_timeout", 200,
+	))
+
+	// Only one consumer gets the claim
+	claimed, err := store.TryMarkProcessing("", "message1")
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	claimed, _ = store.TryMarkProcessing("", "message1")
+	assert.False(t, claimed)
+	processed, _ := store.IsProcessed("", "message1")
+	assert.False(t, processed)
+
+	// Released claims can be taken again
+	assert.Nil(t, store.UnmarkProcessing("", "message1"))
+	claimed, _ = store.TryMarkProcessing("", "message1")
+	assert.True(t, claimed)
+
+	// Processed messages cannot be claimed
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	claimed, _ = store.TryMarkProcessing("", "message1")
+	assert.False(t, claimed)
+
+	// Claims expire after the timeout
+	claimed, _ = store.TryMarkProcessing("", "message2")
+	assert.True(t, claimed)
+	time.Sleep(250 * time.Millisecond)
+	claimed, _ = store.TryMarkProcessing("", "message2")
+	assert.True(t, claimed)
+}
+
+func TestFileProcessedMessageStore(t *testing.T) {
+	config := cconf.NewConfigParamsFromTuples(
+		"path", filepath.Join(t.TempDir(), "processed.log"),
+	)
+
+	store := queues.NewFileProcessedMessageStore()
+	store.Configure(config)
+	assert.Nil(t, store.Open(""))
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	assert.Nil(t, store.MarkProcessed("", "message2"))
+	assert.Nil(t, store.Close(""))
+
+	// Processed messages are restored after reopening
+	store = queues.NewFileProcessedMessageStore()
+	store.Configure(config)
+	assert.Nil(t, store.Open(""))
+	defer store.Close("")
+
+	processed, err := store.IsProcessed("", "message2")
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	processed, _ = store.IsProcessed("", "message3")
+	assert.False(t, processed)
+}
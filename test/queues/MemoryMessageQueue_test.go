@@ -1,9 +1,13 @@
 package test_queues
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
 	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMemoryMessageQueue(t *testing.T) {
@@ -15,11 +19,340 @@ func TestMemoryMessageQueue(t *testing.T) {
 	queue.Clear("")
 
 	t.Run("MemoryMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("MemoryMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("MemoryMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
 	t.Run("MemoryMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
 	t.Run("MemoryMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
 	t.Run("MemoryMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
 	t.Run("MemoryMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
 	t.Run("MemoryMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
 	t.Run("MemoryMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("MemoryMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("MemoryMessageQueue:Receive Batch", fixture.TestReceiveBatch)
 	t.Run("MemoryMessageQueue:On Message", fixture.TestOnMessage)
 }
+
+func TestMemoryMessageQueueReceiveTimeout(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	start := time.Now()
+	envelope, err := queue.Receive("", 300*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope)
+	assert.Less(t, time.Since(start), 1000*time.Millisecond)
+}
+
+func TestMemoryMessageQueueDeduplication(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	assert.True(t, queue.Capabilities().CanDeduplicate())
+
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"options.dedup_window", 200,
+		"options.dedup_header", "dedup_id",
+	))
+	queue.Open("")
+	defer queue.Close("")
+
+	envelope1 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope2 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope2.MessageId = envelope1.MessageId
+	envelope3 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope3.SetHeader("dedup_id", "order1")
+	envelope4 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope4.SetHeader("dedup_id", "order1")
+
+	for _, envelope := range []*queues.MessageEnvelope{envelope1, envelope2, envelope3, envelope4} {
+		assert.Nil(t, queue.Send("", envelope))
+	}
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(2), count)
+
+	// Abandoned messages are returned to the queue
+	message, _ := queue.Receive("", 1000*time.Millisecond)
+	assert.Nil(t, queue.Abandon(message))
+	count, _ = queue.ReadMessageCount()
+	assert.Equal(t, int64(2), count)
+
+	// Keys are forgotten after the window
+	time.Sleep(250 * time.Millisecond)
+	assert.Nil(t, queue.Send("", envelope2))
+	count, _ = queue.ReadMessageCount()
+	assert.Equal(t, int64(3), count)
+}
+
+func TestMemoryMessageQueueGroups(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	for i, groupId := range []string{"A", "A", "B", "", "A"} {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte{byte('0' + i)})
+		envelope.GroupId = groupId
+		queue.Send("", envelope)
+	}
+
+	// One message per group is in flight, messages without groups are not blocked
+	message1, _ := queue.Receive("", 1000*time.Millisecond)
+	message2, _ := queue.Receive("", 1000*time.Millisecond)
+	message3, _ := queue.Receive("", 1000*time.Millisecond)
+	message4, _ := queue.Receive("", 100*time.Millisecond)
+	assert.Equal(t, "0", message1.GetMessageAsString())
+	assert.Equal(t, "2", message2.GetMessageAsString())
+	assert.Equal(t, "3", message3.GetMessageAsString())
+	assert.Nil(t, message4)
+
+	// Abandoned messages keep their place within the group
+	assert.Nil(t, queue.Abandon(message1))
+	message1, _ = queue.Receive("", 1000*time.Millisecond)
+	assert.Equal(t, "0", message1.GetMessageAsString())
+
+	// The group is released when the message is completed
+	assert.Nil(t, queue.Complete(message1))
+	message4, _ = queue.Receive("", 1000*time.Millisecond)
+	assert.Equal(t, "1", message4.GetMessageAsString())
+	queue.Complete(message4)
+	message5, _ := queue.Receive("", 1000*time.Millisecond)
+	assert.Equal(t, "4", message5.GetMessageAsString())
+	queue.Complete(message5)
+}
+
+func TestMemoryMessageQueueLockTimeout(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples("options.lock_timeout", 300))
+	queue.Open("")
+	defer queue.Close("")
+
+	queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("ABC")))
+
+	// The lock does not depend on the wait timeout
+	message, _ := queue.Receive("", 0)
+	assert.NotNil(t, message)
+	time.Sleep(100 * time.Millisecond)
+	stats, _ := queue.ReadStats("")
+	assert.Equal(t, int64(1), stats.LockedCount)
+	assert.Nil(t, queue.Complete(message))
+}
+
+func TestMemoryMessageQueueGroupLockExpiration(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples("options.lock_timeout", 300))
+	queue.Open("")
+	defer queue.Close("")
+
+	for i := 0; i < 2; i++ {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte{byte('0' + i)})
+		envelope.GroupId = "A"
+		queue.Send("", envelope)
+	}
+
+	// The group lock is renewed together with the message lock
+	message1, _ := queue.Receive("", 300*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Nil(t, queue.RenewLock(message1, 300*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+	message2, _ := queue.Receive("", 0)
+	assert.Nil(t, message2)
+
+	// The group is released when the lock expires
+	time.Sleep(200 * time.Millisecond)
+	message2, _ = queue.Receive("", 0)
+	if assert.NotNil(t, message2) {
+		assert.Equal(t, "1", message2.GetMessageAsString())
+	}
+}
+
+func TestMemoryMessageQueueGroupOrder(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	groups := []string{"A", "B", "C"}
+	for i := 0; i < 30; i++ {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte{byte(i)})
+		envelope.GroupId = groups[i%len(groups)]
+		queue.Send("", envelope)
+	}
+
+	// Concurrent consumers process messages of every group in order
+	var lock sync.Mutex
+	received := map[string][]byte{}
+	var done sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			for {
+				message, _ := queue.Receive("", 300*time.Millisecond)
+				if message == nil {
+					return
+				}
+				lock.Lock()
+				received[message.GroupId] = append(received[message.GroupId], message.Message[0])
+				lock.Unlock()
+				time.Sleep(time.Millisecond)
+				queue.Complete(message)
+			}
+		}()
+	}
+	done.Wait()
+
+	for index, groupId := range groups {
+		assert.Len(t, received[groupId], 10)
+		for i, value := range received[groupId] {
+			assert.Equal(t, byte(index+i*len(groups)), value)
+		}
+	}
+}
+
+func TestMemoryMessageQueueSendBatch(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+	assert.True(t, queue.Capabilities().CanSendAtomicBatch())
+
+	// A batch with an invalid message is not sent at all
+	err := queue.SendBatch("", []*queues.MessageEnvelope{
+		queues.NewMessageEnvelope("123", "Test", []byte("A")),
+		nil,
+	})
+	assert.NotNil(t, err)
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+
+	// Messages sent concurrently are not placed between messages of a batch
+	batch := []*queues.MessageEnvelope{}
+	for i := 0; i < 100; i++ {
+		batch = append(batch, queues.NewMessageEnvelope("123", "Batch", []byte("B")))
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			queue.Send("", queues.NewMessageEnvelope("123", "Single", []byte("S")))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		assert.Nil(t, queue.SendBatch("", batch))
+	}()
+	wg.Wait()
+
+	messages, _ := queue.PeekBatch("", 200)
+	assert.Len(t, messages, 200)
+	first := -1
+	for i, message := range messages {
+		if message.MessageType == "Batch" {
+			if first < 0 {
+				first = i
+			}
+			assert.Equal(t, first+batchIndex(batch, message), i)
+		}
+	}
+}
+
+func batchIndex(batch []*queues.MessageEnvelope, message *queues.MessageEnvelope) int {
+	for i, envelope := range batch {
+		if envelope.MessageId == message.MessageId {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestMemoryMessageQueueReceiveBatchGroups(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	for _, groupId := range []string{"A", "A", "B", "", "B"} {
+		envelope := queues.NewMessageEnvelope("123", "Test", []byte(groupId))
+		envelope.GroupId = groupId
+		queue.Send("", envelope)
+	}
+
+	// Only one message of every group is received at once
+	messages, err := queue.ReceiveBatch("", 10, 1000*time.Millisecond)
+	assert.Nil(t, err)
+	if assert.Len(t, messages, 3) {
+		assert.Equal(t, "A", messages[0].GroupId)
+		assert.Equal(t, "B", messages[1].GroupId)
+		assert.Equal(t, "", messages[2].GroupId)
+	}
+
+	assert.Nil(t, queue.CompleteBatch(messages))
+	messages, _ = queue.ReceiveBatch("", 10, 1000*time.Millisecond)
+	assert.Len(t, messages, 2)
+}
+
+func TestMemoryMessageQueueListenRate(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"options.listen_rate", 20,
+		"options.listen_burst", 2,
+	))
+	queue.Open("")
+	defer queue.Close("")
+	assert.Equal(t, float64(20), queue.RateLimiter().Rate())
+
+	for i := 0; i < 6; i++ {
+		queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("ABC")))
+	}
+
+	receiver := &testCompletingReceiver{}
+	start := time.Now()
+	queue.BeginListen("", receiver)
+	defer queue.EndListen("")
+
+	// 2 messages at once and 4 more at 20 messages per second
+	assert.Eventually(t, func() bool { return receiver.Count() == 6 }, 2000*time.Millisecond, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 180*time.Millisecond)
+	assert.Greater(t, queue.RateLimiter().ThrottledTime(), time.Duration(0))
+
+	// The rate is changed while listening
+	queue.SetListenRate(0, 1)
+	for i := 0; i < 20; i++ {
+		queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("ABC")))
+	}
+	assert.Eventually(t, func() bool { return receiver.Count() == 26 }, 1000*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestMemoryMessageQueueListenRateEmptyPolls(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"options.listen_rate", 0.5,
+		"options.listen_burst", 1,
+	))
+	queue.Open("")
+	defer queue.Close("")
+
+	receiver := &testCompletingReceiver{}
+	queue.BeginListen("", receiver)
+	defer queue.EndListen("")
+
+	// Polls without messages do not take tokens
+	time.Sleep(1200 * time.Millisecond)
+	queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("ABC")))
+	assert.Eventually(t, func() bool { return receiver.Count() == 1 }, 500*time.Millisecond, 10*time.Millisecond)
+	assert.Less(t, queue.RateLimiter().ThrottledTime(), 100*time.Millisecond)
+}
+
+type testCompletingReceiver struct {
+	count int
+	lock  sync.Mutex
+}
+
+func (c *testCompletingReceiver) ReceiveMessage(envelope *queues.MessageEnvelope, queue queues.IMessageQueue) error {
+	c.lock.Lock()
+	c.count++
+	c.lock.Unlock()
+	return queue.Complete(envelope)
+}
+
+func (c *testCompletingReceiver) Count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.count
+}
@@ -0,0 +1,210 @@
+package test_queues
+
+import (
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTransactionQueues(t *testing.T) (*queues.MemoryMessageQueue, *queues.MemoryMessageQueue, *queues.MessageEnvelope) {
+	input := queues.NewMemoryMessageQueue("Input")
+	output := queues.NewMemoryMessageQueue("Output")
+	input.Open("")
+	output.Open("")
+	t.Cleanup(func() {
+		input.Close("")
+		output.Close("")
+	})
+
+	input.Send("", queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+	message, _ := input.Receive("", 1000*time.Millisecond)
+	return input, output, message
+}
+
+func TestMemoryMessageTransactionCommit(t *testing.T) {
+	input, output, message := newTransactionQueues(t)
+	assert.True(t, input.Capabilities().CanTransact())
+
+	var queue queues.ITransactionalMessageQueue = input
+	tx, err := queue.BeginTransaction("")
+	assert.Nil(t, err)
+	memoryTx := tx.(*queues.MemoryMessageTransaction)
+
+	assert.Nil(t, tx.Send("", queues.NewMessageEnvelope("123", "order.accepted", []byte("DEF"))))
+	assert.Nil(t, memoryTx.SendTo("", output, queues.NewMessageEnvelope("123", "invoice", []byte("GHI"))))
+	assert.Nil(t, memoryTx.SendTo("", output, queues.NewMessageEnvelope("123", "invoice", []byte("JKL"))))
+	assert.Nil(t, tx.Complete(message))
+
+	// Staged messages are invisible until commit
+	count, _ := input.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+	count, _ = output.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+	stats, _ := input.ReadStats("")
+	assert.Equal(t, int64(1), stats.LockedCount)
+
+	assert.Nil(t, tx.Commit(""))
+
+	count, _ = input.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+	count, _ = output.ReadMessageCount()
+	assert.Equal(t, int64(2), count)
+	stats, _ = input.ReadStats("")
+	assert.Equal(t, int64(0), stats.LockedCount)
+
+	err = tx.Commit("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "TRANSACTION_CLOSED", err.(*cerr.ApplicationError).Code)
+}
+
+func TestMemoryMessageTransactionRollback(t *testing.T) {
+	input, output, message := newTransactionQueues(t)
+
+	tx, _ := input.BeginTransaction("")
+	tx.(*queues.MemoryMessageTransaction).SendTo("", output, queues.NewMessageEnvelope("123", "invoice", []byte("DEF")))
+	assert.Nil(t, tx.Complete(message))
+	assert.Nil(t, tx.Rollback(""))
+
+	count, _ := output.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+
+	// The message is still locked and can be abandoned
+	stats, _ := input.ReadStats("")
+	assert.Equal(t, int64(1), stats.LockedCount)
+	assert.Nil(t, input.Abandon(message))
+	count, _ = input.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemoryMessageTransactionLockLost(t *testing.T) {
+	input := queues.NewMemoryMessageQueue("Input")
+	input.Configure(cconf.NewConfigParamsFromTuples("options.lock_timeout", 100))
+	input.Open("")
+	defer input.Close("")
+
+	input.Send("", queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+	message, _ := input.Receive("", 100*time.Millisecond)
+
+	tx, _ := input.BeginTransaction("")
+	tx.Send("", queues.NewMessageEnvelope("123", "order.accepted", []byte("DEF")))
+	assert.Nil(t, tx.Complete(message))
+
+	time.Sleep(150 * time.Millisecond)
+	err := tx.Commit("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_LOST", err.(*cerr.ApplicationError).Code)
+
+	count, _ := input.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+
+	// Messages that are not locked cannot be completed
+	tx, _ = input.BeginTransaction("")
+	err = tx.Complete(queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+	assert.NotNil(t, err)
+	assert.Equal(t, "MESSAGE_NOT_LOCKED", err.(*cerr.ApplicationError).Code)
+}
+
+func TestMemoryMessageTransactionNilMessage(t *testing.T) {
+	input, output, _ := newTransactionQueues(t)
+
+	tx, _ := input.BeginTransaction("")
+	err := tx.Send("", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_MESSAGE", err.(*cerr.ApplicationError).Code)
+
+	err = tx.(*queues.MemoryMessageTransaction).SendTo("", nil, queues.NewMessageEnvelope("123", "invoice", []byte("DEF")))
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_QUEUE", err.(*cerr.ApplicationError).Code)
+
+	assert.Nil(t, tx.Commit(""))
+	count, _ := output.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMemoryMessageTransactionConcurrentCommits(t *testing.T) {
+	input, output, _ := newTransactionQueues(t)
+
+	// Transactions between the same queues in opposite directions must not deadlock
+	done := make(chan error, 200)
+	for i := 0; i < 100; i++ {
+		go func() {
+			tx, _ := input.BeginTransaction("")
+			tx.Send("", queues.NewMessageEnvelope("", "order", []byte("A")))
+			tx.(*queues.MemoryMessageTransaction).SendTo("", output, queues.NewMessageEnvelope("", "invoice", []byte("B")))
+			done <- tx.Commit("")
+		}()
+		go func() {
+			tx, _ := output.BeginTransaction("")
+			tx.Send("", queues.NewMessageEnvelope("", "invoice", []byte("C")))
+			tx.(*queues.MemoryMessageTransaction).SendTo("", input, queues.NewMessageEnvelope("", "order", []byte("D")))
+			done <- tx.Commit("")
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		select {
+		case err := <-done:
+			assert.Nil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Commits are deadlocked")
+		}
+	}
+
+	count, _ := input.ReadMessageCount()
+	assert.Equal(t, int64(200), count)
+	count, _ = output.ReadMessageCount()
+	assert.Equal(t, int64(200), count)
+}
+
+func TestMemoryMessageTransactionCompletedTwice(t *testing.T) {
+	input, _, message := newTransactionQueues(t)
+
+	// Two transactions complete the same message
+	tx1, _ := input.BeginTransaction("")
+	tx2, _ := input.BeginTransaction("")
+	assert.Nil(t, tx1.Complete(message))
+	assert.Nil(t, tx2.Complete(message))
+	assert.Nil(t, tx1.Commit(""))
+
+	err := tx2.Commit("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_LOST", err.(*cerr.ApplicationError).Code)
+
+	// The queue stays usable after the failed commit
+	assert.Nil(t, input.Send("", queues.NewMessageEnvelope("123", "order", []byte("DEF"))))
+	count, _ := input.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemoryMessageTransactionCompletedDirectly(t *testing.T) {
+	input, output, message := newTransactionQueues(t)
+
+	tx, _ := input.BeginTransaction("")
+	tx.(*queues.MemoryMessageTransaction).SendTo("", output, queues.NewMessageEnvelope("123", "invoice", []byte("DEF")))
+	assert.Nil(t, tx.Complete(message))
+
+	// The message is completed outside of the transaction
+	assert.Nil(t, input.Complete(message))
+
+	err := tx.Commit("")
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_LOST", err.(*cerr.ApplicationError).Code)
+	count, _ := output.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+
+	// Rollback skips messages that are not locked anymore
+	input.Send("", queues.NewMessageEnvelope("123", "order", []byte("GHI")))
+	message, _ = input.Receive("", 100*time.Millisecond)
+	tx, _ = input.BeginTransaction("")
+	assert.Nil(t, tx.Complete(message))
+	assert.Nil(t, input.Abandon(message))
+	assert.Nil(t, tx.Rollback(""))
+
+	// Both queues are not deadlocked
+	assert.Nil(t, output.Send("", queues.NewMessageEnvelope("123", "invoice", []byte("JKL"))))
+	count, _ = input.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,148 @@
+package test_queues
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBatchReceiver struct {
+	batches [][]*queues.MessageEnvelope
+	fail    bool
+	lock    sync.Mutex
+}
+
+func (c *testBatchReceiver) ReceiveMessages(messages []*queues.MessageEnvelope, queue queues.IMessageQueue) error {
+	c.lock.Lock()
+	c.batches = append(c.batches, messages)
+	fail := c.fail
+	c.fail = false
+	c.lock.Unlock()
+
+	if fail {
+		queue.AbandonBatch(messages)
+		return errors.New("database is not available")
+	}
+	return queue.CompleteBatch(messages)
+}
+
+func (c *testBatchReceiver) Batches() [][]*queues.MessageEnvelope {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.batches
+}
+
+func TestMessageBatchListener(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	batch := []*queues.MessageEnvelope{}
+	for i := 0; i < 5; i++ {
+		batch = append(batch, queues.NewMessageEnvelope("123", "Test", []byte("ABC")))
+	}
+	queue.SendBatch("", batch)
+
+	receiver := &testBatchReceiver{fail: true}
+	listener := queues.NewMessageBatchListener(queue, receiver)
+	listener.Configure(cconf.NewConfigParamsFromTuples(
+		"options.batch_size", 3,
+		"options.wait_timeout", 500,
+	))
+	listener.BeginListen("")
+	defer listener.EndListen("")
+
+	assert.Eventually(t, func() bool {
+		count, _ := queue.ReadMessageCount()
+		stats, _ := queue.ReadStats("")
+		return count == 0 && stats.LockedCount == 0
+	}, 3000*time.Millisecond, 50*time.Millisecond)
+
+	// The failed batch is abandoned and received again
+	batches := receiver.Batches()
+	if assert.Len(t, batches, 3) {
+		assert.Len(t, batches[0], 3)
+		total := 0
+		for _, messages := range batches[1:] {
+			assert.LessOrEqual(t, len(messages), 3)
+			total += len(messages)
+		}
+		assert.Equal(t, 5, total)
+	}
+}
+
+type failingBatchQueue struct {
+	*queues.MemoryMessageQueue
+	attempts int32
+}
+
+func (c *failingBatchQueue) ReceiveBatch(correlationId string, messageCount int64, waitTimeout time.Duration) ([]*queues.MessageEnvelope, error) {
+	atomic.AddInt32(&c.attempts, 1)
+	return nil, errors.New("connection is lost")
+}
+
+func TestMessageBatchListenerReceiveError(t *testing.T) {
+	queue := &failingBatchQueue{MemoryMessageQueue: queues.NewMemoryMessageQueue("TestQueue")}
+
+	listener := queues.NewMessageBatchListener(queue, &testBatchReceiver{})
+	listener.Configure(cconf.NewConfigParamsFromTuples(
+		"options.wait_timeout", 100,
+	))
+	listener.BeginListen("")
+	time.Sleep(350 * time.Millisecond)
+	listener.EndListen("")
+
+	// The listener waits between attempts instead of spinning
+	attempts := atomic.LoadInt32(&queue.attempts)
+	assert.GreaterOrEqual(t, attempts, int32(2))
+	assert.LessOrEqual(t, attempts, int32(5))
+}
+
+type panicBatchReceiver struct {
+	testBatchReceiver
+	panicked int32
+}
+
+func (c *panicBatchReceiver) ReceiveMessages(messages []*queues.MessageEnvelope, queue queues.IMessageQueue) error {
+	if atomic.CompareAndSwapInt32(&c.panicked, 0, 1) {
+		panic("receiver is broken")
+	}
+	return c.testBatchReceiver.ReceiveMessages(messages, queue)
+}
+
+func TestMessageBatchListenerPanic(t *testing.T) {
+	queue := queues.NewMemoryMessageQueue("TestQueue")
+	queue.Open("")
+	defer queue.Close("")
+
+	queue.SendBatch("", []*queues.MessageEnvelope{
+		queues.NewMessageEnvelope("123", "Test", []byte("ABC")),
+		queues.NewMessageEnvelope("123", "Test", []byte("DEF")),
+	})
+
+	receiver := &panicBatchReceiver{}
+	listener := queues.NewMessageBatchListener(queue, receiver)
+	listener.Configure(cconf.NewConfigParamsFromTuples(
+		"options.wait_timeout", 100,
+	))
+	listener.BeginListen("")
+	defer listener.EndListen("")
+
+	// The batch is abandoned after the panic and received again long before its lock expires
+	assert.Eventually(t, func() bool {
+		count, _ := queue.ReadMessageCount()
+		stats, _ := queue.ReadStats("")
+		return count == 0 && stats.LockedCount == 0
+	}, 3000*time.Millisecond, 50*time.Millisecond)
+
+	batches := receiver.Batches()
+	if assert.Len(t, batches, 1) {
+		assert.Len(t, batches[0], 2)
+	}
+}
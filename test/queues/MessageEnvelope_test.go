@@ -33,10 +33,42 @@ func (c *messageEnvelopeTest) TestSerializeMessage(t *testing.T) {
 	assert.Equal(t, message.CorrelationId, message2.CorrelationId)
 	assert.Equal(t, message.MessageType, message2.MessageType)
 	assert.Equal(t, message.Message, message2.Message)
+	assert.Nil(t, message2.Headers)
+}
+
+func (c *messageEnvelopeTest) TestSerializeHeaders(t *testing.T) {
+	message := queues.NewMessageEnvelope("123", "TestMessage", []byte("This is a test message"))
+	assert.Equal(t, "", message.GetHeader("key"))
+
+	message.SetHeader("key", "value1")
+	assert.Equal(t, "value1", message.GetHeader("key"))
+
+	buffer, err := json.Marshal(message)
+	assert.Nil(t, err)
+
+	message2 := queues.NewEmptyMessageEnvelope()
+	err = json.Unmarshal(buffer, message2)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key": "value1"}, message2.Headers)
+}
+
+func (c *messageEnvelopeTest) TestSerializeGroupId(t *testing.T) {
+	message := queues.NewMessageEnvelope("123", "TestMessage", []byte("This is a test message"))
+	message.GroupId = "order1"
+
+	buffer, err := json.Marshal(message)
+	assert.Nil(t, err)
+
+	message2 := queues.NewEmptyMessageEnvelope()
+	err = json.Unmarshal(buffer, message2)
+	assert.Nil(t, err)
+	assert.Equal(t, "order1", message2.GroupId)
 }
 
 func TestMessageEnvelop(t *testing.T) {
 	test := NewMessageEnvelopTest()
 
 	t.Run("MessageEnvelop:Serialize Message", test.TestSerializeMessage)
+	t.Run("MessageEnvelop:Serialize Headers", test.TestSerializeHeaders)
+	t.Run("MessageEnvelop:Serialize Group Id", test.TestSerializeGroupId)
 }
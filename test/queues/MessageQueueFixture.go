@@ -108,6 +108,38 @@ func (c *MessageQueueFixture) TestSendPeekMessage(t *testing.T) {
 	assert.Nil(t, rcvErr)
 }
 
+func (c *MessageQueueFixture) TestSendReceiveHeaders(t *testing.T) {
+	envelope1 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope1.SetHeader("tenant", "tenant1")
+	envelope1.SetHeader("trace_id", "abc")
+	sndErr := c.queue.Send("", envelope1)
+	assert.Nil(t, sndErr)
+
+	envelope2, rcvErr := c.queue.Receive("", 10000*time.Millisecond)
+	assert.Nil(t, rcvErr)
+	if assert.NotNil(t, envelope2) {
+		assert.Equal(t, envelope1.Message, envelope2.Message)
+		assert.Equal(t, "tenant1", envelope2.GetHeader("tenant"))
+		assert.Equal(t, "abc", envelope2.GetHeader("trace_id"))
+		assert.Nil(t, c.queue.Complete(envelope2))
+	}
+}
+
+func (c *MessageQueueFixture) TestSendReceiveGroupId(t *testing.T) {
+	envelope1 := queues.NewMessageEnvelope("123", "Test", []byte("Test message"))
+	envelope1.GroupId = "group1"
+	sndErr := c.queue.Send("", envelope1)
+	assert.Nil(t, sndErr)
+
+	envelope2, rcvErr := c.queue.Receive("", 10000*time.Millisecond)
+	assert.Nil(t, rcvErr)
+	if assert.NotNil(t, envelope2) {
+		assert.Equal(t, envelope1.Message, envelope2.Message)
+		assert.Equal(t, "group1", envelope2.GroupId)
+		assert.Nil(t, c.queue.Complete(envelope2))
+	}
+}
+
 func (c *MessageQueueFixture) TestPeekNoMessage(t *testing.T) {
 	envelope, pkErr := c.queue.Peek("")
 	assert.Nil(t, pkErr)
@@ -159,3 +191,62 @@ func (c *TestMsgReceiver) ReceiveMessage(message *queues.MessageEnvelope, queue
 	c.Message = message
 	return nil
 }
+
+func (c *MessageQueueFixture) TestSendBatch(t *testing.T) {
+	envelopes := []*queues.MessageEnvelope{
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 1")),
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 2")),
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 3")),
+	}
+	sndErr := c.queue.SendBatch("", envelopes)
+	assert.Nil(t, sndErr)
+
+	for _, envelope1 := range envelopes {
+		envelope2, rcvErr := c.queue.Receive("", 10000*time.Millisecond)
+		assert.Nil(t, rcvErr)
+		if assert.NotNil(t, envelope2) {
+			assert.Equal(t, envelope1.Message, envelope2.Message)
+			assert.Nil(t, c.queue.Complete(envelope2))
+		}
+	}
+}
+
+func (c *MessageQueueFixture) TestReceiveBatch(t *testing.T) {
+	envelopes := []*queues.MessageEnvelope{
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 1")),
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 2")),
+		queues.NewMessageEnvelope("123", "Test", []byte("Test message 3")),
+	}
+	sndErr := c.queue.SendBatch("", envelopes)
+	assert.Nil(t, sndErr)
+
+	// Remote queues may deliver fewer messages than were sent at the moment
+	messages, rcvErr := c.queue.ReceiveBatch("", 2, 10000*time.Millisecond)
+	assert.Nil(t, rcvErr)
+	assert.NotEmpty(t, messages)
+	assert.LessOrEqual(t, len(messages), 2)
+
+	abnErr := c.queue.AbandonBatch(messages)
+	assert.Nil(t, abnErr)
+
+	received := 0
+	for received < 3 {
+		messages, rcvErr = c.queue.ReceiveBatch("", 10, 10000*time.Millisecond)
+		assert.Nil(t, rcvErr)
+		if !assert.NotEmpty(t, messages) {
+			break
+		}
+		received += len(messages)
+
+		cplErr := c.queue.CompleteBatch(messages)
+		assert.Nil(t, cplErr)
+		for _, message := range messages {
+			assert.Nil(t, message.GetReference())
+		}
+	}
+	assert.Equal(t, 3, received)
+
+	messages, rcvErr = c.queue.ReceiveBatch("", 10, 100*time.Millisecond)
+	assert.Nil(t, rcvErr)
+	assert.Len(t, messages, 0)
+}
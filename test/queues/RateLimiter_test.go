@@ -0,0 +1,52 @@
+package test_queues
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := queues.NewRateLimiter(10, 3)
+
+	// The burst is available at once
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.TryAcquire())
+	}
+	assert.False(t, limiter.TryAcquire())
+
+	// Next tokens come at the configured rate
+	throttled, ok := limiter.Wait(nil)
+	assert.True(t, ok)
+	assert.Greater(t, throttled, 50*time.Millisecond)
+	assert.Less(t, throttled, 200*time.Millisecond)
+	assert.Equal(t, throttled, limiter.ThrottledTime())
+
+	// No limit
+	limiter.SetRate(0, 1)
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.TryAcquire())
+	}
+}
+
+func TestRateLimiterChangeRate(t *testing.T) {
+	limiter := queues.NewRateLimiter(0.1, 1)
+	assert.True(t, limiter.TryAcquire())
+
+	// Waiting follows the new rate
+	time.AfterFunc(100*time.Millisecond, func() {
+		limiter.SetRate(100, 1)
+	})
+	start := time.Now()
+	_, ok := limiter.Wait(nil)
+	assert.True(t, ok)
+	assert.Less(t, time.Since(start), 1000*time.Millisecond)
+
+	// Waiting can be cancelled
+	limiter.SetRate(0.1, 1)
+	limiter.TryAcquire()
+	_, ok = limiter.Wait(func() bool { return true })
+	assert.False(t, ok)
+}
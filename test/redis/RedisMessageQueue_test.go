@@ -0,0 +1,149 @@
+package test_redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cerr "github.com/pip-services3-go/pip-services3-commons-go/errors"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/redis"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueue(server *miniredis.Miniredis, tuples ...interface{}) *redis.RedisMessageQueue {
+	queue := redis.NewRedisMessageQueue("TestQueue")
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.host", server.Host(),
+		"connection.port", server.Port(),
+	)
+	config = config.Override(cconf.NewConfigParamsFromTuples(tuples...))
+	queue.Configure(config)
+	return queue
+}
+
+func TestRedisMessageQueue(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	queue := newTestQueue(server)
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("RedisMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("RedisMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("RedisMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("RedisMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("RedisMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("RedisMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("RedisMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("RedisMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("RedisMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("RedisMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("RedisMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("RedisMessageQueue:On Message", fixture.TestOnMessage)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestRedisMessageQueueRedelivery(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	queue1 := newTestQueue(server, "options.consumer", "consumer1", "options.lock_timeout", 300)
+	err := queue1.Open("")
+	assert.Nil(t, err)
+	defer queue1.Close("")
+
+	queue2 := newTestQueue(server, "options.consumer", "consumer2", "options.lock_timeout", 300)
+	err = queue2.Open("")
+	assert.Nil(t, err)
+	defer queue2.Close("")
+
+	err = queue1.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope1, err := queue1.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+
+	count, err := queue1.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// Renewed lock keeps the message invisible
+	time.Sleep(200 * time.Millisecond)
+	err = queue1.RenewLock(envelope1, 0)
+	assert.Nil(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	envelope2, err := queue2.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope2)
+
+	// Expired lock makes the message available to other consumers
+	time.Sleep(200 * time.Millisecond)
+
+	envelope2, err = queue2.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+	assert.Equal(t, envelope1.MessageId, envelope2.MessageId)
+	assert.Equal(t, "Test message", envelope2.GetMessageAsString())
+
+	// Lock cannot be renewed by the consumer that lost it
+	err = queue1.RenewLock(envelope1, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, "LOCK_LOST", err.(*cerr.ApplicationError).Code)
+
+	// Shorter lock expires before the lock timeout
+	err = queue2.RenewLock(envelope2, 100*time.Millisecond)
+	assert.Nil(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	envelope1, err = queue1.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+
+	err = queue1.Complete(envelope1)
+	assert.Nil(t, err)
+
+	count, err = queue2.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRedisMessageQueueMaxDeliveries(t *testing.T) {
+	server := miniredis.RunT(t)
+
+	queue := newTestQueue(server, "options.lock_timeout", 100, "options.max_deliveries", 2)
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+
+	time.Sleep(150 * time.Millisecond)
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope)
+
+	time.Sleep(150 * time.Millisecond)
+	envelope, err = queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+}
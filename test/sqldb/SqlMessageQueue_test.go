@@ -0,0 +1,162 @@
+package test_sqldb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/sqldb"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSqlMessageQueue(t *testing.T, config *cconf.ConfigParams) {
+	queue := sqldb.NewSqlMessageQueue("TestQueue")
+	queue.Configure(config)
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+	queue.Clear("")
+
+	t.Run("SqlMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("SqlMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("SqlMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("SqlMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("SqlMessageQueue:Receive And Complete Message", fixture.TestReceiveCompleteMessage)
+	t.Run("SqlMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("SqlMessageQueue:Send Peek Message", fixture.TestSendPeekMessage)
+	t.Run("SqlMessageQueue:Peek No Message", fixture.TestPeekNoMessage)
+	t.Run("SqlMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("SqlMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("SqlMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("SqlMessageQueue:On Message", fixture.TestOnMessage)
+
+	messages, err := queue.ReadDeadLetters("")
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestSqliteMessageQueue(t *testing.T) {
+	testSqlMessageQueue(t, cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "sqlite",
+		"connection.database", filepath.Join(t.TempDir(), "queues.db"),
+	))
+}
+
+func TestPostgresMessageQueue(t *testing.T) {
+	host := os.Getenv("POSTGRES_SERVICE_HOST")
+	if host == "" {
+		t.Skip("POSTGRES_SERVICE_HOST is not set")
+	}
+
+	port := os.Getenv("POSTGRES_SERVICE_PORT")
+	if port == "" {
+		port = "5432"
+	}
+	database := os.Getenv("POSTGRES_DB")
+	if database == "" {
+		database = "test"
+	}
+	user := os.Getenv("POSTGRES_USER")
+	if user == "" {
+		user = "postgres"
+	}
+	password := os.Getenv("POSTGRES_PASSWORD")
+	if password == "" {
+		password = "postgres"
+	}
+
+	testSqlMessageQueue(t, cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "postgres",
+		"connection.host", host,
+		"connection.port", port,
+		"connection.database", database,
+		"connection.ssl_mode", "disable",
+		"credential.username", user,
+		"credential.password", password,
+	))
+}
+
+func TestSqlMessageQueueLockExpiration(t *testing.T) {
+	queue := sqldb.NewSqlMessageQueue("TestQueue")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.database", filepath.Join(t.TempDir(), "queues.db"),
+		"options.lock_timeout", 500,
+	))
+	err := queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	envelope1, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope1)
+
+	envelope2, err := queue.Receive("", 0)
+	assert.Nil(t, err)
+	assert.Nil(t, envelope2)
+
+	envelope2, err = queue.Receive("", 1000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, envelope2)
+
+	// The expired lock cannot complete the message anymore
+	err = queue.Complete(envelope1)
+	assert.Nil(t, err)
+	err = queue.Abandon(envelope2)
+	assert.Nil(t, err)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSqlConnection(t *testing.T) {
+	connection := sqldb.NewSqlConnection()
+	connection.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "sqlite",
+		"connection.database", filepath.Join(t.TempDir(), "queues.db"),
+	))
+	err := connection.Open("")
+	assert.Nil(t, err)
+	defer connection.Close("")
+
+	references := cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "connection", "sql", "default", "1.0"), connection,
+	)
+
+	queue := sqldb.NewSqlMessageQueue("queue1")
+	queue.SetReferences(references)
+	err = queue.Open("")
+	assert.Nil(t, err)
+	defer queue.Close("")
+
+	err = connection.CreateQueue("queue2")
+	assert.Nil(t, err)
+
+	names, err := connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue1", "queue2"}, names)
+
+	err = queue.Send("", queues.NewMessageEnvelope("123", "Test", []byte("Test message")))
+	assert.Nil(t, err)
+
+	err = connection.DeleteQueue("queue1")
+	assert.Nil(t, err)
+
+	names, err = connection.ReadQueueNames()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue2"}, names)
+
+	count, err := queue.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
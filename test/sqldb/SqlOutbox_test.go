@@ -0,0 +1,229 @@
+package test_sqldb
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	cref "github.com/pip-services3-go/pip-services3-commons-go/refer"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/sqldb"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingQueue fails to send a given number of messages.
+type failingQueue struct {
+	*queues.MemoryMessageQueue
+	failures int32
+}
+
+func (c *failingQueue) Send(correlationId string, envelope *queues.MessageEnvelope) error {
+	if atomic.AddInt32(&c.failures, -1) >= 0 {
+		return errors.New("queue is not available")
+	}
+	return c.MemoryMessageQueue.Send(correlationId, envelope)
+}
+
+func newTestOutbox(t *testing.T) *sqldb.SqlOutbox {
+	connection := sqldb.NewSqlConnection()
+	connection.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "sqlite",
+		"connection.database", filepath.Join(t.TempDir(), "service.db"),
+	))
+	assert.Nil(t, connection.Open(""))
+	t.Cleanup(func() { connection.Close("") })
+
+	_, err := connection.GetDB().Exec("CREATE TABLE orders (id VARCHAR(50) PRIMARY KEY, status VARCHAR(50))")
+	assert.Nil(t, err)
+
+	outbox := sqldb.NewSqlOutbox()
+	outbox.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "connection", "sql", "default", "1.0"), connection,
+	))
+	assert.Nil(t, outbox.Open(""))
+	t.Cleanup(func() { outbox.Close("") })
+
+	return outbox
+}
+
+func addOrder(t *testing.T, outbox *sqldb.SqlOutbox, id string, queue string, commit bool) {
+	tx, err := outbox.Connection.GetDB().Begin()
+	assert.Nil(t, err)
+
+	_, err = tx.Exec("INSERT INTO orders (id, status) VALUES (?, ?)", id, "accepted")
+	assert.Nil(t, err)
+
+	envelope := queues.NewMessageEnvelope("123", "order.accepted", []byte(id))
+	envelope.SetHeader("tenant", "tenant1")
+	envelope.GroupId = "orders"
+	assert.Nil(t, outbox.Add("123", tx, queue, envelope))
+
+	if commit {
+		assert.Nil(t, tx.Commit())
+	} else {
+		assert.Nil(t, tx.Rollback())
+	}
+}
+
+func TestSqliteOutbox(t *testing.T) {
+	outbox := newTestOutbox(t)
+
+	addOrder(t, outbox, "1", "orders", true)
+	addOrder(t, outbox, "2", "orders", false)
+	addOrder(t, outbox, "3", "orders", true)
+
+	// Messages of rolled back transactions are not stored
+	count, err := outbox.ReadMessageCount()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	queue := queues.NewMemoryMessageQueue("orders")
+	queue.Open("")
+	defer queue.Close("")
+
+	relay := sqldb.NewSqlOutboxRelay()
+	relay.SetOutbox(outbox)
+	relay.SetQueue(queue)
+
+	sent, err := relay.Relay("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), sent)
+
+	messages, _ := queue.PeekBatch("", 10)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "1", messages[0].GetMessageAsString())
+		assert.Equal(t, "3", messages[1].GetMessageAsString())
+		assert.Equal(t, "tenant1", messages[0].GetHeader("tenant"))
+		assert.Equal(t, "orders", messages[0].GroupId)
+	}
+
+	count, _ = outbox.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+}
+
+func TestSqliteOutboxRetries(t *testing.T) {
+	outbox := newTestOutbox(t)
+	addOrder(t, outbox, "1", "orders", true)
+	addOrder(t, outbox, "2", "orders", true)
+	addOrder(t, outbox, "3", "invoices", true)
+
+	queue := &failingQueue{MemoryMessageQueue: queues.NewMemoryMessageQueue("orders"), failures: 2}
+	invoices := queues.NewMemoryMessageQueue("invoices")
+
+	relay := sqldb.NewSqlOutboxRelay()
+	relay.Configure(cconf.NewConfigParamsFromTuples(
+		"options.retry_timeout", 100,
+	))
+	relay.SetOutbox(outbox)
+	relay.SetQueue(queue)
+	relay.SetQueue(invoices)
+
+	// The failed message blocks later messages to the same queue only
+	sent, err := relay.Relay("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), sent)
+	count, _ := invoices.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+
+	// The message is not retried before the retry timeout
+	sent, _ = relay.Relay("")
+	assert.Equal(t, int64(0), sent)
+
+	time.Sleep(150 * time.Millisecond)
+	sent, _ = relay.Relay("")
+	assert.Equal(t, int64(0), sent)
+
+	// The second retry waits twice longer
+	time.Sleep(250 * time.Millisecond)
+	sent, _ = relay.Relay("")
+	assert.Equal(t, int64(2), sent)
+
+	messages, _ := queue.PeekBatch("", 10)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "1", messages[0].GetMessageAsString())
+		assert.Equal(t, "2", messages[1].GetMessageAsString())
+	}
+}
+
+func TestSqliteOutboxBlockedQueue(t *testing.T) {
+	outbox := newTestOutbox(t)
+	for i := 0; i < 3; i++ {
+		addOrder(t, outbox, strconv.Itoa(i), "unknown", true)
+	}
+	addOrder(t, outbox, "3", "orders", true)
+
+	queue := queues.NewMemoryMessageQueue("orders")
+
+	relay := sqldb.NewSqlOutboxRelay()
+	relay.Configure(cconf.NewConfigParamsFromTuples(
+		"options.batch_size", 2,
+		"options.retry_timeout", 100,
+		"options.max_attempts", 2,
+	))
+	relay.SetOutbox(outbox)
+	relay.SetQueue(queue)
+
+	// The queue without a target fills the whole batch
+	sent, err := relay.Relay("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), sent)
+
+	// Messages to the queue that waits for a retry are not read
+	sent, err = relay.Relay("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), sent)
+	count, _ := queue.ReadMessageCount()
+	assert.Equal(t, int64(1), count)
+
+	// Messages are kept as dead letters after the last attempt
+	time.Sleep(150 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		sent, err = relay.Relay("")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), sent)
+		time.Sleep(150 * time.Millisecond)
+	}
+	count, _ = outbox.ReadMessageCount()
+	assert.Equal(t, int64(0), count)
+	count, _ = outbox.ReadDeadLetterCount()
+	assert.Equal(t, int64(3), count)
+
+	relay.SetQueue(queues.NewMemoryMessageQueue("unknown"))
+	count, err = outbox.RedriveDeadLetters("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	sent, err = relay.Relay("")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), sent)
+}
+
+func TestSqliteOutboxRelayInBackground(t *testing.T) {
+	outbox := newTestOutbox(t)
+
+	queue := queues.NewMemoryMessageQueue("orders")
+	queue.Open("")
+	defer queue.Close("")
+
+	relay := sqldb.NewSqlOutboxRelay()
+	relay.Configure(cconf.NewConfigParamsFromTuples(
+		"options.interval", 50,
+	))
+	relay.SetReferences(cref.NewReferencesFromTuples(
+		cref.NewDescriptor("pip-services", "outbox", "sql", "default", "1.0"), outbox,
+		cref.NewDescriptor("pip-services", "message-queue", "memory", "orders", "1.0"), queue,
+	))
+	assert.Nil(t, relay.Open(""))
+	defer relay.Close("")
+
+	addOrder(t, outbox, "1", "orders", true)
+
+	message, err := queue.Receive("", 2000*time.Millisecond)
+	assert.Nil(t, err)
+	if assert.NotNil(t, message) {
+		assert.Equal(t, "1", message.GetMessageAsString())
+	}
+}
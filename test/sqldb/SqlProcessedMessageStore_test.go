@@ -0,0 +1,88 @@
+package test_sqldb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/sqldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqliteProcessedMessageStore(t *testing.T) {
+	store := sqldb.NewSqlProcessedMessageStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "sqlite",
+		"connection.database", filepath.Join(t.TempDir(), "processed.db"),
+		"options.ttl", 500,
+	))
+	assert.Nil(t, store.Open(""))
+	defer store.Close("")
+
+	processed, err := store.IsProcessed("", "message1")
+	assert.Nil(t, err)
+	assert.False(t, processed)
+
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	processed, err = store.IsProcessed("", "message1")
+	assert.Nil(t, err)
+	assert.True(t, processed)
+
+	time.Sleep(600 * time.Millisecond)
+	processed, _ = store.IsProcessed("", "message1")
+	assert.False(t, processed)
+
+	// Expired messages are deleted and can be marked again
+	assert.Nil(t, store.MarkProcessed("", "message2"))
+	processed, _ = store.IsProcessed("", "message2")
+	assert.True(t, processed)
+
+	assert.Nil(t, store.Clear(""))
+	processed, _ = store.IsProcessed("", "message2")
+	assert.False(t, processed)
+}
+
+func TestSqliteProcessedMessageStoreClaim(t *testing.T) {
+	store := sqldb.NewSqlProcessedMessageStore()
+	store.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.protocol", "sqlite",
+		"connection.database", filepath.Join(t.TempDir(), "processed.db"),
+		"options.claim_timeout", 300,
+	))
+	assert.Nil(t, store.Open(""))
+	defer store.Close("")
+
+	// Only one consumer gets the claim
+	claimed, err := store.TryMarkProcessing("", "message1")
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	claimed, err = store.TryMarkProcessing("", "message1")
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+	processed, _ := store.IsProcessed("", "message1")
+	assert.False(t, processed)
+
+	// Released claims can be taken again
+	assert.Nil(t, store.UnmarkProcessing("", "message1"))
+	claimed, _ = store.TryMarkProcessing("", "message1")
+	assert.True(t, claimed)
+
+	// Processed messages cannot be claimed
+	assert.Nil(t, store.MarkProcessed("", "message1"))
+	processed, _ = store.IsProcessed("", "message1")
+	assert.True(t, processed)
+	claimed, _ = store.TryMarkProcessing("", "message1")
+	assert.False(t, claimed)
+	assert.Nil(t, store.UnmarkProcessing("", "message1"))
+	processed, _ = store.IsProcessed("", "message1")
+	assert.True(t, processed)
+
+	// Claims expire after the timeout
+	claimed, _ = store.TryMarkProcessing("", "message2")
+	assert.True(t, claimed)
+	time.Sleep(400 * time.Millisecond)
+	claimed, _ = store.TryMarkProcessing("", "message2")
+	assert.True(t, claimed)
+}
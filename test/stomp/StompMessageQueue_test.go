@@ -0,0 +1,198 @@
+package test_stomp
+
+import (
+	"testing"
+	"time"
+
+	gostomp "github.com/go-stomp/stomp/v3"
+	cconf "github.com/pip-services3-go/pip-services3-commons-go/config"
+	"github.com/pip-services3-go/pip-services3-messaging-go/queues"
+	"github.com/pip-services3-go/pip-services3-messaging-go/stomp"
+	test_queues "github.com/pip-services3-go/pip-services3-messaging-go/test/queues"
+	"github.com/stretchr/testify/assert"
+)
+
+func startServer(t *testing.T, tuples ...interface{}) *stomp.StompServer {
+	server := stomp.NewStompServer()
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.host", "127.0.0.1",
+		"connection.port", 0,
+	)
+	server.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	err := server.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close("") })
+	return server
+}
+
+func newTestQueue(t *testing.T, server *stomp.StompServer, name string, tuples ...interface{}) *stomp.StompMessageQueue {
+	queue := stomp.NewStompMessageQueue(name)
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.uri", "stomp://"+server.GetAddress(),
+	)
+	queue.Configure(config.Override(cconf.NewConfigParamsFromTuples(tuples...)))
+	err := queue.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { queue.Close("") })
+	return queue
+}
+
+func readCount(queue *queues.MemoryMessageQueue, expected int64) int64 {
+	count := int64(-1)
+	for i := 0; i < 50 && count != expected; i++ {
+		count, _ = queue.ReadMessageCount()
+		if count != expected {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	return count
+}
+
+func TestStompMessageQueue(t *testing.T) {
+	server := startServer(t)
+	queue := newTestQueue(t, server, "TestQueue")
+
+	fixture := test_queues.NewMessageQueueFixture(queue)
+
+	// STOMP cannot count or peek messages
+	t.Run("StompMessageQueue:Send Receive Message", fixture.TestSendReceiveMessage)
+	t.Run("StompMessageQueue:Send Receive Headers", fixture.TestSendReceiveHeaders)
+	t.Run("StompMessageQueue:Send Receive Group", fixture.TestSendReceiveGroupId)
+	t.Run("StompMessageQueue:Receive Send Message", fixture.TestReceiveSendMessage)
+	t.Run("StompMessageQueue:Receive And Abandon Message", fixture.TestReceiveAbandonMessage)
+	t.Run("StompMessageQueue:Move To Dead Message", fixture.TestMoveToDeadMessage)
+	t.Run("StompMessageQueue:Send Batch", fixture.TestSendBatch)
+	t.Run("StompMessageQueue:Receive Batch", fixture.TestReceiveBatch)
+	t.Run("StompMessageQueue:On Message", fixture.TestOnMessage)
+}
+
+func TestStompInteroperability(t *testing.T) {
+	server := startServer(t)
+	memoryQueue := server.GetQueue("orders")
+	queue := newTestQueue(t, server, "orders")
+
+	// Go service sends into the memory queue, STOMP component receives
+	envelope := queues.NewMessageEnvelope("123", "order", []byte("ABC"))
+	envelope.SetHeader("tenant", "tenant1")
+	err := memoryQueue.Send("", envelope)
+	assert.Nil(t, err)
+
+	message, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, message)
+	assert.Equal(t, envelope.MessageId, message.MessageId)
+	assert.Equal(t, "123", message.CorrelationId)
+	assert.Equal(t, "order", message.MessageType)
+	assert.Equal(t, "tenant1", message.GetHeader("tenant"))
+	assert.Equal(t, []byte("ABC"), message.Message)
+
+	// Message stays locked until it is acknowledged
+	assert.Equal(t, int64(0), readCount(memoryQueue, 0))
+	err = queue.Complete(message)
+	assert.Nil(t, err)
+	assert.Nil(t, message.GetReference())
+
+	// STOMP component sends, Go service receives
+	err = queue.Send("", queues.NewMessageEnvelope("456", "invoice", []byte("DEF")))
+	assert.Nil(t, err)
+
+	received, err := memoryQueue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, received)
+	assert.Equal(t, "456", received.CorrelationId)
+	assert.Equal(t, "invoice", received.MessageType)
+	memoryQueue.Complete(received)
+}
+
+func TestStompNackAndDisconnect(t *testing.T) {
+	server := startServer(t)
+	memoryQueue := server.GetQueue("orders")
+	memoryQueue.Send("", queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+
+	queue := newTestQueue(t, server, "orders")
+	message, err := queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, message)
+
+	// NACK returns the message that is delivered again
+	err = queue.Abandon(message)
+	assert.Nil(t, err)
+	message, err = queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	assert.NotNil(t, message)
+
+	// Unacknowledged message returns into the queue when the client disconnects
+	queue.Close("")
+	assert.Equal(t, int64(1), readCount(memoryQueue, 1))
+
+	// Dead letters are sent to the dead letter destination
+	queue = newTestQueue(t, server, "orders")
+	message, err = queue.Receive("", 5000*time.Millisecond)
+	assert.Nil(t, err)
+	if !assert.NotNil(t, message) {
+		t.FailNow()
+	}
+	err = queue.MoveToDeadLetter(message)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), readCount(memoryQueue, 0))
+	assert.Equal(t, int64(1), readCount(server.GetQueue("orders.dead"), 1))
+}
+
+func TestStompClientAckMode(t *testing.T) {
+	server := startServer(t)
+	memoryQueue := server.GetQueue("orders")
+	for i := 0; i < 3; i++ {
+		memoryQueue.Send("", queues.NewMessageEnvelope("123", "order", []byte("ABC")))
+	}
+
+	conn, err := gostomp.Dial("tcp", server.GetAddress(), gostomp.ConnOpt.HeartBeat(0, 0))
+	assert.Nil(t, err)
+	defer conn.Disconnect()
+
+	subscription, err := conn.Subscribe("/queue/orders", gostomp.AckClient,
+		gostomp.SubscribeOpt.Header("prefetch-count", "3"))
+	assert.Nil(t, err)
+
+	messages := []*gostomp.Message{}
+	for len(messages) < 3 {
+		select {
+		case msg := <-subscription.C:
+			assert.Nil(t, msg.Err)
+			messages = append(messages, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Messages were not delivered")
+		}
+	}
+
+	// Cumulative ACK completes all messages up to the acknowledged one
+	err = conn.Ack(messages[1])
+	assert.Nil(t, err)
+	err = subscription.Unsubscribe()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), readCount(memoryQueue, 1))
+}
+
+func TestStompAuthentication(t *testing.T) {
+	server := startServer(t,
+		"credential.username", "user",
+		"credential.password", "pass",
+	)
+
+	queue := stomp.NewStompMessageQueue("orders")
+	queue.Configure(cconf.NewConfigParamsFromTuples(
+		"connection.uri", "stomp://"+server.GetAddress(),
+		"credential.username", "user",
+		"credential.password", "wrong",
+	))
+	err := queue.Open("")
+	assert.NotNil(t, err)
+
+	newTestQueue(t, server, "orders",
+		"credential.username", "user",
+		"credential.password", "pass",
+	)
+}